@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+)
+
+// CityQueryOptions mirrors the server's CityQueryOptions.
+type CityQueryOptions struct {
+	WithCountry   bool
+	WithContinent bool
+
+	CountryUuids   []string
+	CityUuids      []string
+	ContinentTypes []pkg_v1.ContinentType
+
+	// only capitals, or only other cities, when set
+	IsCapital *bool
+	// cities of at least that population when set
+	MinPopulation *int64
+
+	Deleted bool
+
+	// Fields narrows the response, like "uuid" or "details.iso_code";
+	// Expand supersedes the With* flags when set
+	Fields []string
+	Expand []string
+
+	// AND-ed, like {"details.currency", "eq", "EUR"}
+	Filters []Filter
+
+	// like "-population" or "name", earlier fields take precedence
+	Sort []string
+
+	// 0 limit lists every row, iterators page by DefaultPageSize instead
+	Limit  int
+	Offset int
+}
+
+func (options CityQueryOptions) values() url.Values {
+	values := url.Values{}
+	setBool(values, "with_country", options.WithCountry)
+	setBool(values, "with_continent", options.WithContinent)
+	setBool(values, "deleted", options.Deleted)
+	setList(values, "fields", options.Fields)
+	setList(values, "expand", options.Expand)
+	setFilters(values, options.Filters)
+	setList(values, "sort", options.Sort)
+	setList(values, "countries", options.CountryUuids)
+	setList(values, "cities", options.CityUuids)
+	if options.IsCapital != nil {
+		values.Set("is_capital", strconv.FormatBool(*options.IsCapital))
+	}
+	if options.MinPopulation != nil {
+		values.Set("min_population", strconv.FormatInt(*options.MinPopulation, 10))
+	}
+	setContinentTypes(values, "continent_types", options.ContinentTypes)
+	setPage(values, options.Limit, options.Offset)
+	return values
+}
+
+func (c *Client) ListCities(ctx context.Context, options CityQueryOptions) ([]*pkg_v1.City, error) {
+	results := []*pkg_v1.City{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/cities", query: options.values()}, &results)
+	return results, err
+}
+
+func (c *Client) GetCity(ctx context.Context, uuid string) (*pkg_v1.City, error) {
+	if len(uuid) == 0 {
+		return nil, errEmptyUuid
+	}
+	result := &pkg_v1.City{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/city", query: uuidQuery(uuid)}, result)
+	return result, err
+}
+
+func (c *Client) CreateCity(ctx context.Context, city *pkg_v1.City) (*pkg_v1.City, error) {
+	result := &pkg_v1.City{}
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/city/create", body: city, idempotent: true}, result)
+	return result, err
+}
+
+func (c *Client) UpdateCity(ctx context.Context, city *pkg_v1.City) (*pkg_v1.City, error) {
+	result := &pkg_v1.City{}
+	_, err := c.do(ctx, request{method: http.MethodPut, path: "/api/v1/city/update", body: city}, result)
+	return result, err
+}
+
+// UpsertCity updates the city with city.Uuid or creates it.
+func (c *Client) UpsertCity(ctx context.Context, city *pkg_v1.City) (*pkg_v1.City, bool, error) {
+	result := &pkg_v1.City{}
+	status, err := c.do(ctx, request{method: http.MethodPut, path: "/api/v1/city", body: city}, result)
+	return result, status == http.StatusCreated, err
+}
+
+func (c *Client) DeleteCity(ctx context.Context, uuid string) error {
+	if len(uuid) == 0 {
+		return errEmptyUuid
+	}
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: "/api/v1/city/delete", query: uuidQuery(uuid)}, nil)
+	return err
+}
+
+type CityIterator struct {
+	pager
+	load func(limit int, offset int) (int, error)
+	page []*pkg_v1.City
+}
+
+// Cities iterates every city matching options, a page at a time.
+//
+//	iter := c.Cities(ctx, client.CityQueryOptions{})
+//	for iter.Next() {
+//		city := iter.City()
+//	}
+//	err := iter.Err()
+func (c *Client) Cities(ctx context.Context, options CityQueryOptions) *CityIterator {
+	iter := &CityIterator{pager: newPager(options.Limit, options.Offset)}
+	iter.load = func(limit int, offset int) (int, error) {
+		options.Limit, options.Offset = limit, offset
+		page, err := c.ListCities(ctx, options)
+		iter.page = page
+		return len(page), err
+	}
+	return iter
+}
+
+func (iter *CityIterator) Next() bool {
+	return iter.next(iter.load)
+}
+
+func (iter *CityIterator) City() *pkg_v1.City {
+	return iter.page[iter.index]
+}
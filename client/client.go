@@ -0,0 +1,264 @@
+// Package client is a typed Go client of the earth REST API, returning the
+// pkg_v1 models the server is built on.
+//
+//	c, err := client.New(client.Config{BaseURL: "https://earth.example"})
+//	countries, err := c.ListCountries(ctx, client.CountryQueryOptions{WithContinent: true})
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	muuid "github.com/nhht77/earth-rest-api/server/pkg/muuid"
+)
+
+const (
+	HeaderIdempotencyKey = "Idempotency-Key"
+	HeaderReadYourWrites = "X-Read-Your-Writes"
+
+	DefaultTimeout         = 30 * time.Second
+	DefaultMaxRetries      = 3
+	DefaultRetryBackoff    = 200 * time.Millisecond
+	DefaultRetryBackoffMax = 5 * time.Second
+)
+
+type Config struct {
+	// scheme & host of the server, e.g. https://earth.example
+	BaseURL string
+
+	// sent as `Authorization: Bearer <Token>` to a gateway in front of the server
+	Token string
+	// added to every request
+	Header http.Header
+
+	// per attempt, DefaultTimeout when 0, ignored with HTTPClient
+	Timeout time.Duration
+
+	// retries of network errors, 429, 502, 503 & 504, -1 disables them
+	MaxRetries      int
+	RetryBackoff    time.Duration
+	RetryBackoffMax time.Duration
+
+	HTTPClient *http.Client
+}
+
+type Client struct {
+	config  Config
+	baseURL *url.URL
+	http    *http.Client
+}
+
+func New(config Config) (*Client, error) {
+	base_url, err := url.Parse(strings.TrimSuffix(config.BaseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("client: invalid BaseURL: %s", err.Error())
+	}
+	if base_url.Scheme != "http" && base_url.Scheme != "https" {
+		return nil, fmt.Errorf("client: BaseURL %q must be http or https", config.BaseURL)
+	}
+
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = DefaultMaxRetries
+	} else if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	}
+	if config.RetryBackoff == 0 {
+		config.RetryBackoff = DefaultRetryBackoff
+	}
+	if config.RetryBackoffMax == 0 {
+		config.RetryBackoffMax = DefaultRetryBackoffMax
+	}
+
+	http_client := config.HTTPClient
+	if http_client == nil {
+		http_client = &http.Client{Timeout: config.Timeout}
+	}
+
+	return &Client{config: config, baseURL: base_url, http: http_client}, nil
+}
+
+////////////////////////////
+/////// Request context
+
+type contextKey int
+
+const (
+	contextReadYourWrites contextKey = iota
+	contextIdempotencyKey
+)
+
+// WithReadYourWrites makes reads of ctx go to the primary & skip the server cache.
+func WithReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextReadYourWrites, true)
+}
+
+// WithIdempotencyKey sets the Idempotency-Key of a create, which otherwise
+// gets a new one per call so that retries never create twice.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, contextIdempotencyKey, key)
+}
+
+////////////////////////////
+/////// Transport
+
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   interface{}
+
+	idempotent bool // set an Idempotency-Key, POST is retried with it
+}
+
+// do sends req, retrying when allowed, and decodes a 2xx body into dest.
+func (c *Client) do(ctx context.Context, req request, dest interface{}) (int, error) {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return 0, err
+		}
+	}
+
+	endpoint := *c.baseURL
+	endpoint.Path += req.path
+	endpoint.RawQuery = req.query.Encode()
+
+	idempotency_key := ""
+	if req.idempotent {
+		idempotency_key, _ = ctx.Value(contextIdempotencyKey).(string)
+		if len(idempotency_key) == 0 {
+			idempotency_key = muuid.NewUUID().String()
+		}
+	}
+	retryable := len(idempotency_key) > 0 || req.method != http.MethodPost
+
+	for attempt := 0; ; attempt++ {
+		http_req, err := http.NewRequestWithContext(ctx, req.method, endpoint.String(), bytes.NewReader(body))
+		if err != nil {
+			return 0, err
+		}
+		c.setHeaders(ctx, http_req, idempotency_key, body != nil)
+
+		resp, err := c.http.Do(http_req)
+		if err != nil {
+			if ctx.Err() != nil || !retryable || attempt >= c.config.MaxRetries {
+				return 0, err
+			}
+			if err = c.wait(ctx, attempt, ""); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		status := resp.StatusCode
+		if retryable && retryStatus(status) && attempt < c.config.MaxRetries {
+			retry_after := resp.Header.Get("Retry-After")
+			resp.Body.Close()
+			if err = c.wait(ctx, attempt, retry_after); err != nil {
+				return status, err
+			}
+			continue
+		}
+
+		return status, decodeResponse(resp, req, dest)
+	}
+}
+
+func (c *Client) setHeaders(ctx context.Context, r *http.Request, idempotency_key string, has_body bool) {
+	for key, values := range c.config.Header {
+		for _, value := range values {
+			r.Header.Add(key, value)
+		}
+	}
+	r.Header.Set("Accept", "application/json")
+	if has_body {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	if len(c.config.Token) > 0 {
+		r.Header.Set("Authorization", "Bearer "+c.config.Token)
+	}
+	if len(idempotency_key) > 0 {
+		r.Header.Set(HeaderIdempotencyKey, idempotency_key)
+	}
+	if value, _ := ctx.Value(contextReadYourWrites).(bool); value {
+		r.Header.Set(HeaderReadYourWrites, "true")
+	}
+}
+
+func decodeResponse(resp *http.Response, req request, dest interface{}) error {
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newError(req.method, req.path, resp.StatusCode, body)
+	}
+
+	if dest == nil || len(body) == 0 {
+		return nil
+	}
+	if err = json.Unmarshal(body, dest); err != nil {
+		return fmt.Errorf("client: %s %s: decode response: %s", req.method, req.path, err.Error())
+	}
+	return nil
+}
+
+func retryStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// wait sleeps before retry attempt+1: Retry-After seconds when sent, else
+// RetryBackoff doubled per attempt up to RetryBackoffMax.
+func (c *Client) wait(ctx context.Context, attempt int, retry_after string) error {
+	delay := c.config.RetryBackoff
+	for i := 0; i < attempt && delay < c.config.RetryBackoffMax; i++ {
+		delay *= 2
+	}
+	if seconds, err := strconv.Atoi(retry_after); err == nil && seconds >= 0 {
+		delay = time.Duration(seconds) * time.Second
+	}
+	if delay > c.config.RetryBackoffMax {
+		delay = c.config.RetryBackoffMax
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func uuidQuery(uuid string) url.Values {
+	return url.Values{"uuid": []string{uuid}}
+}
+
+var errEmptyUuid = errors.New("client: empty uuid")
+
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/ping"}, nil)
+	return err
+}
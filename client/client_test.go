@@ -0,0 +1,187 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c, err := New(Config{
+		BaseURL:         server.URL,
+		Token:           "secret-token",
+		RetryBackoff:    time.Millisecond,
+		RetryBackoffMax: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestNewValidatesBaseURL(t *testing.T) {
+	for _, base_url := range []string{"", "earth.example", "ftp://earth.example"} {
+		if _, err := New(Config{BaseURL: base_url}); err == nil {
+			t.Errorf("expected an error for BaseURL %q", base_url)
+		}
+	}
+}
+
+func TestRetryIdempotentCreate(t *testing.T) {
+	var (
+		calls int32
+		keys  = make(chan string, 3)
+	)
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		keys <- r.Header.Get(HeaderIdempotencyKey)
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			t.Errorf("unexpected Authorization %q", r.Header.Get("Authorization"))
+		}
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode("busy")
+			return
+		}
+		json.NewEncoder(w).Encode(pkg_v1.Continent{Name: "Europe", Type: pkg_v1.ContinentType_Europe})
+	})
+
+	result, err := c.CreateContinent(context.Background(), &pkg_v1.Continent{Name: "Europe"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Name != "Europe" || calls != 3 {
+		t.Fatalf("expected Europe after 3 calls, got %q after %d", result.Name, calls)
+	}
+
+	// every retry carries the same key so the server creates once
+	first := <-keys
+	if len(first) == 0 || first != <-keys || first != <-keys {
+		t.Error("expected the same Idempotency-Key on every attempt")
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode("Rate limit exceeded")
+	})
+
+	_, err := c.ListCities(context.Background(), CityQueryOptions{})
+	if !errors.Is(err, ErrTooManyRequests) {
+		t.Fatalf("expected ErrTooManyRequests, got %v", err)
+	}
+	if calls != DefaultMaxRetries+1 {
+		t.Errorf("expected %d calls, got %d", DefaultMaxRetries+1, calls)
+	}
+}
+
+func TestTypedErrors(t *testing.T) {
+	responses := map[string]struct {
+		status  int
+		message string
+		target  error
+	}{
+		"/api/v1/country":        {http.StatusNotFound, "sql: no rows in result set", ErrNotFound},
+		"/api/v1/country/update": {http.StatusBadRequest, "Invalid uuid", ErrBadRequest},
+		"/api/v1/country/create": {http.StatusConflict, "uuid already exists", ErrConflict},
+		"/api/v1/countries":      {http.StatusGatewayTimeout, "context deadline exceeded", ErrGatewayTimeout},
+	}
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		response := responses[r.URL.Path]
+		w.WriteHeader(response.status)
+		json.NewEncoder(w).Encode(response.message)
+	})
+	c.config.MaxRetries = 0
+
+	ctx := context.Background()
+	_, get_err := c.GetCountry(ctx, "3f1b0e0c-8f5e-4d1a-9a43-2c7d1a9f0b11")
+	_, update_err := c.UpdateCountry(ctx, &pkg_v1.Country{})
+	_, create_err := c.CreateCountry(ctx, &pkg_v1.Country{})
+	_, list_err := c.ListCountries(ctx, CountryQueryOptions{})
+
+	for path, err := range map[string]error{
+		"/api/v1/country":        get_err,
+		"/api/v1/country/update": update_err,
+		"/api/v1/country/create": create_err,
+		"/api/v1/countries":      list_err,
+	} {
+		var api_err *Error
+		if !errors.As(err, &api_err) || api_err.Message != responses[path].message {
+			t.Errorf("%s: expected *Error with the server message, got %v", path, err)
+		}
+		if !errors.Is(err, responses[path].target) {
+			t.Errorf("%s: expected %v, got %v", path, responses[path].target, err)
+		}
+	}
+}
+
+func TestIteratorPages(t *testing.T) {
+	const total = 7
+
+	var requests []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RawQuery)
+
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+		page := []*pkg_v1.City{}
+		for i := offset; i < total && i < offset+limit; i++ {
+			page = append(page, &pkg_v1.City{Name: strconv.Itoa(i)})
+		}
+		json.NewEncoder(w).Encode(page)
+	})
+
+	iter := c.Cities(context.Background(), CityQueryOptions{WithCountry: true, Limit: 3})
+	names := ""
+	for iter.Next() {
+		names += iter.City().Name
+	}
+	if err := iter.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if names != "0123456" {
+		t.Errorf("expected every city once in order, got %q", names)
+	}
+	expected := []string{"limit=3&with_country=true", "limit=3&offset=3&with_country=true", "limit=3&offset=6&with_country=true"}
+	if len(requests) != len(expected) {
+		t.Fatalf("expected %d requests, got %v", len(expected), requests)
+	}
+	for i := range expected {
+		if requests[i] != expected[i] {
+			t.Errorf("request %d: expected %q, got %q", i, expected[i], requests[i])
+		}
+	}
+}
+
+func TestIteratorStopsOnError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode("Invalid query: limit must be between 0 and 1000")
+	})
+
+	iter := c.Countries(context.Background(), CountryQueryOptions{Limit: 5000})
+	if iter.Next() {
+		t.Fatal("expected no country")
+	}
+	if !errors.Is(iter.Err(), ErrBadRequest) {
+		t.Fatalf("expected ErrBadRequest, got %v", iter.Err())
+	}
+}
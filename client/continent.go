@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+)
+
+// ContinentQueryOptions mirrors the server's ContinentQueryOptions.
+type ContinentQueryOptions struct {
+	WithCities    bool
+	WithCountries bool
+
+	Types []pkg_v1.ContinentType
+
+	Deleted bool
+
+	// Fields narrows the response, like "uuid" or "details.iso_code";
+	// Expand supersedes the With* flags when set
+	Fields []string
+	Expand []string
+
+	// AND-ed, like {"details.currency", "eq", "EUR"}
+	Filters []Filter
+
+	// 0 limit lists every row, iterators page by DefaultPageSize instead
+	Limit  int
+	Offset int
+}
+
+func (options ContinentQueryOptions) values() url.Values {
+	values := url.Values{}
+	setBool(values, "cities", options.WithCities)
+	setBool(values, "countries", options.WithCountries)
+	setBool(values, "deleted", options.Deleted)
+	setList(values, "fields", options.Fields)
+	setList(values, "expand", options.Expand)
+	setFilters(values, options.Filters)
+	setContinentTypes(values, "types", options.Types)
+	setPage(values, options.Limit, options.Offset)
+	return values
+}
+
+func (c *Client) ListContinents(ctx context.Context, options ContinentQueryOptions) ([]*pkg_v1.Continent, error) {
+	results := []*pkg_v1.Continent{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/continents", query: options.values()}, &results)
+	return results, err
+}
+
+func (c *Client) GetContinent(ctx context.Context, uuid string) (*pkg_v1.Continent, error) {
+	if len(uuid) == 0 {
+		return nil, errEmptyUuid
+	}
+	result := &pkg_v1.Continent{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/continent", query: uuidQuery(uuid)}, result)
+	return result, err
+}
+
+func (c *Client) CreateContinent(ctx context.Context, continent *pkg_v1.Continent) (*pkg_v1.Continent, error) {
+	result := &pkg_v1.Continent{}
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/continent/create", body: continent, idempotent: true}, result)
+	return result, err
+}
+
+func (c *Client) UpdateContinent(ctx context.Context, continent *pkg_v1.Continent) (*pkg_v1.Continent, error) {
+	result := &pkg_v1.Continent{}
+	_, err := c.do(ctx, request{method: http.MethodPut, path: "/api/v1/continent/update", body: continent}, result)
+	return result, err
+}
+
+// UpsertContinent updates the continent with continent.Uuid or creates it.
+func (c *Client) UpsertContinent(ctx context.Context, continent *pkg_v1.Continent) (*pkg_v1.Continent, bool, error) {
+	result := &pkg_v1.Continent{}
+	status, err := c.do(ctx, request{method: http.MethodPut, path: "/api/v1/continent", body: continent}, result)
+	return result, status == http.StatusCreated, err
+}
+
+func (c *Client) DeleteContinent(ctx context.Context, uuid string) error {
+	if len(uuid) == 0 {
+		return errEmptyUuid
+	}
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: "/api/v1/continent/delete", query: uuidQuery(uuid)}, nil)
+	return err
+}
+
+type ContinentIterator struct {
+	pager
+	load func(limit int, offset int) (int, error)
+	page []*pkg_v1.Continent
+}
+
+// Continents iterates every continent matching options, a page at a time.
+//
+//	iter := c.Continents(ctx, client.ContinentQueryOptions{})
+//	for iter.Next() {
+//		continent := iter.Continent()
+//	}
+//	err := iter.Err()
+func (c *Client) Continents(ctx context.Context, options ContinentQueryOptions) *ContinentIterator {
+	iter := &ContinentIterator{pager: newPager(options.Limit, options.Offset)}
+	iter.load = func(limit int, offset int) (int, error) {
+		options.Limit, options.Offset = limit, offset
+		page, err := c.ListContinents(ctx, options)
+		iter.page = page
+		return len(page), err
+	}
+	return iter
+}
+
+func (iter *ContinentIterator) Next() bool {
+	return iter.next(iter.load)
+}
+
+func (iter *ContinentIterator) Continent() *pkg_v1.Continent {
+	return iter.page[iter.index]
+}
+
+////////////////////////////
+/////// Query values
+
+func setBool(values url.Values, key string, value bool) {
+	if value {
+		values.Set(key, "true")
+	}
+}
+
+// Filter is a filter[Field][Operator]=Value of list queries, Operator is
+// eq, ne, gt, gte, lt, lte, like, ilike or in (Value comma separated).
+type Filter struct {
+	Field    string
+	Operator string
+	Value    string
+}
+
+func setFilters(values url.Values, filters []Filter) {
+	for _, iter := range filters {
+		operator := iter.Operator
+		if len(operator) == 0 {
+			operator = "eq"
+		}
+		values.Add(fmt.Sprintf("filter[%s][%s]", iter.Field, operator), iter.Value)
+	}
+}
+
+func setList(values url.Values, key string, list []string) {
+	if len(list) > 0 {
+		values.Set(key, strings.Join(list, ","))
+	}
+}
+
+func setContinentTypes(values url.Values, key string, types []pkg_v1.ContinentType) {
+	list := []string{}
+	for _, iter := range types {
+		list = append(list, strconv.Itoa(int(iter)))
+	}
+	setList(values, key, list)
+}
+
+func setPage(values url.Values, limit int, offset int) {
+	if limit > 0 {
+		values.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		values.Set("offset", strconv.Itoa(offset))
+	}
+}
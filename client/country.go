@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+)
+
+// CountryQueryOptions mirrors the server's CountryQueryOptions.
+type CountryQueryOptions struct {
+	WithCities    bool
+	WithContinent bool
+
+	CountryUuids   []string
+	ContinentTypes []pkg_v1.ContinentType
+
+	Deleted bool
+
+	// Fields narrows the response, like "uuid" or "details.iso_code";
+	// Expand supersedes the With* flags when set
+	Fields []string
+	Expand []string
+
+	// AND-ed, like {"details.currency", "eq", "EUR"}
+	Filters []Filter
+
+	// like "-population" or "name", earlier fields take precedence
+	Sort []string
+
+	// 0 limit lists every row, iterators page by DefaultPageSize instead
+	Limit  int
+	Offset int
+}
+
+func (options CountryQueryOptions) values() url.Values {
+	values := url.Values{}
+	setBool(values, "with_cities", options.WithCities)
+	setBool(values, "with_continent", options.WithContinent)
+	setBool(values, "deleted", options.Deleted)
+	setList(values, "fields", options.Fields)
+	setList(values, "expand", options.Expand)
+	setFilters(values, options.Filters)
+	setList(values, "sort", options.Sort)
+	setList(values, "countries", options.CountryUuids)
+	setContinentTypes(values, "continent_types", options.ContinentTypes)
+	setPage(values, options.Limit, options.Offset)
+	return values
+}
+
+func (c *Client) ListCountries(ctx context.Context, options CountryQueryOptions) ([]*pkg_v1.Country, error) {
+	results := []*pkg_v1.Country{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/countries", query: options.values()}, &results)
+	return results, err
+}
+
+func (c *Client) GetCountry(ctx context.Context, uuid string) (*pkg_v1.Country, error) {
+	if len(uuid) == 0 {
+		return nil, errEmptyUuid
+	}
+	result := &pkg_v1.Country{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/country", query: uuidQuery(uuid)}, result)
+	return result, err
+}
+
+// CountrySummaries answers the capital & city count of the countries of
+// options.
+func (c *Client) CountrySummaries(ctx context.Context, options CountryQueryOptions) ([]*pkg_v1.CountrySummary, error) {
+	results := []*pkg_v1.CountrySummary{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/countries/summary", query: options.values()}, &results)
+	return results, err
+}
+
+func (c *Client) CountrySummary(ctx context.Context, uuid string) (*pkg_v1.CountrySummary, error) {
+	if len(uuid) == 0 {
+		return nil, errEmptyUuid
+	}
+	result := &pkg_v1.CountrySummary{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/countries/" + url.PathEscape(uuid) + "/summary"}, result)
+	return result, err
+}
+
+// CountrySeries lists the years of a country's series from from to to,
+// included, 0 leaves a bound open.
+func (c *Client) CountrySeries(ctx context.Context, uuid string, from int, to int) (pkg_v1.CountrySeries, error) {
+	if len(uuid) == 0 {
+		return nil, errEmptyUuid
+	}
+	values := url.Values{}
+	if from > 0 {
+		values.Set("from", strconv.Itoa(from))
+	}
+	if to > 0 {
+		values.Set("to", strconv.Itoa(to))
+	}
+	results := pkg_v1.CountrySeries{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/countries/" + url.PathEscape(uuid) + "/series", query: values}, &results)
+	return results, err
+}
+
+// UpsertCountrySeries writes years of a country's series, nil values keep
+// the stored ones.
+func (c *Client) UpsertCountrySeries(ctx context.Context, uuid string, series pkg_v1.CountrySeries) (pkg_v1.CountrySeries, error) {
+	if len(uuid) == 0 {
+		return nil, errEmptyUuid
+	}
+	results := pkg_v1.CountrySeries{}
+	_, err := c.do(ctx, request{method: http.MethodPut, path: "/api/v1/countries/" + url.PathEscape(uuid) + "/series", body: series}, &results)
+	return results, err
+}
+
+func (c *Client) CreateCountry(ctx context.Context, country *pkg_v1.Country) (*pkg_v1.Country, error) {
+	result := &pkg_v1.Country{}
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/country/create", body: country, idempotent: true}, result)
+	return result, err
+}
+
+func (c *Client) UpdateCountry(ctx context.Context, country *pkg_v1.Country) (*pkg_v1.Country, error) {
+	result := &pkg_v1.Country{}
+	_, err := c.do(ctx, request{method: http.MethodPut, path: "/api/v1/country/update", body: country}, result)
+	return result, err
+}
+
+// UpsertCountry updates the country with country.Uuid or creates it.
+func (c *Client) UpsertCountry(ctx context.Context, country *pkg_v1.Country) (*pkg_v1.Country, bool, error) {
+	result := &pkg_v1.Country{}
+	status, err := c.do(ctx, request{method: http.MethodPut, path: "/api/v1/country", body: country}, result)
+	return result, status == http.StatusCreated, err
+}
+
+func (c *Client) DeleteCountry(ctx context.Context, uuid string) error {
+	if len(uuid) == 0 {
+		return errEmptyUuid
+	}
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: "/api/v1/country/delete", query: uuidQuery(uuid)}, nil)
+	return err
+}
+
+type CountryIterator struct {
+	pager
+	load func(limit int, offset int) (int, error)
+	page []*pkg_v1.Country
+}
+
+// Countries iterates every country matching options, a page at a time.
+//
+//	iter := c.Countries(ctx, client.CountryQueryOptions{})
+//	for iter.Next() {
+//		country := iter.Country()
+//	}
+//	err := iter.Err()
+func (c *Client) Countries(ctx context.Context, options CountryQueryOptions) *CountryIterator {
+	iter := &CountryIterator{pager: newPager(options.Limit, options.Offset)}
+	iter.load = func(limit int, offset int) (int, error) {
+		options.Limit, options.Offset = limit, offset
+		page, err := c.ListCountries(ctx, options)
+		iter.page = page
+		return len(page), err
+	}
+	return iter
+}
+
+func (iter *CountryIterator) Next() bool {
+	return iter.next(iter.load)
+}
+
+func (iter *CountryIterator) Country() *pkg_v1.Country {
+	return iter.page[iter.index]
+}
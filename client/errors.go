@@ -0,0 +1,64 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Errors matched with errors.Is by the status code of an *Error.
+var (
+	ErrBadRequest          = errors.New("bad request")
+	ErrNotFound            = errors.New("not found")
+	ErrConflict            = errors.New("conflict")
+	ErrUnprocessableEntity = errors.New("unprocessable entity")
+	ErrTooManyRequests     = errors.New("too many requests")
+	ErrServiceUnavailable  = errors.New("service unavailable")
+	ErrGatewayTimeout      = errors.New("gateway timeout")
+	ErrServer              = errors.New("server error")
+)
+
+// Error is a non-2xx response, Message is the JSON string the server sent.
+type Error struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string
+}
+
+func newError(method string, path string, status int, body []byte) *Error {
+	result := &Error{Method: method, Path: path, StatusCode: status}
+	if err := json.Unmarshal(body, &result.Message); err != nil {
+		result.Message = strings.TrimSpace(string(body))
+	}
+	return result
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("client: %s %s: %d %s", e.Method, e.Path, e.StatusCode, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusBadRequest:
+		return ErrBadRequest
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusConflict:
+		return ErrConflict
+	case http.StatusUnprocessableEntity:
+		return ErrUnprocessableEntity
+	case http.StatusTooManyRequests:
+		return ErrTooManyRequests
+	case http.StatusServiceUnavailable:
+		return ErrServiceUnavailable
+	case http.StatusGatewayTimeout:
+		return ErrGatewayTimeout
+	}
+	if e.StatusCode >= 500 {
+		return ErrServer
+	}
+	return nil
+}
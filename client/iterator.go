@@ -0,0 +1,53 @@
+package client
+
+// DefaultPageSize is the page size of iterators when the options set no Limit.
+const DefaultPageSize = 100
+
+// pager walks a list page by page with limit & offset, the typed iterators
+// keep the rows of the current page.
+type pager struct {
+	limit  int
+	offset int
+
+	index int
+	size  int
+	done  bool
+	err   error
+}
+
+func newPager(limit int, offset int) pager {
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	return pager{limit: limit, offset: offset}
+}
+
+// next moves to the following row, calling load for the next page once the
+// current one is consumed. load returns the number of rows of the page.
+func (p *pager) next(load func(limit int, offset int) (int, error)) bool {
+	p.index++
+	if p.index < p.size {
+		return true
+	}
+	if p.done || p.err != nil {
+		return false
+	}
+
+	p.size, p.err = load(p.limit, p.offset)
+	if p.err != nil {
+		p.size = 0
+		return false
+	}
+	p.index = 0
+	p.offset += p.size
+	// Note: a short page is the last one, no request is spent to find an empty page
+	if p.size < p.limit {
+		p.done = true
+	}
+	return p.size > 0
+}
+
+// Err is the error that stopped Next, nil when the list was exhausted.
+func (p *pager) Err() error {
+	return p.err
+}
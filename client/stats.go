@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+)
+
+// StatsQueryOptions mirrors the server's StatsQueryOptions, zero values
+// take the server defaults.
+type StatsQueryOptions struct {
+	// day, week or month
+	Bucket string
+
+	From time.Time
+	To   time.Time
+}
+
+func (options StatsQueryOptions) values() url.Values {
+	values := url.Values{}
+	if len(options.Bucket) > 0 {
+		values.Set("bucket", options.Bucket)
+	}
+	if !options.From.IsZero() {
+		values.Set("from", options.From.Format(time.RFC3339))
+	}
+	if !options.To.IsZero() {
+		values.Set("to", options.To.Format(time.RFC3339))
+	}
+	return values
+}
+
+func (c *Client) Stats(ctx context.Context, options StatsQueryOptions) (*pkg_v1.Stats, error) {
+	result := &pkg_v1.Stats{}
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/stats", query: options.values()}, result)
+	return result, err
+}
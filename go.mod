@@ -6,10 +6,13 @@ require (
 	github.com/gobuffalo/buffalo-plugins v1.8.3 // indirect
 	github.com/gobuffalo/genny v0.0.0-20181211165820-e26c8466f14d // indirect
 	github.com/gobuffalo/packr/v2 v2.8.3 // indirect
-	github.com/gorilla/mux v1.8.0 // indirect
-	github.com/lib/pq v1.10.4 // indirect
-	github.com/sirupsen/logrus v1.8.1 // indirect
+	github.com/gorilla/mux v1.8.0
+	github.com/graph-gophers/graphql-go v1.3.0
+	github.com/lib/pq v1.10.4
+	github.com/sirupsen/logrus v1.8.1
 	golang.org/x/sys v0.0.0-20220403020550-483a9cbc67c0 // indirect
-	google.golang.org/protobuf v1.28.0 // indirect
-	gopkg.in/satori/go.uuid.v1 v1.2.0 // indirect
+	google.golang.org/grpc v1.46.2
+	google.golang.org/protobuf v1.28.0
+	gopkg.in/satori/go.uuid.v1 v1.2.0
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+	"github.com/nhht77/earth-rest-api/server/pkg/mcache"
+	"github.com/nhht77/earth-rest-api/server/pkg/mmetrics"
+)
+
+const HeaderCache = "X-Cache"
+
+var (
+	ReadCache = mcache.NewLRU(0, 0)
+
+	// bumped on every write so a read started before it is not cached after
+	cacheGeneration uint64
+
+	cacheRequestsTotal = mmetrics.NewCounterVec(
+		"earth_cache_requests_total",
+		"Read cache lookups by entity and result (hit or miss).",
+		"entity", "result",
+	)
+)
+
+func init() {
+	Metrics.Register(cacheRequestsTotal)
+}
+
+func InitCache(c CacheConfig) {
+	ReadCache = mcache.NewLRU(c.Capacity, time.Duration(c.TTL))
+}
+
+// InvalidateCache drops every cached read, called once Create/Update/SoftDelete
+// are committed, see Database.AfterCommit.
+// Note: countries embed continents and cities embed both, so all entries go.
+func InvalidateCache() {
+	atomic.AddUint64(&cacheGeneration, 1)
+	ReadCache.Purge()
+}
+
+// cached returns the value for key, or loads and stores it. Reads asked to
+// see the request's own writes skip the cache.
+func cached(ctx context.Context, entity string, key string, load func(ctx context.Context) (interface{}, error)) (interface{}, bool, error) {
+	if !ReadCache.Enabled() || usePrimary(ctx) {
+		value, err := load(ctx)
+		return value, false, err
+	}
+
+	if value, ok := ReadCache.Get(key); ok {
+		cacheRequestsTotal.Inc(entity, "hit")
+		return value, true, nil
+	}
+	cacheRequestsTotal.Inc(entity, "miss")
+
+	// Note: a replica may not have replayed the write that purged the cache
+	// yet, its row would then be served stale for the whole TTL
+	generation := atomic.LoadUint64(&cacheGeneration)
+	value, err := load(WithPrimary(ctx))
+	if err != nil {
+		return value, false, err
+	}
+	if generation == atomic.LoadUint64(&cacheGeneration) {
+		ReadCache.Set(key, value)
+	}
+	return value, false, nil
+}
+
+func (options ContinentQueryOptions) CacheKey() string {
+	types := append(ContinentTypeList{}, options.Types...)
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+
+	return fmt.Sprintf("continents:types=%s:cities=%t:countries=%t:deleted=%t:limit=%d:offset=%d:fields=%s:filters=%s",
+		types.String(),
+		options.WithCities,
+		options.WithCountries,
+		options.Deleted,
+		options.Limit,
+		options.Offset,
+		options.Fields.String(),
+		options.Filters.String(),
+	)
+}
+
+func (options CountryQueryOptions) CacheKey() string {
+	types := append(ContinentTypeList{}, options.ContinentTypes...)
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+
+	return fmt.Sprintf("countries:uuids=%s:types=%s:cities=%t:continent=%t:deleted=%t:limit=%d:offset=%d:fields=%s:filters=%s:sort=%s",
+		normalizeUuids(options.CountryUuids),
+		types.String(),
+		options.WithCities,
+		options.WithContinent,
+		options.Deleted,
+		options.Limit,
+		options.Offset,
+		options.Fields.String(),
+		options.Filters.String(),
+		options.Sort.String(),
+	)
+}
+
+func normalizeUuids(uuids []string) string {
+	normalized := []string{}
+	for _, iter := range uuids {
+		normalized = append(normalized, strings.ToLower(strings.TrimSpace(iter)))
+	}
+	sort.Strings(normalized)
+	return strings.Join(normalized, ",")
+}
+
+func (db *Database) CachedContinentsByOptions(ctx context.Context, options ContinentQueryOptions) ([]*pkg_v1.Continent, bool, error) {
+	value, hit, err := cached(ctx, "continent", options.CacheKey(), func(ctx context.Context) (interface{}, error) {
+		return db.ContinentsByOptions(ctx, options)
+	})
+	results, _ := value.([]*pkg_v1.Continent)
+	return results, hit, err
+}
+
+func (db *Database) CachedCountriesByOptions(ctx context.Context, options CountryQueryOptions) (pkg_v1.CountryList, bool, error) {
+	value, hit, err := cached(ctx, "country", options.CacheKey(), func(ctx context.Context) (interface{}, error) {
+		return db.CountriesByOptions(ctx, options)
+	})
+	results, _ := value.(pkg_v1.CountryList)
+	return results, hit, err
+}
+
+func (db *Database) CachedContinentByUuid(ctx context.Context, uuid string) (*pkg_v1.Continent, bool, error) {
+	value, hit, err := cached(ctx, "continent", "continent:"+strings.ToLower(uuid), func(ctx context.Context) (interface{}, error) {
+		return db.ContinentByUuid(ctx, nil, uuid)
+	})
+	result, _ := value.(*pkg_v1.Continent)
+	return result, hit, err
+}
+
+func (db *Database) CachedCountryByUuid(ctx context.Context, uuid string) (*pkg_v1.Country, bool, error) {
+	value, hit, err := cached(ctx, "country", "country:"+strings.ToLower(uuid), func(ctx context.Context) (interface{}, error) {
+		return db.CountryByUuid(ctx, nil, uuid)
+	})
+	result, _ := value.(*pkg_v1.Country)
+	return result, hit, err
+}
+
+func (db *Database) CachedCityByUuid(ctx context.Context, uuid string) (*pkg_v1.City, bool, error) {
+	value, hit, err := cached(ctx, "city", "city:"+strings.ToLower(uuid), func(ctx context.Context) (interface{}, error) {
+		return db.CityByUuid(ctx, nil, uuid)
+	})
+	result, _ := value.(*pkg_v1.City)
+	return result, hit, err
+}
+
+// WriteCacheHeaders sets Cache-Control from CacheConfig.MaxAge and X-Cache.
+func WriteCacheHeaders(w http.ResponseWriter, hit bool) {
+	if max_age := time.Duration(AppConfig.Cache.MaxAge); max_age > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(max_age.Seconds())))
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+
+	if hit {
+		w.Header().Set(HeaderCache, "HIT")
+	} else {
+		w.Header().Set(HeaderCache, "MISS")
+	}
+}
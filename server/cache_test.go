@@ -0,0 +1,75 @@
+package main_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	main "github.com/nhht77/earth-rest-api/server"
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+)
+
+func withReadCache(t *testing.T) {
+	previous := main.ReadCache
+	main.InitCache(main.CacheConfig{Capacity: 16, TTL: main.Duration(time.Minute)})
+	t.Cleanup(func() { main.ReadCache = previous })
+}
+
+func TestAfterCommitWithoutTransaction(t *testing.T) {
+	ran := false
+	new(main.Database).AfterCommit(nil, func() { ran = true })
+	if !ran {
+		t.Error("AfterCommit without a transaction did not run")
+	}
+}
+
+func TestCacheInvalidatedAfterOuterCommit(t *testing.T) {
+	RequireDB(t)
+	withReadCache(t)
+
+	main.DB = DB
+	main.AppConfig = AppConfig
+
+	var (
+		ctx     = context.Background()
+		creator = &pkg_v1.UserMinimal{Email: "cache@earth.test", Name: "cache"}
+	)
+
+	create := func(name string, fail error) error {
+		return DB.InTx(ctx, nil, func(tx *sql.Tx) error {
+			continent, err := DB.CreateContinent(ctx, tx, &pkg_v1.Continent{
+				Name:    name,
+				Type:    pkg_v1.ContinentType_North_America,
+				Creator: creator,
+			})
+			if err != nil {
+				return err
+			}
+			t.Cleanup(func() { DB.SoftDeleteContinent(ctx, nil, continent.Uuid.String()) })
+
+			// the write is not visible to other readers yet
+			if _, ok := main.ReadCache.Get("probe"); !ok {
+				t.Errorf("%s: cache purged before the outer transaction committed", name)
+			}
+			return fail
+		})
+	}
+
+	main.ReadCache.Set("probe", true)
+	rollback := errors.New("rollback")
+	if err := create("Rolled back", rollback); err != rollback {
+		t.Fatal(err)
+	}
+	if _, ok := main.ReadCache.Get("probe"); !ok {
+		t.Error("cache purged by a rolled back transaction")
+	}
+
+	if err := create("Committed", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := main.ReadCache.Get("probe"); ok {
+		t.Error("cache not purged after the outer transaction committed")
+	}
+}
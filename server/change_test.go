@@ -0,0 +1,75 @@
+package main_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	main "github.com/nhht77/earth-rest-api/server"
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+)
+
+func TestChangeOptionsFromQuery(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/v1/changes?entities=city,Country&continent_types=3&last_event_id=7", nil)
+	r.Header.Set(main.HeaderLastEventID, "42")
+
+	options, err := main.ChangeOptionsFromQuery(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Last-Event-ID wins over the query parameter
+	if options.After != 42 {
+		t.Errorf("After = %d, expected 42", options.After)
+	}
+
+	for _, iter := range []struct {
+		change   *pkg_v1.Change
+		expected bool
+	}{
+		{&pkg_v1.Change{Entity: pkg_v1.ChangeEntity_City, ContinentType: pkg_v1.ContinentType_Europe}, true},
+		{&pkg_v1.Change{Entity: pkg_v1.ChangeEntity_Country, ContinentType: pkg_v1.ContinentType_Europe}, true},
+		{&pkg_v1.Change{Entity: pkg_v1.ChangeEntity_Continent, ContinentType: pkg_v1.ContinentType_Europe}, false},
+		{&pkg_v1.Change{Entity: pkg_v1.ChangeEntity_City, ContinentType: pkg_v1.ContinentType_Asia}, false},
+	} {
+		if matches := options.Matches(iter.change); matches != iter.expected {
+			t.Errorf("Matches(%s, %d) = %t, expected %t", iter.change.Entity, iter.change.ContinentType, matches, iter.expected)
+		}
+	}
+}
+
+func TestChangeOptionsFromQueryInvalid(t *testing.T) {
+	for _, query := range []string{
+		"entities=planet",
+		"continent_types=europe",
+		"last_event_id=abc",
+	} {
+		r := httptest.NewRequest("GET", "/api/v1/changes?"+query, nil)
+		if _, err := main.ChangeOptionsFromQuery(r); err == nil {
+			t.Errorf("expected error for %s", query)
+		}
+	}
+}
+
+func TestChangeFeedClosesSlowSubscriber(t *testing.T) {
+	var (
+		feed    = main.NewChangeFeed()
+		changes = feed.Subscribe()
+	)
+
+	for i := 0; i <= main.ChangeSubscriberBuffer; i++ {
+		feed.Publish(&pkg_v1.Change{Entity: pkg_v1.ChangeEntity_City})
+	}
+
+	received := 0
+	for range changes {
+		received++
+	}
+	if received != main.ChangeSubscriberBuffer {
+		t.Errorf("received %d changes, expected %d before close", received, main.ChangeSubscriberBuffer)
+	}
+
+	feed.CloseSubscribers()
+	if feed.Subscribe() != nil {
+		t.Error("expected no subscription on a closed feed")
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+	"github.com/nhht77/earth-rest-api/server/pkg/msql"
+)
+
+const (
+	// channel notified by trigger_change_log(), see sql/02-trigger-function.sql
+	ChangeChannel = "earth_changes"
+
+	ChangeSubscriberBuffer = 64
+
+	changeListenerMinReconnect = time.Second
+	changeListenerMaxReconnect = time.Minute
+	changeListenerPing         = 90 * time.Second
+	changeCatchUpTimeout       = 10 * time.Second
+
+	// re-read interval while a missing index holds changes back
+	changeGapRetry = 250 * time.Millisecond
+)
+
+var Changes = NewChangeFeed()
+
+// ChangeFeed listens to ChangeChannel and fans the change log out to
+// subscribers. Notifications only carry the log index, every wakeup reads the
+// log from the last published index so nothing is missed across reconnects.
+//
+// Concurrent transactions can commit their changes out of index order, so
+// changes are only published once every lower index is committed or known
+// to be rolled back, see catchUp.
+type ChangeFeed struct {
+	mu          sync.Mutex
+	subscribers map[chan *pkg_v1.Change]struct{}
+	closed      bool
+	listening   bool
+	last        msql.DatabaseIndex
+
+	// first missing index & the snapshot xmax it was seen in
+	gapAt   msql.DatabaseIndex
+	gapXmax int64
+
+	listener *pq.Listener
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewChangeFeed() *ChangeFeed {
+	return &ChangeFeed{subscribers: map[chan *pkg_v1.Change]struct{}{}}
+}
+
+// Listen opens the dedicated LISTEN connection and starts publishing changes
+// written after this call.
+func (feed *ChangeFeed) Listen(c *Config) error {
+	framework := c.Framework
+	if framework == nil {
+		framework = DefaultFrameworkConfig()
+	}
+
+	last, err := DB.LastChangeIndex(context.Background())
+	if err != nil {
+		return err
+	}
+	feed.setLast(last)
+
+	feed.listener = pq.NewListener(
+		c.DatabaseSource(framework.DatabaseHost, framework.DatabasePort),
+		changeListenerMinReconnect,
+		changeListenerMaxReconnect,
+		func(event pq.ListenerEventType, err error) {
+			switch event {
+			case pq.ListenerEventDisconnected:
+				Log.Warnf("[postgre] Change listener disconnected: %s", err.Error())
+			case pq.ListenerEventReconnected:
+				Log.Info("[postgre] Change listener reconnected")
+			case pq.ListenerEventConnectionAttemptFailed:
+				Log.Warnf("[postgre] Change listener reconnect failed: %s", err.Error())
+			}
+		},
+	)
+	if err := feed.listener.Listen(ChangeChannel); err != nil {
+		feed.listener.Close()
+		feed.listener = nil
+		return err
+	}
+
+	feed.mu.Lock()
+	feed.listening = true
+	feed.mu.Unlock()
+
+	feed.stop = make(chan struct{})
+	feed.done = make(chan struct{})
+	go feed.run()
+
+	Log.Infof("[postgre] Listening to %s from change #%d", ChangeChannel, last)
+	return nil
+}
+
+func (feed *ChangeFeed) run() {
+	defer close(feed.done)
+
+	held := false
+	for {
+		wait := changeListenerPing
+		if held {
+			wait = changeGapRetry
+		}
+
+		select {
+		case <-feed.stop:
+			return
+		// Note: nil after a reconnect, the catch up covers what was missed
+		case <-feed.listener.Notify:
+			held = feed.catchUp()
+		case <-time.After(wait):
+			if !held {
+				go feed.listener.Ping()
+			}
+			held = feed.catchUp()
+		}
+	}
+}
+
+// catchUp publishes the log after the last published index, in index order.
+// A missing index holds back the changes after it until its transaction
+// commits, or until every transaction running when the gap was first seen
+// has ended and it is known to be rolled back. Returns true while held.
+func (feed *ChangeFeed) catchUp() bool {
+	ctx, cancel := context.WithTimeout(WithPrimary(context.Background()), changeCatchUpTimeout)
+	defer cancel()
+
+	for {
+		last, _ := feed.Last()
+
+		changes, xmin, xmax, err := DB.ChangesInSnapshot(ctx, ChangeQueryOptions{After: last, Limit: ChangeLogPageSize})
+		if err != nil {
+			Log.Warnf("[postgre] Change feed catch up error: %s", err.Error())
+			return false
+		}
+
+		for _, iter := range changes {
+			if iter.Index != last+1 && !feed.gapResolved(last+1, xmin, xmax) {
+				return true
+			}
+			feed.Publish(iter)
+			feed.setLast(iter.Index)
+			last = iter.Index
+		}
+
+		if len(changes) < ChangeLogPageSize {
+			return false
+		}
+	}
+}
+
+// gapResolved reports whether the indexes from at are never going to be
+// committed. The transaction holding a missing index took it before the
+// snapshot that first saw the gap, so it is below that snapshot's xmax. Once
+// the xmin of a later snapshot passes it, that transaction has ended and a
+// change it committed would be visible.
+func (feed *ChangeFeed) gapResolved(at msql.DatabaseIndex, xmin int64, xmax int64) bool {
+	if feed.gapAt != at {
+		feed.gapAt = at
+		feed.gapXmax = xmax
+		return false
+	}
+	if xmin < feed.gapXmax {
+		return false
+	}
+	Log.Debugf("[postgre] Change feed skips rolled back changes from #%d", at)
+	return true
+}
+
+// Last returns the index up to which the log has been published, false when
+// the feed is not listening.
+func (feed *ChangeFeed) Last() (msql.DatabaseIndex, bool) {
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+	return feed.last, feed.listening
+}
+
+func (feed *ChangeFeed) setLast(last msql.DatabaseIndex) {
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+	feed.last = last
+}
+
+// Subscribe returns a channel of every change published from now on, nil
+// once the feed is closed. A subscriber that falls ChangeSubscriberBuffer
+// changes behind has its channel closed and resumes from the log.
+func (feed *ChangeFeed) Subscribe() chan *pkg_v1.Change {
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+
+	if feed.closed {
+		return nil
+	}
+
+	changes := make(chan *pkg_v1.Change, ChangeSubscriberBuffer)
+	feed.subscribers[changes] = struct{}{}
+	return changes
+}
+
+func (feed *ChangeFeed) Unsubscribe(changes chan *pkg_v1.Change) {
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+
+	if _, ok := feed.subscribers[changes]; ok {
+		delete(feed.subscribers, changes)
+		close(changes)
+	}
+}
+
+func (feed *ChangeFeed) Publish(change *pkg_v1.Change) {
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+
+	for changes := range feed.subscribers {
+		select {
+		case changes <- change:
+		default:
+			Log.Warnf("[http] Change subscriber too slow, closing stream at change #%d", change.Index)
+			delete(feed.subscribers, changes)
+			close(changes)
+		}
+	}
+}
+
+// CloseSubscribers ends every stream so draining requests can finish.
+func (feed *ChangeFeed) CloseSubscribers() {
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+
+	feed.closed = true
+	for changes := range feed.subscribers {
+		delete(feed.subscribers, changes)
+		close(changes)
+	}
+}
+
+func (feed *ChangeFeed) Close() error {
+	feed.CloseSubscribers()
+
+	feed.mu.Lock()
+	feed.listening = false
+	feed.mu.Unlock()
+
+	if feed.listener == nil {
+		return nil
+	}
+	close(feed.stop)
+	<-feed.done
+
+	err := feed.listener.Close()
+	feed.listener = nil
+	return err
+}
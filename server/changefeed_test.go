@@ -0,0 +1,176 @@
+package main_test
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	main "github.com/nhht77/earth-rest-api/server"
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+	muuid "github.com/nhht77/earth-rest-api/server/pkg/muuid"
+)
+
+func TestChangeStreamOutlivesWriteTimeout(t *testing.T) {
+	main.AppConfig = AppConfig
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	framework := *main.DefaultFrameworkConfig()
+	framework.WriteTimeout = main.Duration(200 * time.Millisecond)
+
+	server := main.NewHTTPServer(listener.Addr().String(), main.NewRouter(), &framework)
+	go server.Serve(listener)
+	defer server.Close()
+
+	resp, err := http.Get("http://" + listener.Addr().String() + "/api/v1/changes?entities=continent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	change := &pkg_v1.Change{Index: 1, Entity: pkg_v1.ChangeEntity_Continent, Operation: pkg_v1.ChangeOperation_Insert, Uuid: muuid.NewUUID()}
+	go func() {
+		// past the write timeout of the server
+		time.Sleep(500 * time.Millisecond)
+		main.Changes.Publish(change)
+	}()
+
+	received := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if strings.HasPrefix(scanner.Text(), "data: ") {
+				received <- scanner.Text()
+				return
+			}
+		}
+		received <- "stream ended"
+	}()
+
+	select {
+	case line := <-received:
+		if !strings.Contains(line, change.Uuid.String()) {
+			t.Errorf("expected change %s, got %s", change.Uuid, line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change received")
+	}
+}
+
+// receiveChanges collects the changes of uuids published by feed, in order.
+func receiveChanges(t *testing.T, changes chan *pkg_v1.Change, count int, timeout time.Duration, uuids ...muuid.UUID) []*pkg_v1.Change {
+	var (
+		results = []*pkg_v1.Change{}
+		expired = time.After(timeout)
+	)
+	for len(results) < count {
+		select {
+		case change, ok := <-changes:
+			if !ok {
+				t.Fatal("change feed closed")
+			}
+			for _, uuid := range uuids {
+				if change.Uuid == uuid {
+					results = append(results, change)
+				}
+			}
+		case <-expired:
+			return results
+		}
+	}
+	return results
+}
+
+func TestChangeFeedOutOfOrderCommits(t *testing.T) {
+	RequireDB(t)
+
+	main.DB = DB
+	main.AppConfig = AppConfig
+
+	var (
+		ctx     = context.Background()
+		creator = &pkg_v1.UserMinimal{Email: "changefeed@earth.test", Name: "changefeed"}
+		feed    = main.NewChangeFeed()
+	)
+
+	continent, err := DB.CreateContinent(ctx, nil, &pkg_v1.Continent{
+		Name:    "Changefeed",
+		Type:    pkg_v1.ContinentType_North_America,
+		Creator: creator,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DB.SoftDeleteContinent(ctx, nil, continent.Uuid.String())
+
+	if err := feed.Listen(AppConfig); err != nil {
+		t.Fatal(err)
+	}
+	defer feed.Close()
+
+	changes := feed.Subscribe()
+	if changes == nil {
+		t.Fatal("feed is closed")
+	}
+
+	begin := func(name string) (*sql.Tx, *pkg_v1.Country) {
+		tx, err := DB.Begin(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		country, err := DB.CreateCountry(ctx, tx, &pkg_v1.Country{
+			ContinentUuid: continent.Uuid,
+			Name:          name,
+			Details:       &pkg_v1.CountryDetails{PhoneCode: "changefeed-" + name, ISOCode: "C" + name, Currency: "CHF"},
+			Creator:       creator,
+		})
+		if err != nil {
+			DB.Rollback(tx)
+			t.Fatal(err)
+		}
+		return tx, country
+	}
+
+	// the first change log index is committed last
+	tx_first, first := begin("First")
+	tx_second, second := begin("Second")
+
+	if err := tx_second.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if early := receiveChanges(t, changes, 1, time.Second, first.Uuid, second.Uuid); len(early) > 0 {
+		t.Fatalf("change #%d published while a lower index was not committed", early[0].Index)
+	}
+
+	if err := tx_first.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	received := receiveChanges(t, changes, 2, 5*time.Second, first.Uuid, second.Uuid)
+	if len(received) != 2 {
+		t.Fatalf("received %d changes, expected 2", len(received))
+	}
+	if received[0].Uuid != first.Uuid || received[1].Uuid != second.Uuid || received[0].Index >= received[1].Index {
+		t.Errorf("changes out of index order: #%d %s, #%d %s", received[0].Index, received[0].Uuid, received[1].Index, received[1].Uuid)
+	}
+
+	// a rolled back index does not hold the feed back
+	tx_rolled_back, rolled_back := begin("Rolled")
+	tx_after, after := begin("After")
+	if err := tx_after.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	DB.Rollback(tx_rolled_back)
+
+	received = receiveChanges(t, changes, 1, 5*time.Second, rolled_back.Uuid, after.Uuid)
+	if len(received) != 1 || received[0].Uuid != after.Uuid {
+		t.Errorf("expected the change after the rolled back one, got %d changes", len(received))
+	}
+}
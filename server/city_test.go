@@ -0,0 +1,65 @@
+package main_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	main "github.com/nhht77/earth-rest-api/server"
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+)
+
+func TestCityMetadataValidation(t *testing.T) {
+	var (
+		population = int64(2148000)
+		elevation  = float64(35)
+	)
+
+	city := &pkg_v1.City{
+		Population:         &population,
+		Timezone:           "Europe/Paris",
+		ElevationByM:       &elevation,
+		PostalCodePatterns: []string{"75###", "@#@ #@#"},
+		AlternateNames:     []string{"Lutetia", "Paname"},
+	}
+	if err := city.ValidateMetadata(); err != nil {
+		t.Fatal(err)
+	}
+
+	negative, high := int64(-1), float64(9001)
+	for name, invalid := range map[string]pkg_v1.City{
+		"population":     {Population: &negative},
+		"timezone":       {Timezone: "Europe/Atlantis"},
+		"local timezone": {Timezone: "Local"},
+		"elevation":      {ElevationByM: &high},
+		"pattern":        {PostalCodePatterns: []string{"75*"}},
+		"empty pattern":  {PostalCodePatterns: []string{" "}},
+		"duplicate name": {AlternateNames: []string{"Paname", "Paname"}},
+	} {
+		if err := invalid.ValidateMetadata(); err == nil {
+			t.Errorf("expected an error for the %s", name)
+		}
+	}
+}
+
+func TestCityOptions(t *testing.T) {
+	options, err := main.CityOptionsFromQuery(httptest.NewRequest("GET", "/api/v1/cities?min_population=100000&sort=-elevation_by_m&filter[timezone]=Europe/Paris", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if options.MinPopulation == nil || *options.MinPopulation != 100000 {
+		t.Errorf("expected min_population 100000, got %v", options.MinPopulation)
+	}
+	if order := options.Sort.OrderBy(); order != "ORDER BY elevation_by_m DESC NULLS LAST, index" {
+		t.Errorf("unexpected order %q", order)
+	}
+
+	for _, query := range []string{
+		"/api/v1/cities?min_population=-1",
+		"/api/v1/cities?min_population=many",
+		"/api/v1/cities?sort=gdp",
+	} {
+		if _, err := main.CityOptionsFromQuery(httptest.NewRequest("GET", query, nil)); err == nil {
+			t.Errorf("expected an error for %s", query)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package main_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nhht77/earth-rest-api/client"
+	main "github.com/nhht77/earth-rest-api/server"
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+	muuid "github.com/nhht77/earth-rest-api/server/pkg/muuid"
+)
+
+var clientCreator = &pkg_v1.UserMinimal{Email: "client@earth.test", Name: "client"}
+
+func newRouterClient(t *testing.T) *client.Client {
+	main.DB = DB
+	main.AppConfig = AppConfig
+
+	server := httptest.NewServer(main.NewRouter())
+	t.Cleanup(server.Close)
+
+	c, err := client.New(client.Config{BaseURL: server.URL, HTTPClient: server.Client()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestClientCRUD(t *testing.T) {
+	RequireDB(t)
+
+	var (
+		c   = newRouterClient(t)
+		ctx = client.WithReadYourWrites(context.Background())
+	)
+
+	continent, err := c.CreateContinent(ctx, &pkg_v1.Continent{
+		Name:      "South America",
+		Type:      pkg_v1.ContinentType_South_America,
+		AreaByKm2: 17840000,
+		Creator:   clientCreator,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { DB.SoftDeleteContinent(context.Background(), nil, continent.Uuid.String()) })
+
+	country, err := c.CreateCountry(ctx, &pkg_v1.Country{
+		ContinentUuid: continent.Uuid,
+		Name:          "Chile",
+		Details:       &pkg_v1.CountryDetails{PhoneCode: "56", ISOCode: "CL", Currency: "CLP"},
+		Creator:       clientCreator,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	city_uuid := muuid.NewUUID()
+	city, created, err := c.UpsertCity(ctx, &pkg_v1.City{
+		Uuid:          city_uuid,
+		ContinentUuid: continent.Uuid,
+		CountryUuid:   country.Uuid,
+		Name:          "Santiago",
+		Details:       &pkg_v1.CityDetails{IsCapital: true},
+		Creator:       clientCreator,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created || city.Uuid != city_uuid {
+		t.Fatalf("expected city %s created by upsert, got %s (created %t)", city_uuid, city.Uuid, created)
+	}
+
+	got, err := c.GetCountry(ctx, country.Uuid.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "Chile" || got.ContinentUuid != continent.Uuid {
+		t.Errorf("unexpected country %+v", got)
+	}
+
+	cities, err := c.ListCities(ctx, client.CityQueryOptions{CountryUuids: []string{country.Uuid.String()}, WithCountry: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cities) != 1 || cities[0].Details.Country == nil || cities[0].Details.Country.Uuid != country.Uuid {
+		t.Fatalf("expected Santiago with its country, got %v", cities)
+	}
+
+	if err = c.DeleteCity(ctx, city.Uuid.String()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.GetCity(ctx, city.Uuid.String()); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestClientTypedErrors(t *testing.T) {
+	RequireDB(t)
+
+	var (
+		c         = newRouterClient(t)
+		ctx       = context.Background()
+		continent = &pkg_v1.Continent{
+			Uuid:      muuid.NewUUID(),
+			Name:      "Antarctica",
+			Type:      pkg_v1.ContinentType_Antarctica,
+			AreaByKm2: 14200000,
+			Creator:   clientCreator,
+		}
+	)
+
+	if _, err := c.CreateContinent(ctx, continent); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CreateContinent(ctx, continent); !errors.Is(err, client.ErrConflict) {
+		t.Errorf("expected ErrConflict for a used uuid, got %v", err)
+	}
+	// Note: the continent type is free again for the keyed create below
+	if err := c.DeleteContinent(ctx, continent.Uuid.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	missing := muuid.NewUUID()
+	_, err := c.GetCountry(ctx, missing.String())
+	if client_err := (*client.Error)(nil); !errors.As(err, &client_err) || client_err.StatusCode != http.StatusNotFound || !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("expected a 404 ErrNotFound for a missing country, got %v", err)
+	}
+	_, err = c.CreateCountry(ctx, &pkg_v1.Country{
+		ContinentUuid: missing,
+		Name:          "Atlantis",
+		Details:       &pkg_v1.CountryDetails{PhoneCode: "999", ISOCode: "AT", Currency: "ATL"},
+		Creator:       clientCreator,
+	})
+	if !errors.Is(err, client.ErrBadRequest) {
+		t.Errorf("expected ErrBadRequest for a missing continent, got %v", err)
+	}
+
+	if _, err := c.CreateContinent(ctx, &pkg_v1.Continent{Name: "Nowhere"}); !errors.Is(err, client.ErrBadRequest) {
+		t.Errorf("expected ErrBadRequest for an invalid continent, got %v", err)
+	}
+
+	// the same key replays the first response instead of answering 409
+	keyed := client.WithIdempotencyKey(ctx, "client-test-"+muuid.NewUUID().String())
+	continent.Uuid = muuid.NewUUID()
+	first, err := c.CreateContinent(keyed, continent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DB.SoftDeleteContinent(context.Background(), nil, first.Uuid.String())
+	replayed, err := c.CreateContinent(keyed, continent)
+	if err != nil || replayed.Uuid != first.Uuid {
+		t.Errorf("expected the replayed continent %s, got %v (%v)", first.Uuid, replayed, err)
+	}
+}
+
+func TestClientIterator(t *testing.T) {
+	RequireDB(t)
+
+	var (
+		c   = newRouterClient(t)
+		ctx = client.WithReadYourWrites(context.Background())
+	)
+
+	continent, err := c.CreateContinent(ctx, &pkg_v1.Continent{
+		Name:      "North America",
+		Type:      pkg_v1.ContinentType_North_America,
+		AreaByKm2: 24709000,
+		Creator:   clientCreator,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { DB.SoftDeleteContinent(context.Background(), nil, continent.Uuid.String()) })
+
+	for i, name := range []string{"Canada", "Mexico", "Cuba"} {
+		country, err := c.CreateCountry(ctx, &pkg_v1.Country{
+			ContinentUuid: continent.Uuid,
+			Name:          name,
+			Details:       &pkg_v1.CountryDetails{PhoneCode: fmt.Sprintf("90%d", i), ISOCode: fmt.Sprintf("X%d", i), Currency: "XXX"},
+			Creator:       clientCreator,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { DB.SoftDeleteCountry(context.Background(), nil, country.Uuid.String()) })
+	}
+
+	options := client.CountryQueryOptions{ContinentTypes: []pkg_v1.ContinentType{pkg_v1.ContinentType_North_America}}
+	listed, err := c.ListCountries(ctx, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 3 {
+		t.Fatalf("listed %d countries of North America, expected 3", len(listed))
+	}
+
+	options.Limit = 2
+	iter := c.Countries(ctx, options)
+	iterated := []*pkg_v1.Country{}
+	for iter.Next() {
+		iterated = append(iterated, iter.Country())
+	}
+	if err := iter.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(iterated) != len(listed) {
+		t.Fatalf("iterated %d countries in pages of 2, listed %d", len(iterated), len(listed))
+	}
+	for i := range listed {
+		if iterated[i].Uuid != listed[i].Uuid || iterated[i].ContinentUuid != continent.Uuid {
+			t.Errorf("country %d: iterated %+v, listed %s", i, iterated[i], listed[i].Uuid)
+		}
+	}
+
+	// the offset counts the countries of North America only
+	options.Offset = 2
+	page, err := c.ListCountries(ctx, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 1 || page[0].Uuid != listed[2].Uuid {
+		t.Errorf("page at offset 2 = %v, expected %s", page, listed[2].Uuid)
+	}
+}
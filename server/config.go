@@ -1,63 +1,432 @@
 package main
 
 import (
+	"crypto/tls"
+	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nhht77/earth-rest-api/server/pkg/msecret"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
 	Database  DatabaseConfig   `json:"database"`
 	Framework *FrameworkConfig `json:"framework"`
+
+	// Note: settings below can be reloaded at runtime with SIGHUP
+	Logging   LoggingConfig   `json:"logging"`
+	RateLimit RateLimitConfig `json:"rate_limit"`
+	CORS      CORSConfig      `json:"cors"`
+
+	TLS TLSConfig `json:"tls"`
+
+	Cache CacheConfig `json:"cache"`
+
+	Webhook WebhookConfig `json:"webhook"`
+
+	Idempotency IdempotencyConfig `json:"idempotency"`
+}
+
+// Read cache in front of continent & country lists and get by uuid.
+type CacheConfig struct {
+	Capacity int      `json:"capacity"` // default 1024 entries, 0 disables
+	TTL      Duration `json:"ttl"`      // default 5m, 0 disables
+	MaxAge   Duration `json:"max_age"`  // Cache-Control max-age, default 60s
+}
+
+// Webhook delivery worker, see webhook.go.
+type WebhookConfig struct {
+	MaxAttempts  int      `json:"max_attempts"`  // default 8, then dead-lettered
+	BackoffBase  Duration `json:"backoff_base"`  // first retry delay, doubled per attempt, default 30s
+	BackoffMax   Duration `json:"backoff_max"`   // default 6h
+	Timeout      Duration `json:"timeout"`       // per request, default 10s
+	PollInterval Duration `json:"poll_interval"` // default 2s
+
+	// deliver to loopback, private & link-local addresses, default false
+	AllowPrivateNetworks bool `json:"allow_private_networks"`
+}
+
+// Idempotency-Key handling on create endpoints, see idempotency.go.
+type IdempotencyConfig struct {
+	Window Duration `json:"window"` // how long a key is replayed, default 24h
 }
 
 type DatabaseConfig struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
 	Database string `json:"database"`
+
+	// Read credentials from mounted secrets instead, e.g. /run/secrets/db_password
+	UsernameFile string `json:"username_file,omitempty"`
+	PasswordFile string `json:"password_file,omitempty"`
+
+	SSLMode     string `json:"sslmode"`     // default "disable"
+	SSLRootCert string `json:"sslrootcert"` // CA bundle for verify-ca & verify-full
+	SSLCert     string `json:"sslcert"`     // client certificate
+	SSLKey      string `json:"sslkey"`      // client key
+
+	Pool DatabasePoolConfig `json:"pool"`
+
+	// "host:port" of read replicas, sharing the primary credentials
+	Replicas []string `json:"replicas"`
+}
+
+type DatabasePoolConfig struct {
+	MaxOpenConns     int      `json:"max_open_conns"`     // default 20, 0 is unlimited
+	MaxIdleConns     int      `json:"max_idle_conns"`     // default 10
+	ConnMaxLifetime  Duration `json:"conn_max_lifetime"`  // default 30m, 0 keeps connections forever
+	ConnMaxIdleTime  Duration `json:"conn_max_idle_time"` // default 5m
+	StatementTimeout Duration `json:"statement_timeout"`  // default 15s, enforced by PostgreSQL, 0 disables
+}
+
+// HTTPS is served when both CertFile and KeyFile are set.
+type TLSConfig struct {
+	CertFile     string `json:"cert_file"`
+	KeyFile      string `json:"key_file"`
+	ClientCAFile string `json:"client_ca_file"`
+	ClientAuth   string `json:"client_auth"` // none (default), request, require, verify_if_given, require_and_verify
+}
+
+func (c TLSConfig) Enabled() bool {
+	return len(c.CertFile) > 0 && len(c.KeyFile) > 0
 }
 
 type FrameworkConfig struct {
 	ServerPort  string `json:"server_port"`   // default "8080"
+	GRPCPort    string `json:"grpc_port"`     // default "9090", empty disables gRPC
 	IsTestBuild bool   `json:"is_test_build"` // default false
 
 	DatabaseHost string `json:"database_host"` // default "localhost"
 	DatabasePort string `json:"database_port"` // default "5432"
+
+	ReadTimeout       Duration `json:"read_timeout"`        // default 15s
+	ReadHeaderTimeout Duration `json:"read_header_timeout"` // default 5s
+	WriteTimeout      Duration `json:"write_timeout"`       // default 30s
+	IdleTimeout       Duration `json:"idle_timeout"`        // default 60s
+	ShutdownTimeout   Duration `json:"shutdown_timeout"`    // default 20s
+
+	ConfigFile  string `json:"config_file,omitempty"`
+	PrintConfig bool   `json:"-"`
+}
+
+type LoggingConfig struct {
+	Level  string `json:"level"`  // default "info"
+	Format string `json:"format"` // "text" (default) or "json"
 }
 
-// Read and print Database connection
+type RateLimitConfig struct {
+	RequestsPerSecond float64 `json:"requests_per_second"` // per client ip, 0 disables
+	Burst             int     `json:"burst"`               // default 20
+}
 
-func (c *Config) ReadDefault() {
+type CORSConfig struct {
+	AllowedOrigins []string `json:"allowed_origins"` // empty disables CORS, "*" allows any
+	AllowedMethods []string `json:"allowed_methods"`
+	AllowedHeaders []string `json:"allowed_headers"`
+	MaxAge         Duration `json:"max_age"`
+}
 
-	// @bugfix source test db if test build flag is true
-	if AppConfig.Framework.IsTestBuild || false {
-		AppConfig.Framework.IsTestBuild = true
-		Log.Infof("[postgre] Reading from test database %t", AppConfig.Framework.IsTestBuild)
-		c.ReadTestDefault()
-		return
+func DefaultConfig() *Config {
+	return &Config{
+		Database: DatabaseConfig{
+			SSLMode: "disable",
+			Pool: DatabasePoolConfig{
+				MaxOpenConns:     20,
+				MaxIdleConns:     10,
+				ConnMaxLifetime:  Duration(30 * time.Minute),
+				ConnMaxIdleTime:  Duration(5 * time.Minute),
+				StatementTimeout: Duration(15 * time.Second),
+			},
+		},
+		Framework: DefaultFrameworkConfig(),
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		RateLimit: RateLimitConfig{
+			Burst: 20,
+		},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+			AllowedHeaders: []string{"Content-Type", "Idempotency-Key"},
+			MaxAge:         Duration(10 * time.Minute),
+		},
+		TLS: TLSConfig{
+			ClientAuth: "none",
+		},
+		Cache: CacheConfig{
+			Capacity: 1024,
+			TTL:      Duration(5 * time.Minute),
+			MaxAge:   Duration(60 * time.Second),
+		},
+		Webhook: WebhookConfig{
+			MaxAttempts:  8,
+			BackoffBase:  Duration(30 * time.Second),
+			BackoffMax:   Duration(6 * time.Hour),
+			Timeout:      Duration(10 * time.Second),
+			PollInterval: Duration(2 * time.Second),
+		},
+		Idempotency: IdempotencyConfig{
+			Window: Duration(24 * time.Hour),
+		},
 	}
+}
 
-	if len(c.Database.Database) == 0 {
-		c.Database.Database = os.Getenv("APP_DATABASE")
+func DefaultFrameworkConfig() *FrameworkConfig {
+	return &FrameworkConfig{
+		ServerPort:   "8080",
+		GRPCPort:     "9090",
+		DatabaseHost: "localhost",
+		DatabasePort: "5432",
+
+		ReadTimeout:       Duration(15 * time.Second),
+		ReadHeaderTimeout: Duration(5 * time.Second),
+		WriteTimeout:      Duration(30 * time.Second),
+		IdleTimeout:       Duration(60 * time.Second),
+		ShutdownTimeout:   Duration(20 * time.Second),
 	}
-	if len(c.Database.Username) == 0 {
-		c.Database.Username = os.Getenv("APP_USERNAME")
+}
+
+//////////////////////////
+/////// Config loader
+
+// Each option can be set from the config file (json key), the environment
+// (<APP|TEST>_<env>) and the command line (--<flag>).
+type configOption struct {
+	flag  string
+	env   string
+	usage string
+	value func(c *Config) flag.Value
+}
+
+var configOptions = []configOption{
+	{"test-build", "", "run test environment and using test database", func(c *Config) flag.Value { return (*boolValue)(&c.Framework.IsTestBuild) }},
+	{"port", "SERVER_PORT", "server serves and listens at port", func(c *Config) flag.Value { return (*stringValue)(&c.Framework.ServerPort) }},
+	{"grpc-port", "GRPC_PORT", "gRPC server listens at port, empty disables it", func(c *Config) flag.Value { return (*stringValue)(&c.Framework.GRPCPort) }},
+	{"database-host", "DATABASE_HOST", "Database host", func(c *Config) flag.Value { return (*stringValue)(&c.Framework.DatabaseHost) }},
+	{"database-port", "DATABASE_PORT", "Database port", func(c *Config) flag.Value { return (*stringValue)(&c.Framework.DatabasePort) }},
+	{"database-name", "DATABASE", "Database name", func(c *Config) flag.Value { return (*stringValue)(&c.Database.Database) }},
+	{"database-user", "USERNAME", "Database username", func(c *Config) flag.Value { return (*stringValue)(&c.Database.Username) }},
+	{"", "PASSWORD", "", func(c *Config) flag.Value { return (*stringValue)(&c.Database.Password) }},
+	{"database-sslmode", "DATABASE_SSLMODE", "PostgreSQL sslmode: disable, allow, prefer, require, verify-ca, verify-full", func(c *Config) flag.Value { return (*stringValue)(&c.Database.SSLMode) }},
+	{"database-sslrootcert", "DATABASE_SSLROOTCERT", "PostgreSQL root certificate file", func(c *Config) flag.Value { return (*stringValue)(&c.Database.SSLRootCert) }},
+	{"database-sslcert", "DATABASE_SSLCERT", "PostgreSQL client certificate file", func(c *Config) flag.Value { return (*stringValue)(&c.Database.SSLCert) }},
+	{"database-sslkey", "DATABASE_SSLKEY", "PostgreSQL client key file", func(c *Config) flag.Value { return (*stringValue)(&c.Database.SSLKey) }},
+	{"database-replicas", "DATABASE_REPLICAS", "comma separated host:port of read replicas", func(c *Config) flag.Value { return (*listValue)(&c.Database.Replicas) }},
+	{"database-max-open-conns", "DATABASE_MAX_OPEN_CONNS", "maximum open database connections, 0 is unlimited", func(c *Config) flag.Value { return (*intValue)(&c.Database.Pool.MaxOpenConns) }},
+	{"database-max-idle-conns", "DATABASE_MAX_IDLE_CONNS", "maximum idle database connections", func(c *Config) flag.Value { return (*intValue)(&c.Database.Pool.MaxIdleConns) }},
+	{"database-conn-max-lifetime", "DATABASE_CONN_MAX_LIFETIME", "maximum lifetime of a database connection", func(c *Config) flag.Value { return &c.Database.Pool.ConnMaxLifetime }},
+	{"database-conn-max-idle-time", "DATABASE_CONN_MAX_IDLE_TIME", "maximum idle time of a database connection", func(c *Config) flag.Value { return &c.Database.Pool.ConnMaxIdleTime }},
+	{"database-statement-timeout", "DATABASE_STATEMENT_TIMEOUT", "default statement timeout, 0 disables", func(c *Config) flag.Value { return &c.Database.Pool.StatementTimeout }},
+	{"cache-capacity", "CACHE_CAPACITY", "read cache entries, 0 disables", func(c *Config) flag.Value { return (*intValue)(&c.Cache.Capacity) }},
+	{"cache-ttl", "CACHE_TTL", "read cache entry lifetime, 0 disables", func(c *Config) flag.Value { return &c.Cache.TTL }},
+	{"cache-max-age", "CACHE_MAX_AGE", "Cache-Control max-age of cached reads", func(c *Config) flag.Value { return &c.Cache.MaxAge }},
+	{"webhook-max-attempts", "WEBHOOK_MAX_ATTEMPTS", "webhook delivery attempts before dead-lettering", func(c *Config) flag.Value { return (*intValue)(&c.Webhook.MaxAttempts) }},
+	{"webhook-backoff-base", "WEBHOOK_BACKOFF_BASE", "first webhook retry delay, doubled per attempt", func(c *Config) flag.Value { return &c.Webhook.BackoffBase }},
+	{"webhook-backoff-max", "WEBHOOK_BACKOFF_MAX", "maximum webhook retry delay", func(c *Config) flag.Value { return &c.Webhook.BackoffMax }},
+	{"webhook-timeout", "WEBHOOK_TIMEOUT", "webhook request timeout", func(c *Config) flag.Value { return &c.Webhook.Timeout }},
+	{"webhook-poll-interval", "WEBHOOK_POLL_INTERVAL", "webhook outbox poll interval", func(c *Config) flag.Value { return &c.Webhook.PollInterval }},
+	{"webhook-allow-private-networks", "WEBHOOK_ALLOW_PRIVATE_NETWORKS", "allow webhook urls on loopback, private & link-local addresses", func(c *Config) flag.Value { return (*boolValue)(&c.Webhook.AllowPrivateNetworks) }},
+	{"idempotency-window", "IDEMPOTENCY_WINDOW", "how long an Idempotency-Key response is replayed", func(c *Config) flag.Value { return &c.Idempotency.Window }},
+	{"tls-cert", "TLS_CERT_FILE", "HTTPS certificate file, reloaded when rotated", func(c *Config) flag.Value { return (*stringValue)(&c.TLS.CertFile) }},
+	{"tls-key", "TLS_KEY_FILE", "HTTPS key file, reloaded when rotated", func(c *Config) flag.Value { return (*stringValue)(&c.TLS.KeyFile) }},
+	{"tls-client-ca", "TLS_CLIENT_CA_FILE", "CA bundle to verify client certificates", func(c *Config) flag.Value { return (*stringValue)(&c.TLS.ClientCAFile) }},
+	{"tls-client-auth", "TLS_CLIENT_AUTH", "client certificate policy: none, request, require, verify_if_given, require_and_verify", func(c *Config) flag.Value { return (*stringValue)(&c.TLS.ClientAuth) }},
+	{"read-timeout", "READ_TIMEOUT", "maximum duration for reading the entire request", func(c *Config) flag.Value { return &c.Framework.ReadTimeout }},
+	{"read-header-timeout", "READ_HEADER_TIMEOUT", "maximum duration for reading request headers", func(c *Config) flag.Value { return &c.Framework.ReadHeaderTimeout }},
+	{"write-timeout", "WRITE_TIMEOUT", "maximum duration before timing out writes of the response", func(c *Config) flag.Value { return &c.Framework.WriteTimeout }},
+	{"idle-timeout", "IDLE_TIMEOUT", "maximum keep-alive idle time", func(c *Config) flag.Value { return &c.Framework.IdleTimeout }},
+	{"shutdown-timeout", "SHUTDOWN_TIMEOUT", "deadline to drain in-flight requests on SIGTERM", func(c *Config) flag.Value { return &c.Framework.ShutdownTimeout }},
+	{"log-level", "LOG_LEVEL", "log level: trace, debug, info, warn, error", func(c *Config) flag.Value { return (*stringValue)(&c.Logging.Level) }},
+	{"log-format", "LOG_FORMAT", "log format: text or json", func(c *Config) flag.Value { return (*stringValue)(&c.Logging.Format) }},
+	{"rate-limit-rps", "RATE_LIMIT_RPS", "requests per second per client ip, 0 disables", func(c *Config) flag.Value { return (*floatValue)(&c.RateLimit.RequestsPerSecond) }},
+	{"rate-limit-burst", "RATE_LIMIT_BURST", "rate limit burst size", func(c *Config) flag.Value { return (*intValue)(&c.RateLimit.Burst) }},
+	{"cors-origins", "CORS_ORIGINS", "comma separated allowed CORS origins", func(c *Config) flag.Value { return (*listValue)(&c.CORS.AllowedOrigins) }},
+}
+
+// LoadConfig builds the application config. Precedence, lowest to highest:
+// defaults, config file (--config, JSON or YAML), environment, flags.
+func LoadConfig(args []string) (*Config, error) {
+	var (
+		config = DefaultConfig()
+		parsed = DefaultConfig()
+		flags  = flag.NewFlagSet("server", flag.ContinueOnError)
+	)
+
+	flags.StringVar(&parsed.Framework.ConfigFile, "config", "", "path to a JSON or YAML config file")
+	flags.BoolVar(&parsed.Framework.PrintConfig, "print-config", false, "print the merged config with secrets redacted and exit")
+	for _, option := range configOptions {
+		if len(option.flag) > 0 {
+			flags.Var(option.value(parsed), option.flag, option.usage)
+		}
 	}
-	if len(c.Database.Password) == 0 {
-		c.Database.Password = os.Getenv("APP_PASSWORD")
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
 	}
+
+	explicit := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	config.Framework.ConfigFile = parsed.Framework.ConfigFile
+	config.Framework.PrintConfig = parsed.Framework.PrintConfig
+
+	if len(config.Framework.ConfigFile) > 0 {
+		if err := config.ReadFile(config.Framework.ConfigFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if explicit["test-build"] {
+		config.Framework.IsTestBuild = parsed.Framework.IsTestBuild
+	}
+
+	if err := config.ReadDefault(); err != nil {
+		return nil, err
+	}
+
+	for _, option := range configOptions {
+		if len(option.flag) == 0 || !explicit[option.flag] {
+			continue
+		}
+		if err := option.value(config).Set(option.value(parsed).String()); err != nil {
+			return nil, fmt.Errorf("--%s: %s", option.flag, err.Error())
+		}
+	}
+
+	if err := config.ValidateConfig(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
 }
 
-func (c *Config) ReadTestDefault() {
-	if len(c.Database.Database) == 0 {
-		c.Database.Database = os.Getenv("TEST_DATABASE")
+func (c *Config) ReadFile(path string) error {
+	file_b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
 	}
-	if len(c.Database.Username) == 0 {
-		c.Database.Username = os.Getenv("TEST_USERNAME")
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		// Note: round trip through JSON so both formats share the json tags
+		var raw interface{}
+		if err := yaml.Unmarshal(file_b, &raw); err != nil {
+			return fmt.Errorf("%s: %s", path, err.Error())
+		}
+		if file_b, err = json.Marshal(raw); err != nil {
+			return fmt.Errorf("%s: %s", path, err.Error())
+		}
+	case ".json":
+	default:
+		return fmt.Errorf("%s: unsupported config file extension", path)
 	}
-	if len(c.Database.Password) == 0 {
-		c.Database.Password = os.Getenv("TEST_PASSWORD")
+
+	if c.Framework == nil {
+		c.Framework = DefaultFrameworkConfig()
+	}
+	// Note: lists from the file replace the defaults instead of merging
+	c.CORS.AllowedMethods, c.CORS.AllowedHeaders = nil, nil
+	defaults := DefaultConfig().CORS
+	if err := json.Unmarshal(file_b, c); err != nil {
+		return fmt.Errorf("%s: %s", path, err.Error())
+	}
+	if c.CORS.AllowedMethods == nil {
+		c.CORS.AllowedMethods = defaults.AllowedMethods
+	}
+	if c.CORS.AllowedHeaders == nil {
+		c.CORS.AllowedHeaders = defaults.AllowedHeaders
+	}
+
+	for _, iter := range []struct {
+		name  string
+		file  string
+		value *string
+	}{
+		{"database.username", c.Database.UsernameFile, &c.Database.Username},
+		{"database.password", c.Database.PasswordFile, &c.Database.Password},
+	} {
+		if len(iter.file) == 0 {
+			continue
+		}
+		if len(*iter.value) > 0 {
+			return fmt.Errorf("%s: set either %s or %s_file", path, iter.name, iter.name)
+		}
+		secret, err := msecret.FromFile(iter.file)
+		if err != nil {
+			return fmt.Errorf("%s: %s", path, err.Error())
+		}
+		*iter.value = secret
+	}
+	return nil
+}
+
+// Read environment with APP_ prefix, or TEST_ prefix for test build.
+func (c *Config) ReadDefault() error {
+	if c.Framework == nil {
+		c.Framework = DefaultFrameworkConfig()
+	}
+
+	// @bugfix source test db if test build flag is true
+	if c.Framework.IsTestBuild {
+		Log.Infof("[postgre] Reading from test database %t", c.Framework.IsTestBuild)
+		return c.ReadEnvironment("TEST")
+	}
+	return c.ReadEnvironment("APP")
+}
+
+func (c *Config) ReadTestDefault() error {
+	if c.Framework == nil {
+		c.Framework = DefaultFrameworkConfig()
 	}
+	return c.ReadEnvironment("TEST")
+}
+
+// ReadEnvironment reads <prefix>_<name>, or the content of the file named by
+// <prefix>_<name>_FILE for secrets mounted by Docker or Kubernetes.
+func (c *Config) ReadEnvironment(prefix string) error {
+	for _, option := range configOptions {
+		if len(option.env) == 0 {
+			continue
+		}
+
+		var (
+			key       = prefix + "_" + option.env
+			value     = os.Getenv(key)
+			file_path = os.Getenv(key + "_FILE")
+		)
+
+		if len(file_path) > 0 {
+			if len(value) > 0 {
+				return fmt.Errorf("set either %s or %s_FILE", key, key)
+			}
+			secret, err := msecret.FromFile(file_path)
+			if err != nil {
+				return fmt.Errorf("%s_FILE: %s", key, err.Error())
+			}
+			value = secret
+		}
+
+		if len(value) > 0 {
+			// Note: the value is left out, it may be a secret
+			if err := option.value(c).Set(value); err != nil {
+				return fmt.Errorf("%s: invalid value", key)
+			}
+		}
+	}
+	return nil
 }
 
 func (c *Config) ValidateConfig() error {
@@ -70,44 +439,327 @@ func (c *Config) ValidateConfig() error {
 	if len(c.Database.Password) == 0 {
 		return errors.New("Config.Database.Password")
 	}
+
+	if c.Framework == nil {
+		return errors.New("Config.Framework")
+	}
+	if err := validatePort(c.Framework.ServerPort); err != nil {
+		return fmt.Errorf("Config.Framework.ServerPort: %s", err.Error())
+	}
+	if len(c.Framework.GRPCPort) > 0 {
+		if err := validatePort(c.Framework.GRPCPort); err != nil {
+			return fmt.Errorf("Config.Framework.GRPCPort: %s", err.Error())
+		}
+		if c.Framework.GRPCPort == c.Framework.ServerPort {
+			return errors.New("Config.Framework.GRPCPort must differ from ServerPort")
+		}
+	}
+	if err := validatePort(c.Framework.DatabasePort); err != nil {
+		return fmt.Errorf("Config.Framework.DatabasePort: %s", err.Error())
+	}
+	if len(c.Framework.DatabaseHost) == 0 {
+		return errors.New("Config.Framework.DatabaseHost")
+	}
+
+	for name, timeout := range map[string]Duration{
+		"ReadTimeout":       c.Framework.ReadTimeout,
+		"ReadHeaderTimeout": c.Framework.ReadHeaderTimeout,
+		"WriteTimeout":      c.Framework.WriteTimeout,
+		"IdleTimeout":       c.Framework.IdleTimeout,
+		"ShutdownTimeout":   c.Framework.ShutdownTimeout,
+	} {
+		if timeout <= 0 {
+			return fmt.Errorf("Config.Framework.%s must be positive", name)
+		}
+	}
+
+	if _, err := logrus.ParseLevel(c.Logging.Level); err != nil {
+		return fmt.Errorf("Config.Logging.Level: %s", err.Error())
+	}
+	if c.Logging.Format != "text" && c.Logging.Format != "json" {
+		return fmt.Errorf("Config.Logging.Format: unsupported format %q", c.Logging.Format)
+	}
+
+	if c.Cache.Capacity < 0 || c.Cache.TTL < 0 || c.Cache.MaxAge < 0 {
+		return errors.New("Config.Cache must not be negative")
+	}
+
+	if c.Webhook.MaxAttempts < 1 {
+		return errors.New("Config.Webhook.MaxAttempts must be at least 1")
+	}
+	if c.Webhook.BackoffBase <= 0 || c.Webhook.BackoffMax < c.Webhook.BackoffBase {
+		return errors.New("Config.Webhook.BackoffBase must be positive and not above BackoffMax")
+	}
+	if c.Webhook.Timeout <= 0 || c.Webhook.PollInterval <= 0 {
+		return errors.New("Config.Webhook.Timeout and PollInterval must be positive")
+	}
+
+	if c.Idempotency.Window <= 0 {
+		return errors.New("Config.Idempotency.Window must be positive")
+	}
+
+	if c.RateLimit.RequestsPerSecond < 0 || c.RateLimit.Burst < 0 {
+		return errors.New("Config.RateLimit must not be negative")
+	}
+
+	switch c.Database.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		return fmt.Errorf("Config.Database.SSLMode: unsupported sslmode %q", c.Database.SSLMode)
+	}
+	if pool := c.Database.Pool; pool.MaxOpenConns < 0 || pool.MaxIdleConns < 0 ||
+		pool.ConnMaxLifetime < 0 || pool.ConnMaxIdleTime < 0 || pool.StatementTimeout < 0 {
+		return errors.New("Config.Database.Pool must not be negative")
+	}
+	for _, address := range c.Database.Replicas {
+		_, port, err := net.SplitHostPort(address)
+		if err == nil {
+			err = validatePort(port)
+		}
+		if err != nil {
+			return fmt.Errorf("Config.Database.Replicas %q: %s", address, err.Error())
+		}
+	}
+	if (len(c.Database.SSLCert) > 0) != (len(c.Database.SSLKey) > 0) {
+		return errors.New("Config.Database.SSLCert and SSLKey must be set together")
+	}
+
+	if (len(c.TLS.CertFile) > 0) != (len(c.TLS.KeyFile) > 0) {
+		return errors.New("Config.TLS.CertFile and KeyFile must be set together")
+	}
+	client_auth, err := c.TLS.ClientAuthType()
+	if err != nil {
+		return fmt.Errorf("Config.TLS.ClientAuth: %s", err.Error())
+	}
+	if client_auth != tls.NoClientCert {
+		if !c.TLS.Enabled() {
+			return errors.New("Config.TLS.ClientAuth requires CertFile and KeyFile")
+		}
+		if client_auth >= tls.VerifyClientCertIfGiven && len(c.TLS.ClientCAFile) == 0 {
+			return errors.New("Config.TLS.ClientAuth requires ClientCAFile to verify client certificates")
+		}
+	}
+
+	return nil
+}
+
+func (c TLSConfig) ClientAuthType() (tls.ClientAuthType, error) {
+	switch c.ClientAuth {
+	case "", "none":
+		return tls.NoClientCert, nil
+	case "request":
+		return tls.RequestClientCert, nil
+	case "require":
+		return tls.RequireAnyClientCert, nil
+	case "verify_if_given":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require_and_verify":
+		return tls.RequireAndVerifyClientCert, nil
+	}
+	return tls.NoClientCert, fmt.Errorf("unsupported client auth %q", c.ClientAuth)
+}
+
+// ImmutableChanges lists settings of next that differ from c but are only
+// read at startup, so a reload cannot apply them.
+func (c *Config) ImmutableChanges(next *Config) []string {
+	changes := []string{}
+
+	if c.DatabaseSource(c.Framework.DatabaseHost, c.Framework.DatabasePort) !=
+		next.DatabaseSource(next.Framework.DatabaseHost, next.Framework.DatabasePort) {
+		changes = append(changes, "database connection (DSN)")
+	}
+	if c.Database.Pool != next.Database.Pool {
+		changes = append(changes, "database.pool")
+	}
+	if strings.Join(c.Database.Replicas, ",") != strings.Join(next.Database.Replicas, ",") {
+		changes = append(changes, "database.replicas")
+	}
+	if c.Framework.ServerPort != next.Framework.ServerPort {
+		changes = append(changes, "framework.server_port")
+	}
+	if c.Framework.GRPCPort != next.Framework.GRPCPort {
+		changes = append(changes, "framework.grpc_port")
+	}
+	if c.Framework.IsTestBuild != next.Framework.IsTestBuild {
+		changes = append(changes, "framework.is_test_build")
+	}
+	if c.Framework.ReadTimeout != next.Framework.ReadTimeout ||
+		c.Framework.ReadHeaderTimeout != next.Framework.ReadHeaderTimeout ||
+		c.Framework.WriteTimeout != next.Framework.WriteTimeout ||
+		c.Framework.IdleTimeout != next.Framework.IdleTimeout ||
+		c.Framework.ShutdownTimeout != next.Framework.ShutdownTimeout {
+		changes = append(changes, "framework server timeouts")
+	}
+	if c.Cache != next.Cache {
+		changes = append(changes, "cache")
+	}
+	if c.Webhook != next.Webhook {
+		changes = append(changes, "webhook")
+	}
+	if c.Idempotency != next.Idempotency {
+		changes = append(changes, "idempotency")
+	}
+	if c.TLS != next.TLS {
+		changes = append(changes, "tls (certificate files are reloaded automatically when rotated)")
+	}
+
+	return changes
+}
+
+func validatePort(port string) error {
+	num, err := strconv.Atoi(port)
+	if err != nil {
+		return err
+	}
+	if num < 1 || num > 65535 {
+		return fmt.Errorf("port %d out of range", num)
+	}
 	return nil
 }
 
 func (c *Config) DatabaseSource(host string, port string) string {
-	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		host,
-		port,
-		c.Database.Username,
-		c.Database.Password,
-		c.Database.Database,
+	// Note: the timestamp columns default to NOW() in the session time zone,
+	// UTC keeps them comparable to the UTC times the server sends & reads back
+	source := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s timezone=UTC",
+		dsnValue(host),
+		dsnValue(port),
+		dsnValue(c.Database.Username),
+		dsnValue(c.Database.Password),
+		dsnValue(c.Database.Database),
+		dsnValue(c.Database.SSLMode),
 	)
+	if timeout := time.Duration(c.Database.Pool.StatementTimeout); timeout > 0 {
+		// Note: unknown keys are sent by lib/pq as run-time parameters
+		source += fmt.Sprintf(" statement_timeout=%d", timeout.Milliseconds())
+	}
+	for _, iter := range [][2]string{
+		{"sslrootcert", c.Database.SSLRootCert},
+		{"sslcert", c.Database.SSLCert},
+		{"sslkey", c.Database.SSLKey},
+	} {
+		if len(iter[1]) > 0 {
+			source += fmt.Sprintf(" %s=%s", iter[0], dsnValue(iter[1]))
+		}
+	}
+	return source
 }
 
 func (c *Config) DatabaseSourcePrintable(host string, port string) string {
-	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		host,
-		port,
-		c.Database.Username[0:3]+"...",
-		"****",
-		c.Database.Database[0:3]+"...",
-	)
+	return msecret.RedactDSN(c.DatabaseSource(host, port))
+}
+
+// Quote libpq key=value connection string values containing spaces or quotes.
+func dsnValue(value string) string {
+	if len(value) > 0 && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return "'" + strings.ReplaceAll(value, "'", `\'`) + "'"
 }
 
+// Printable returns a copy safe to log, every config log line goes through it.
 func (c *Config) Printable() *Config {
 
 	temp := *c
 
-	if l := len(c.Database.Database); l > 0 {
-		temp.Database.Database = c.Database.Database[0:int(l/3)] + "..."
+	temp.Database.Database = msecret.RedactPartial(c.Database.Database)
+	temp.Database.Username = msecret.RedactPartial(c.Database.Username)
+	temp.Database.Password = msecret.Redact(c.Database.Password)
+
+	return &temp
+}
+
+//////////////////////////
+/////// Config values
+
+// Duration reads "15s" style strings from flags, env and config files.
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d *Duration) Set(value string) error {
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var value string
+	if err := json.Unmarshal(b, &value); err != nil {
+		return fmt.Errorf("duration should be a string such as \"15s\": %s", err.Error())
+	}
+	return d.Set(value)
+}
+
+type stringValue string
+
+func (v *stringValue) String() string { return string(*v) }
+
+func (v *stringValue) Set(value string) error {
+	*v = stringValue(value)
+	return nil
+}
+
+type floatValue float64
+
+func (v *floatValue) String() string { return strconv.FormatFloat(float64(*v), 'g', -1, 64) }
+
+func (v *floatValue) Set(value string) error {
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return err
 	}
+	*v = floatValue(parsed)
+	return nil
+}
 
-	if l := len(c.Database.Username); l > 0 {
-		temp.Database.Username = c.Database.Username[0:int(l/3)] + "..."
+type intValue int
+
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+
+func (v *intValue) Set(value string) error {
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return err
 	}
+	*v = intValue(parsed)
+	return nil
+}
+
+type listValue []string
+
+func (v *listValue) String() string { return strings.Join(*v, ",") }
 
-	if len(c.Database.Password) > 0 {
-		c.Database.Password = "****"
+func (v *listValue) Set(value string) error {
+	*v = listValue{}
+	for _, iter := range strings.Split(value, ",") {
+		if iter = strings.TrimSpace(iter); len(iter) > 0 {
+			*v = append(*v, iter)
+		}
 	}
+	return nil
+}
 
-	return &temp
+type boolValue bool
+
+func (v *boolValue) String() string { return strconv.FormatBool(bool(*v)) }
+
+func (v *boolValue) IsBoolFlag() bool { return true }
+
+func (v *boolValue) Set(value string) error {
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	*v = boolValue(parsed)
+	return nil
 }
@@ -0,0 +1,239 @@
+package main_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	main "github.com/nhht77/earth-rest-api/server"
+	"github.com/nhht77/earth-rest-api/server/pkg/mstring"
+)
+
+const (
+	secretUsername = "earth_secret_username"
+	secretPassword = "earth_secret_password"
+	secretDatabase = "earth_secret_database"
+)
+
+// setEnv sets environment variables for the duration of a test.
+func setEnv(t *testing.T, env map[string]string) {
+	for key, value := range env {
+		key := key
+		previous, exists := os.LookupEnv(key)
+		if len(value) > 0 {
+			os.Setenv(key, value)
+		} else {
+			os.Unsetenv(key)
+		}
+		t.Cleanup(func() {
+			if exists {
+				os.Setenv(key, previous)
+			} else {
+				os.Unsetenv(key)
+			}
+		})
+	}
+}
+
+func writeSecret(t *testing.T, dir string, name string, secret string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(secret+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func loadSecretConfig(t *testing.T) *main.Config {
+	dir := t.TempDir()
+
+	setEnv(t, map[string]string{
+		"TEST_USERNAME":      "",
+		"TEST_PASSWORD":      "",
+		"TEST_DATABASE":      secretDatabase,
+		"TEST_USERNAME_FILE": writeSecret(t, dir, "username", secretUsername),
+		"TEST_PASSWORD_FILE": writeSecret(t, dir, "password", secretPassword),
+	})
+
+	config, err := main.LoadConfig([]string{"--test-build", "--database-port", "1", "--database-host", "127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+func TestConfigSecretsFromFile(t *testing.T) {
+	config := loadSecretConfig(t)
+
+	if config.Database.Username != secretUsername {
+		t.Errorf("Username = %q, expected %q", config.Database.Username, secretUsername)
+	}
+	if config.Database.Password != secretPassword {
+		t.Errorf("Password = %q, expected %q", config.Database.Password, secretPassword)
+	}
+}
+
+func TestConfigSecretsFileConflict(t *testing.T) {
+	dir := t.TempDir()
+
+	setEnv(t, map[string]string{
+		"TEST_PASSWORD":      secretPassword,
+		"TEST_PASSWORD_FILE": writeSecret(t, dir, "password", secretPassword),
+	})
+
+	if _, err := main.LoadConfig([]string{"--test-build"}); err == nil {
+		t.Error("expected error when both TEST_PASSWORD and TEST_PASSWORD_FILE are set")
+	}
+}
+
+func TestConfigSecretsNotLogged(t *testing.T) {
+	var (
+		config = loadSecretConfig(t)
+		output = &bytes.Buffer{}
+		out    = main.Log.Out
+	)
+	main.Log.Out = output
+	defer func() { main.Log.Out = out }()
+
+	main.Log.Info("Config: ", mstring.ToJSON(config.Printable()))
+
+	// logs the printable DSN then fails to reach port 1
+	if err := new(main.Database).Initialize(config); err == nil {
+		t.Fatal("expected connection error")
+	} else {
+		main.Log.Errorf("Error: open connection %s", err.Error())
+	}
+
+	if !strings.Contains(output.String(), "[postgre] Database Source") {
+		t.Fatalf("database source was not logged:\n%s", output.String())
+	}
+	if config.Database.Password != secretPassword {
+		t.Error("Printable modified the original config")
+	}
+
+	for _, secret := range []string{secretUsername, secretPassword, secretDatabase} {
+		if strings.Contains(output.String(), secret) {
+			t.Errorf("secret %q found in log output:\n%s", secret, output.String())
+		}
+	}
+}
+
+func TestDatabaseSourcePrintableShortValues(t *testing.T) {
+	config := &main.Config{Database: main.DatabaseConfig{Username: "ab", Password: "p", Database: "d", SSLMode: "disable"}}
+
+	printable := config.DatabaseSourcePrintable("localhost", "5432")
+	if strings.Contains(printable, "password=p ") || strings.Contains(printable, "user=ab ") {
+		t.Errorf("DatabaseSourcePrintable leaked a secret: %s", printable)
+	}
+}
+
+func TestDatabaseSourceTimezone(t *testing.T) {
+	config := &main.Config{Database: main.DatabaseConfig{Username: "earth", Password: "secret", Database: "earth", SSLMode: "disable"}}
+
+	if source := config.DatabaseSource("localhost", "5432"); !strings.Contains(source, " timezone=UTC") {
+		t.Errorf("expected sessions in UTC, got %s", source)
+	}
+}
+
+func writeConfigFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	setEnv(t, map[string]string{
+		"TEST_USERNAME":      "earth",
+		"TEST_PASSWORD":      "earth",
+		"TEST_DATABASE":      "earth",
+		"TEST_SERVER_PORT":   "7002",
+		"TEST_READ_TIMEOUT":  "20s",
+		"TEST_WRITE_TIMEOUT": "",
+		"TEST_LOG_LEVEL":     "warn",
+		"TEST_CACHE_TTL":     "",
+	})
+
+	path := writeConfigFile(t, "config.yaml", `
+framework:
+  server_port: "7001"
+  read_timeout: 10s
+  write_timeout: 1m
+logging:
+  level: debug
+rate_limit:
+  burst: 5
+cache:
+  ttl: 1m
+`)
+
+	config, err := main.LoadConfig([]string{"--test-build", "--config", path, "--port", "7003"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, iter := range []struct {
+		name     string
+		value    interface{}
+		expected interface{}
+	}{
+		{"ServerPort from flag over env & file", config.Framework.ServerPort, "7003"},
+		{"ReadTimeout from env over file", config.Framework.ReadTimeout, main.Duration(20 * time.Second)},
+		{"WriteTimeout from file", config.Framework.WriteTimeout, main.Duration(time.Minute)},
+		{"Logging.Level from env over file", config.Logging.Level, "warn"},
+		{"RateLimit.Burst from file", config.RateLimit.Burst, 5},
+		{"Cache.TTL from file", config.Cache.TTL, main.Duration(time.Minute)},
+		{"IdleTimeout from defaults", config.Framework.IdleTimeout, main.DefaultFrameworkConfig().IdleTimeout},
+	} {
+		if iter.value != iter.expected {
+			t.Errorf("%s = %v, expected %v", iter.name, iter.value, iter.expected)
+		}
+	}
+}
+
+func TestConfigValidation(t *testing.T) {
+	setEnv(t, map[string]string{
+		"TEST_USERNAME": "earth",
+		"TEST_PASSWORD": "earth",
+		"TEST_DATABASE": "earth",
+	})
+
+	if _, err := main.LoadConfig([]string{"--test-build"}); err != nil {
+		t.Fatalf("valid config rejected: %s", err)
+	}
+
+	for _, args := range [][]string{
+		{"--port", "http"},
+		{"--port", "70000"},
+		{"--port", "8080", "--grpc-port", "8080"},
+		{"--log-level", "loud"},
+		{"--log-format", "xml"},
+		{"--write-timeout", "0s"},
+		{"--write-timeout", "soon"},
+		{"--database-sslmode", "always"},
+		{"--database-replicas", "replica"},
+		{"--webhook-max-attempts", "0"},
+		{"--rate-limit-rps", "-1"},
+		{"--tls-client-auth", "require"},
+		{"--tls-cert", "server.crt"},
+	} {
+		if _, err := main.LoadConfig(append([]string{"--test-build"}, args...)); err == nil {
+			t.Errorf("LoadConfig(%v) expected error", args)
+		}
+	}
+
+	for name, content := range map[string]string{
+		"bad_value.yaml": "logging:\n  format: xml\n",
+		"bad_type.json":  `{"framework": {"server_port": 8080}}`,
+		"config.toml":    "",
+	} {
+		path := writeConfigFile(t, name, content)
+		if _, err := main.LoadConfig([]string{"--test-build", "--config", path}); err == nil {
+			t.Errorf("LoadConfig(--config %s) expected error", name)
+		}
+	}
+}
@@ -1,19 +1,36 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 
 	"github.com/nhht77/earth-rest-api/server/pkg/msql"
 	"github.com/nhht77/earth-rest-api/server/pkg/mstring"
+	muuid "github.com/nhht77/earth-rest-api/server/pkg/muuid"
 )
 
 type Database struct {
 	postgres *sql.DB
+
+	// read replicas, see database_replica.go
+	replicas    []*replica
+	replicaNext uint32
+	stopHealth  chan struct{}
+
+	// set once every migration file ran without error
+	migrated   bool
+	migratedAt time.Time
+
+	// last row count of /metrics, see metrics.go
+	rowsMu        sync.Mutex
+	rowsCollected time.Time
 }
 
 func (db *Database) Initialize(c *Config) error {
@@ -22,20 +39,20 @@ func (db *Database) Initialize(c *Config) error {
 		return err
 	}
 
+	framework := c.Framework
+	if framework == nil {
+		framework = DefaultFrameworkConfig()
+	}
+
 	Log.Infof(
 		"[postgre] Database Source: %s",
 		c.DatabaseSourcePrintable(
-			AppConfig.Framework.DatabaseHost,
-			AppConfig.Framework.DatabasePort,
+			framework.DatabaseHost,
+			framework.DatabasePort,
 		),
 	)
 
-	result, err := sql.Open(
-		"postgres",
-		c.DatabaseSource(
-			AppConfig.Framework.DatabaseHost,
-			AppConfig.Framework.DatabasePort,
-		))
+	result, err := openPool(c, framework.DatabaseHost, framework.DatabasePort)
 	if err != nil {
 		return err
 	}
@@ -94,16 +111,54 @@ func (db *Database) Initialize(c *Config) error {
 		}
 	}
 
-	return nil
+	db.migrated = true
+	db.migratedAt = time.Now()
+
+	return db.openReplicas(c)
+}
+
+func openPool(c *Config, host string, port string) (*sql.DB, error) {
+	result, err := sql.Open("postgres", c.DatabaseSource(host, port))
+	if err != nil {
+		return nil, err
+	}
+
+	result.SetMaxOpenConns(c.Database.Pool.MaxOpenConns)
+	result.SetMaxIdleConns(c.Database.Pool.MaxIdleConns)
+	result.SetConnMaxLifetime(time.Duration(c.Database.Pool.ConnMaxLifetime))
+	result.SetConnMaxIdleTime(time.Duration(c.Database.Pool.ConnMaxIdleTime))
+
+	return result, nil
 }
 
 func (db *Database) Close() error {
+	db.closeReplicas()
 	if db.postgres == nil {
 		return nil
 	}
 	return db.postgres.Close()
 }
 
+func (db *Database) PingTimeout(timeout time.Duration) error {
+	if db.postgres == nil {
+		return errors.New("database is not initialized")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return db.postgres.PingContext(ctx)
+}
+
+func (db *Database) MigrationStatus() (bool, time.Time) {
+	return db.migrated, db.migratedAt
+}
+
+func (db *Database) PoolStats() sql.DBStats {
+	if db.postgres == nil {
+		return sql.DBStats{}
+	}
+	return db.postgres.Stats()
+}
+
 func (db *Database) resultChange(result sql.Result) bool {
 	num1, _ := result.RowsAffected()
 	num2, _ := result.LastInsertId()
@@ -121,33 +176,186 @@ func (db *Database) Rollback(tx *sql.Tx) {
 	}
 }
 
-func (db *Database) Begin() (*sql.Tx, error) {
-	return db.postgres.Begin()
+func (db *Database) Begin(ctx context.Context) (*sql.Tx, error) {
+	return db.postgres.BeginTx(ctx, nil)
+}
+
+// InTx runs fn in tx, or in a new transaction committed when fn succeeds.
+func (db *Database) InTx(ctx context.Context, tx *sql.Tx, fn func(tx *sql.Tx) error) error {
+	if tx != nil {
+		return fn(tx)
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		db.Rollback(tx)
+		takeAfterCommit(tx)
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		takeAfterCommit(tx)
+		return err
+	}
+
+	for _, hook := range takeAfterCommit(tx) {
+		hook()
+	}
+	return nil
+}
+
+var (
+	afterCommitMu sync.Mutex
+	afterCommit   = map[*sql.Tx][]func(){}
+)
+
+// AfterCommit runs fn once tx is committed by the InTx that began it, right
+// away when tx is nil. fn is dropped when tx is rolled back.
+func (db *Database) AfterCommit(tx *sql.Tx, fn func()) {
+	if tx == nil {
+		fn()
+		return
+	}
+
+	afterCommitMu.Lock()
+	defer afterCommitMu.Unlock()
+	afterCommit[tx] = append(afterCommit[tx], fn)
+}
+
+func takeAfterCommit(tx *sql.Tx) []func() {
+	afterCommitMu.Lock()
+	defer afterCommitMu.Unlock()
+
+	hooks := afterCommit[tx]
+	delete(afterCommit, tx)
+	return hooks
 }
 
-func (db *Database) Query(tx *sql.Tx, query string) (*sql.Rows, error) {
+// Note: ctx is the request context, queries are cancelled when the client goes away
+func (db *Database) Query(ctx context.Context, tx *sql.Tx, query string) (*sql.Rows, error) {
 	if tx != nil {
-		return tx.Query(query)
+		return tx.QueryContext(ctx, query)
 	}
-	return db.postgres.Query(query)
+	return db.postgres.QueryContext(ctx, query)
 }
 
-func (db *Database) QueryRow(tx *sql.Tx, query string) *sql.Row {
+func (db *Database) QueryRow(ctx context.Context, tx *sql.Tx, query string) *sql.Row {
 	if tx != nil {
-		return tx.QueryRow(query)
+		return tx.QueryRowContext(ctx, query)
 	}
-	return db.postgres.QueryRow(query)
+	return db.postgres.QueryRowContext(ctx, query)
 }
 
-func (db *Database) Exec(tx *sql.Tx, query string) (sql.Result, error) {
+func (db *Database) Exec(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) (sql.Result, error) {
 	if tx != nil {
-		return tx.Exec(query)
+		return tx.ExecContext(ctx, query, args...)
+	}
+	return db.postgres.ExecContext(ctx, query, args...)
+}
+
+var ErrUuidExists = errors.New("uuid already exists")
+
+// UuidDeletedState reports whether uuid is used in table, soft-deleted rows
+// included, and the row's deleted state.
+func (db *Database) UuidDeletedState(ctx context.Context, tx *sql.Tx, table string, uuid muuid.UUID) (msql.DeletedState, bool, error) {
+	var (
+		state   msql.DeletedState
+		started = time.Now()
+	)
+
+	err := db.QueryRow(ctx, tx,
+		fmt.Sprintf(
+			`SELECT deleted_state FROM %s WHERE uuid = '%s'`,
+			table,
+			uuid.String(),
+		)).Scan(&state)
+
+	CheckOperation("UuidDeletedState", err, started)
+	if DatabaseNoResults(err) {
+		return state, false, nil
+	}
+	if err != nil {
+		return state, false, err
+	}
+
+	return state, true, nil
+}
+
+// CheckUuidAvailable fails with ErrUuidExists when a client supplied uuid
+// is already used in table, soft-deleted rows included.
+func (db *Database) CheckUuidAvailable(ctx context.Context, tx *sql.Tx, table string, uuid muuid.UUID) error {
+	_, exist, err := db.UuidDeletedState(ctx, tx, table, uuid)
+	if err != nil {
+		return err
+	}
+	if exist {
+		return fmt.Errorf("%w: %s %s", ErrUuidExists, table, uuid.String())
 	}
-	return db.postgres.Exec(query)
+	return nil
+}
+
+// DatabaseConflict reports a used uuid, or a unique constraint hit by a
+// concurrent insert.
+func DatabaseConflict(err error) bool {
+	if errors.Is(err, ErrUuidExists) {
+		return true
+	}
+	var pq_err *pq.Error
+	// 23505: unique_violation
+	return errors.As(err, &pq_err) && pq_err.Code == "23505"
 }
 
 func DatabaseNoResults(err error) bool {
-	return err == sql.ErrNoRows
+	return errors.Is(err, sql.ErrNoRows)
+}
+
+// parentNotFound reports a missing continent or country a write refers to as
+// a bad request, not found is for the entity of the request.
+func parentNotFound(err error, entity string, uuid muuid.UUID) error {
+	if DatabaseNoResults(err) {
+		return fmt.Errorf("%s %s does not exist", entity, uuid.String())
+	}
+	return err
+}
+
+// DatabaseTimeout reports a context deadline or a statement_timeout cancel.
+func DatabaseTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var pq_err *pq.Error
+	// 57014: query_canceled
+	return errors.As(err, &pq_err) && pq_err.Code == "57014"
+}
+
+// ListMaxLimit bounds the `limit` of continent, country & city lists.
+const ListMaxLimit = 1000
+
+// PageSQL returns the LIMIT & OFFSET of a page, a 0 limit keeps every row
+// after offset.
+func PageSQL(limit int, offset int) string {
+	page := ""
+	if limit > 0 {
+		page += fmt.Sprintf(`LIMIT %d `, limit)
+	}
+	if offset > 0 {
+		page += fmt.Sprintf(`OFFSET %d `, offset)
+	}
+	return page
+}
+
+// continentTypesSQL selects the index of live continents of types.
+func continentTypesSQL(types ContinentTypeList) string {
+	return fmt.Sprintf(
+		`SELECT index FROM continent WHERE type IN (%s) AND deleted_state != %d`,
+		types.String(),
+		msql.SoftDeleted,
+	)
 }
 
 func CheckOperation(op string, err error, started time.Time) bool {
@@ -156,6 +364,8 @@ func CheckOperation(op string, err error, started time.Time) bool {
 		spent = time.Since(started).String()
 	}
 
+	ObserveOperation(op, err, started)
+
 	hasError := err != nil && err != sql.ErrNoRows
 	if hasError {
 		Log.Errorf("[postgre] DB.%s error: %s (%s)", op, err.Error(), spent)
@@ -166,21 +376,39 @@ func CheckOperation(op string, err error, started time.Time) bool {
 	return hasError == false
 }
 
+// TestTable is a table emptied by _ClearTable and the tests.
+type TestTable struct {
+	Name     string
+	Sequence string
+}
+
+// TestTables lists referencing tables before the tables they reference.
+var TestTables = []TestTable{
+	{"city", "city_index_seq"},
+	{"country_series", "country_series_index_seq"},
+	{"country", "country_index_seq"},
+	{"continent", "continent_index_seq"},
+	{"change_log", "change_log_index_seq"},
+
+	{"webhook_delivery_attempt", "webhook_delivery_attempt_index_seq"},
+	{"webhook_delivery", "webhook_delivery_index_seq"},
+	{"webhook_outbox", "webhook_outbox_index_seq"},
+	{"webhook_subscription", "webhook_subscription_index_seq"},
+
+	{"idempotency_key", "idempotency_key_index_seq"},
+}
+
 func (db *Database) _ClearTable() error {
 
-	tables := map[string]string{
-		"continent": "continent_index_seq",
-		"country":   "country_index_seq",
-		"city":      "city_index_seq",
-	}
+	ctx := context.Background()
 
-	clear_table_by_map := func(tx *sql.Tx, tables map[string]string) error {
-		for table, sequence := range tables {
-			_, err := DB.Exec(tx, fmt.Sprintf("TRUNCATE %s CASCADE", table))
+	clear_table_by_map := func(tx *sql.Tx) error {
+		for _, iter := range TestTables {
+			_, err := DB.Exec(ctx, tx, fmt.Sprintf("TRUNCATE %s CASCADE", iter.Name))
 			if err != nil {
 				return err
 			}
-			_, err = DB.Exec(tx, fmt.Sprintf("ALTER SEQUENCE %s RESTART WITH 1", sequence))
+			_, err = DB.Exec(ctx, tx, fmt.Sprintf("ALTER SEQUENCE %s RESTART WITH 1", iter.Sequence))
 			if err != nil {
 				return err
 			}
@@ -188,13 +416,13 @@ func (db *Database) _ClearTable() error {
 		return nil
 	}
 
-	tx, err := DB.Begin()
+	tx, err := DB.Begin(ctx)
 	if err != nil {
 		Log.Fatal("[testing] clearTable DB.Begin error", err)
 		return err
 	}
 
-	if err := clear_table_by_map(tx, tables); err != nil {
+	if err := clear_table_by_map(tx); err != nil {
 		DB.Rollback(tx)
 		Log.Fatal("[testing] clearTable clear_table_by_map error", err)
 		return err
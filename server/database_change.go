@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+	"github.com/nhht77/earth-rest-api/server/pkg/mhttp"
+	"github.com/nhht77/earth-rest-api/server/pkg/msql"
+	muuid "github.com/nhht77/earth-rest-api/server/pkg/muuid"
+)
+
+const (
+	HeaderLastEventID = "Last-Event-ID"
+
+	// rows read from the change log per query when resuming
+	ChangeLogPageSize = 500
+)
+
+type ChangeQueryOptions struct {
+	// changes with a greater index, 0 for none of the log
+	After msql.DatabaseIndex
+	// changes up to this index, 0 for no bound
+	Until msql.DatabaseIndex
+
+	Entities       []pkg_v1.ChangeEntity
+	ContinentTypes ContinentTypeList
+
+	Limit int
+}
+
+// ChangeOptionsFromQuery reads `entities` & `continent_types`, and the resume
+// position from Last-Event-ID or, for the first EventSource connection, `last_event_id`.
+func ChangeOptionsFromQuery(r *http.Request) (ChangeQueryOptions, error) {
+	options := ChangeQueryOptions{
+		Limit: ChangeLogPageSize,
+	}
+
+	for _, iter := range mhttp.QueryList(r, "entities", ",") {
+		entity := pkg_v1.ChangeEntity(strings.ToLower(iter))
+		if err := entity.IsValid(); err != nil {
+			return options, fmt.Errorf("%s: %s", err.Error(), iter)
+		}
+		options.Entities = append(options.Entities, entity)
+	}
+
+	continent_types, err := mhttp.QueryIntList(r, "continent_types", ",")
+	if err != nil {
+		return options, err
+	}
+	for _, v := range continent_types {
+		options.ContinentTypes = append(options.ContinentTypes, pkg_v1.ContinentType(v))
+	}
+
+	last_event_id := r.Header.Get(HeaderLastEventID)
+	if len(last_event_id) == 0 {
+		last_event_id = mhttp.Query(r, "last_event_id")
+	}
+	if len(last_event_id) > 0 {
+		after, err := strconv.ParseUint(last_event_id, 10, 64)
+		if err != nil {
+			return options, fmt.Errorf("Invalid last event id %s", last_event_id)
+		}
+		options.After = msql.DatabaseIndex(after)
+	}
+
+	return options, nil
+}
+
+// Matches applies the entity & continent filters to a change from the feed.
+func (options ChangeQueryOptions) Matches(change *pkg_v1.Change) bool {
+	if len(options.Entities) > 0 {
+		found := false
+		for _, iter := range options.Entities {
+			if iter == change.Entity {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(options.ContinentTypes) > 0 && !options.ContinentTypes.Contains(change.ContinentType) {
+		return false
+	}
+	return true
+}
+
+func (db *Database) ChangesByOptions(ctx context.Context, tx *sql.Tx, options ChangeQueryOptions) ([]*pkg_v1.Change, error) {
+	var (
+		err     error
+		started = time.Now()
+		results = []*pkg_v1.Change{}
+		fields  = new(pkg_v1.Change).DatabaseFields()
+	)
+
+	query := fmt.Sprintf(
+		`SELECT %s FROM change_log WHERE index > %d `,
+		fields,
+		options.After,
+	)
+
+	if options.Until > 0 {
+		query += fmt.Sprintf(`AND index <= %d `, options.Until)
+	}
+
+	if len(options.Entities) > 0 {
+		entities := []string{}
+		for _, iter := range options.Entities {
+			entities = append(entities, fmt.Sprintf("'%s'", iter))
+		}
+		query += fmt.Sprintf(`AND entity IN (%s) `, strings.Join(entities, ","))
+	}
+
+	if len(options.ContinentTypes) > 0 {
+		query += fmt.Sprintf(`AND continent_type IN (%s) `, options.ContinentTypes.String())
+	}
+
+	query += `ORDER BY index ASC `
+	if options.Limit > 0 {
+		query += fmt.Sprintf(`LIMIT %d`, options.Limit)
+	}
+
+	rows, err := db.ReadQuery(ctx, tx, query)
+	CheckOperation("ChangesByOptions", err, started)
+	if err != nil {
+		return results, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			curr           = &pkg_v1.Change{}
+			continent_uuid muuid.NullUUID
+			continent_type sql.NullInt64
+		)
+		if err = rows.Scan(
+			&curr.Index,
+			&curr.Entity,
+			&curr.Operation,
+			&curr.Uuid,
+			&continent_uuid,
+			&continent_type,
+			&curr.Created,
+		); err == nil {
+
+			if continent_uuid.Valid {
+				curr.ContinentUuid = continent_uuid.UUID
+			}
+			if continent_type.Valid {
+				curr.ContinentType = pkg_v1.ContinentType(continent_type.Int64)
+			}
+
+			results = append(results, curr)
+		} else {
+			Log.Warnf("DB.ChangesByOptions Scan error - %s", err.Error())
+			rows.Close()
+			break
+		}
+	}
+	if err = rows.Err(); err != nil {
+		Log.Warnf("DB.ChangesByOptions error - %s", err.Error())
+		return results, err
+	}
+
+	return results, nil
+}
+
+func (db *Database) LastChangeIndex(ctx context.Context) (msql.DatabaseIndex, error) {
+	var (
+		index   msql.DatabaseIndex
+		started = time.Now()
+	)
+
+	err := db.QueryRow(ctx, nil, `SELECT COALESCE(MAX(index), 0) FROM change_log`).Scan(&index)
+	CheckOperation("LastChangeIndex", err, started)
+	if err != nil {
+		return index, err
+	}
+
+	return index, nil
+}
+
+// ChangesInSnapshot reads the log like ChangesByOptions, along with the xmin
+// and xmax of the transaction snapshot it was read in, see ChangeFeed.catchUp.
+func (db *Database) ChangesInSnapshot(ctx context.Context, options ChangeQueryOptions) ([]*pkg_v1.Change, int64, int64, error) {
+	var (
+		xmin    int64
+		xmax    int64
+		started = time.Now()
+	)
+
+	// Note: every statement of a repeatable read transaction sees the same snapshot
+	tx, err := db.postgres.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, xmin, xmax, err
+	}
+	defer db.Rollback(tx)
+
+	err = tx.QueryRowContext(ctx,
+		`SELECT txid_snapshot_xmin(txid_current_snapshot()), txid_snapshot_xmax(txid_current_snapshot())`,
+	).Scan(&xmin, &xmax)
+	CheckOperation("ChangesInSnapshot", err, started)
+	if err != nil {
+		return nil, xmin, xmax, err
+	}
+
+	changes, err := db.ChangesByOptions(ctx, tx, options)
+	return changes, xmin, xmax, err
+}
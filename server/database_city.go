@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
@@ -23,7 +25,26 @@ type CityQueryOptions struct {
 	CityUuids      []string
 	ContinentTypes ContinentTypeList
 
+	// only capitals, or only other cities, when set
+	IsCapital *bool
+
+	// cities of at least that population when set, unknown ones left out
+	MinPopulation *int64
+
 	Deleted bool
+
+	// fields= of the response, the SQL projection leaves out the others
+	Fields Fieldset
+
+	// filter[field][operator]=value, compiled to SQL conditions
+	Filters FilterList
+
+	// sort=-population,name, creation order by default
+	Sort SortList
+
+	// page of the list, 0 limit for every row
+	Limit  int
+	Offset int
 }
 
 func CityOptionsFromQuery(r *http.Request) (CityQueryOptions, error) {
@@ -40,6 +61,49 @@ func CityOptionsFromQuery(r *http.Request) (CityQueryOptions, error) {
 	if err != nil {
 		return options, err
 	}
+	if options.Fields, err = FieldsetFromQuery(r, CityOutput); err != nil {
+		return options, err
+	}
+	if options.Filters, err = FiltersFromQuery(r, cityFilterFields); err != nil {
+		return options, err
+	}
+	if options.Sort, err = SortFromQuery(r, citySortFields); err != nil {
+		return options, err
+	}
+	if min_population := mhttp.Query(r, "min_population"); len(min_population) > 0 {
+		value, err := strconv.ParseInt(min_population, 10, 64)
+		if err != nil || value < 0 {
+			return options, fmt.Errorf("invalid min_population %q", min_population)
+		}
+		options.MinPopulation = &value
+	}
+	if is_capital := mhttp.Query(r, "is_capital"); len(is_capital) > 0 {
+		value, err := strconv.ParseBool(is_capital)
+		if err != nil {
+			return options, fmt.Errorf("invalid is_capital %q", is_capital)
+		}
+		options.IsCapital = &value
+	}
+
+	// expand= supersedes the with_* flags
+	expand, ok, err := ExpandFromQuery(r, "continent", "country")
+	if err != nil {
+		return options, err
+	}
+	if ok {
+		options.WithContinent, options.WithCountry = expand["continent"], expand["country"]
+	}
+	if options.WithContinent {
+		options.Fields.Include("details.continent")
+	}
+	if options.WithCountry {
+		options.Fields.Include("details.country")
+	}
+
+	options.Limit, options.Offset, err = PageFromQuery(r)
+	if err != nil {
+		return options, err
+	}
 	if len(continent_types) > 0 {
 		for _, v := range continent_types {
 			options.ContinentTypes = append(options.ContinentTypes, pkg_v1.ContinentType(v))
@@ -49,13 +113,20 @@ func CityOptionsFromQuery(r *http.Request) (CityQueryOptions, error) {
 	return options, nil
 }
 
-func (db *Database) CitiesByOptions(options CityQueryOptions) ([]*pkg_v1.City, error) {
+func (db *Database) CitiesByOptions(ctx context.Context, options CityQueryOptions) ([]*pkg_v1.City, error) {
+	results := []*pkg_v1.City{}
 
-	var (
-		err     error
-		started = time.Now()
-		results = []*pkg_v1.City{}
-	)
+	err := db.EachCityByOptions(ctx, options, func(city *pkg_v1.City) error {
+		results = append(results, city)
+		return nil
+	})
+	return results, err
+}
+
+// EachCityByOptions calls fn with the cities of options as rows are read, in
+// the options sort order. An error of fn stops the scan and is returned.
+func (db *Database) EachCityByOptions(ctx context.Context, options CityQueryOptions, fn func(city *pkg_v1.City) error) error {
+	started := time.Now()
 
 	if len(options.ContinentTypes) == 0 {
 		options.ContinentTypes = append(options.ContinentTypes,
@@ -69,22 +140,46 @@ func (db *Database) CitiesByOptions(options CityQueryOptions) ([]*pkg_v1.City, e
 		)
 	}
 
-	query := fmt.Sprintf(`SELECT %s FROM city `, new(pkg_v1.City).DatabaseFields())
+	relations, err := db.newCityRelations(ctx, started, options)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM city `, options.Fields.Columns(new(pkg_v1.City).DatabaseFields(), CityOutput))
 
 	if !options.Deleted {
-		query += fmt.Sprintf(`WHERE deleted_state != %d`, msql.SoftDeleted)
+		query += fmt.Sprintf(`WHERE deleted_state != %d `, msql.SoftDeleted)
 	} else {
-		query += fmt.Sprintf(`WHERE deleted_state = %d`, msql.SoftDeleted)
+		query += fmt.Sprintf(`WHERE deleted_state = %d `, msql.SoftDeleted)
 	}
 
 	if len(options.CityUuids) > 0 {
 		query += fmt.Sprintf(`AND uuid IN (%s) `, mstring.FormatStringValues(options.CityUuids...))
 	}
+	if options.IsCapital != nil {
+		query += fmt.Sprintf(`AND %s = %t `, cityCapitalColumn, *options.IsCapital)
+	}
+	query += fmt.Sprintf(`AND continent_index IN (%s) `, continentTypesSQL(options.ContinentTypes))
+
+	countries := fmt.Sprintf(`SELECT index FROM country WHERE deleted_state != %d`, msql.SoftDeleted)
+	if len(options.CountryUuids) > 0 {
+		countries += fmt.Sprintf(` AND uuid IN (%s)`, mstring.FormatStringValues(options.CountryUuids...))
+	}
+	query += fmt.Sprintf(`AND country_index IN (%s) `, countries)
 
-	rows, err := db.postgres.Query(query)
+	args := []interface{}{}
+	if options.MinPopulation != nil {
+		args = append(args, *options.MinPopulation)
+		query += fmt.Sprintf(`AND population >= $%d `, len(args))
+	}
+	query += options.Filters.Where(&args)
+	query += options.Sort.OrderBy() + " "
+	query += PageSQL(options.Limit, options.Offset)
+
+	rows, err := db.ReadQuery(ctx, nil, query, args...)
 	CheckOperation("CitysByOptions", err, started)
 	if err != nil {
-		return results, err
+		return err
 	}
 
 	defer rows.Close()
@@ -100,110 +195,117 @@ func (db *Database) CitiesByOptions(options CityQueryOptions) ([]*pkg_v1.City, e
 			&curr.CountryIndex,
 			&curr.Uuid,
 			&curr.Name,
+			&curr.Population,
+			&curr.Timezone,
+			&curr.ElevationByM,
+			&curr.PostalCodePatterns,
+			&curr.AlternateNames,
 			&curr.Details,
 			&curr.Creator,
 			&curr.Created,
 			&updated,
 			&curr.DeletedState,
-		); err == nil {
+		); err != nil {
+			Log.Warnf("DB.CitiesByOptions Scan error - %s", err.Error())
+			return err
+		}
 
-			if updated.Valid && !updated.Time.IsZero() {
-				curr.Updated = updated.Time
-			}
+		if updated.Valid && !updated.Time.IsZero() {
+			curr.Updated = updated.Time
+		}
 
-			results = append(results, curr)
-		} else {
-			Log.Warnf("DB.CitiesByOptions Scan error - %s", err.Error())
-			rows.Close()
-			break
+		relations.Attach(curr)
+
+		if err = fn(curr); err != nil {
+			return err
 		}
 	}
 	if err = rows.Err(); err != nil {
 		Log.Warnf("DB.CitiesByOptions error - %s", err.Error())
-		rows.Close()
-		return results, err
+		return err
 	}
 
-	filter_results, err := DB.ToCities(started, results, options)
-	if err != nil {
-		return results, err
-	}
+	return nil
+}
+
+// cityRelations holds the continents & countries of the cities CityQueryOptions list.
+type cityRelations struct {
+	options CityQueryOptions
 
-	return filter_results, nil
+	continent_map map[msql.DatabaseIndex]*pkg_v1.Continent
+	country_map   map[msql.DatabaseIndex]*pkg_v1.Country
 }
 
-func (db *Database) ToCities(started time.Time, cities pkg_v1.CityList, options CityQueryOptions) (pkg_v1.CityList, error) {
+func (db *Database) newCityRelations(ctx context.Context, started time.Time, options CityQueryOptions) (*cityRelations, error) {
 	if started.IsZero() {
 		started = time.Now()
 	}
 
-	continents, err := DB.ContinentsByOptions(ContinentQueryOptions{
+	continents, err := db.ContinentsByOptions(ctx, ContinentQueryOptions{
 		Types: options.ContinentTypes,
 	})
-	CheckOperation("ToCities ", err, started)
+	CheckOperation("CityRelations ", err, started)
 	if err != nil {
-		return pkg_v1.CityList{}, err
+		return nil, err
 	}
 
-	countries, err := DB.CountriesByOptions(CountryQueryOptions{
-		CountryUuids: options.CountryUuids,
+	countries, err := db.CountriesByOptions(ctx, CountryQueryOptions{
+		CountryUuids:   options.CountryUuids,
+		ContinentTypes: options.ContinentTypes,
 	})
-	CheckOperation("ToCities ", err, started)
+	CheckOperation("CityRelations ", err, started)
 	if err != nil {
-		return pkg_v1.CityList{}, err
+		return nil, err
 	}
 
-	var (
-		continent_map = map[msql.DatabaseIndex]*pkg_v1.Continent{}
-		country_map   = map[msql.DatabaseIndex]*pkg_v1.Country{}
-		filter_cities = pkg_v1.CityList{}
-	)
+	relations := &cityRelations{
+		options:       options,
+		continent_map: map[msql.DatabaseIndex]*pkg_v1.Continent{},
+		country_map:   map[msql.DatabaseIndex]*pkg_v1.Country{},
+	}
 
 	// create continents map
 	for _, iter := range continents {
-		continent_map[iter.Index] = iter
+		relations.continent_map[iter.Index] = iter
 	}
 
 	// create country map
 	for _, iter := range countries {
-		country_map[iter.Index] = iter
+		relations.country_map[iter.Index] = iter
 	}
 
-	for _, iter := range cities {
-
-		iter_country := country_map[iter.CountryIndex]
-		iter_continent := continent_map[iter.ContinentIndex]
-
-		if iter_continent == nil || iter_country == nil {
-			continue
-		}
+	return relations, nil
+}
 
-		if !options.ContinentTypes.Contains(iter_continent.Type) {
-			continue
-		}
+// Attach sets the continent & country uuids of city and the snapshots asked
+// for. The cities query filters on the same continents & countries.
+func (relations *cityRelations) Attach(city *pkg_v1.City) {
+	var (
+		options        = relations.options
+		iter_country   = relations.country_map[city.CountryIndex]
+		iter_continent = relations.continent_map[city.ContinentIndex]
+	)
 
-		is_country_not_match := len(options.CountryUuids) > 0 && !mstring.SliceContains(options.CountryUuids, iter_country.Uuid.String())
-		if is_country_not_match {
-			continue
-		}
+	if city.Details == nil && (options.WithContinent || options.WithCountry) {
+		city.Details = &pkg_v1.CityDetails{}
+	}
 
+	if iter_continent != nil {
 		if options.WithContinent {
-			iter.Details.Continent = iter_continent
+			city.Details.Continent = iter_continent
 		}
+		city.ContinentUuid = iter_continent.Uuid
+	}
 
+	if iter_country != nil {
 		if options.WithCountry {
-			iter.Details.Country = iter_country
+			city.Details.Country = iter_country
 		}
-
-		iter.ContinentUuid = iter_continent.Uuid
-		iter.CountryUuid = iter_country.Uuid
-
-		filter_cities = append(filter_cities, iter)
+		city.CountryUuid = iter_country.Uuid
 	}
-	return filter_cities, nil
 }
 
-func (db *Database) CityByUuid(tx *sql.Tx, uuid string) (*pkg_v1.City, error) {
+func (db *Database) CityByUuid(ctx context.Context, tx *sql.Tx, uuid string) (*pkg_v1.City, error) {
 	if _, err := muuid.UUIDFromString(uuid); err != nil {
 		return nil, err
 	}
@@ -215,7 +317,7 @@ func (db *Database) CityByUuid(tx *sql.Tx, uuid string) (*pkg_v1.City, error) {
 		updated sql.NullTime
 	)
 
-	err := db.QueryRow(tx,
+	err := db.ReadQueryRow(ctx, tx,
 		fmt.Sprintf(
 			`SELECT %s
 				FROM city
@@ -229,6 +331,11 @@ func (db *Database) CityByUuid(tx *sql.Tx, uuid string) (*pkg_v1.City, error) {
 		&result.CountryIndex,
 		&result.Uuid,
 		&result.Name,
+		&result.Population,
+		&result.Timezone,
+		&result.ElevationByM,
+		&result.PostalCodePatterns,
+		&result.AlternateNames,
 		&result.Details,
 		&result.Creator,
 		&result.Created,
@@ -245,12 +352,12 @@ func (db *Database) CityByUuid(tx *sql.Tx, uuid string) (*pkg_v1.City, error) {
 		return nil, err
 	}
 
-	continent_uuid, err := DB.ContinentUuidByIndex(tx, result.ContinentIndex)
+	continent_uuid, err := db.ContinentUuidByIndex(ctx, tx, result.ContinentIndex)
 	if err != nil {
 		return nil, err
 	}
 
-	country_uuid, err := DB.CountryUuidByIndex(tx, result.CountryIndex)
+	country_uuid, err := db.CountryUuidByIndex(ctx, tx, result.CountryIndex)
 	if err != nil {
 		return nil, err
 	}
@@ -261,8 +368,81 @@ func (db *Database) CityByUuid(tx *sql.Tx, uuid string) (*pkg_v1.City, error) {
 	return result, nil
 }
 
+// cityCapitalColumn reads details.is_capital, false when missing.
+const cityCapitalColumn = `COALESCE((details->>'is_capital')::boolean, false)`
+
+// CitiesByCountryIndexes loads the live cities of countries in one query.
+func (db *Database) CitiesByCountryIndexes(ctx context.Context, indexes msql.DatabaseIndexList) (pkg_v1.CityList, error) {
+	if len(indexes) == 0 {
+		return pkg_v1.CityList{}, nil
+	}
+	return db.citiesWhere(ctx, "CitiesByCountryIndexes", fmt.Sprintf("country_index IN (%s)", indexes.String()))
+}
+
+// CitiesByContinentIndexes loads the live cities of continents in one query.
+func (db *Database) CitiesByContinentIndexes(ctx context.Context, indexes msql.DatabaseIndexList) (pkg_v1.CityList, error) {
+	if len(indexes) == 0 {
+		return pkg_v1.CityList{}, nil
+	}
+	return db.citiesWhere(ctx, "CitiesByContinentIndexes", fmt.Sprintf("continent_index IN (%s)", indexes.String()))
+}
+
+// citiesWhere lists live cities matching condition ordered by index,
+// ContinentUuid & CountryUuid are left to the caller.
+func (db *Database) citiesWhere(ctx context.Context, op string, condition string) (pkg_v1.CityList, error) {
+	var (
+		started = time.Now()
+		results = pkg_v1.CityList{}
+	)
+
+	rows, err := db.ReadQuery(ctx, nil, fmt.Sprintf(
+		`SELECT %s FROM city WHERE %s AND deleted_state != %d ORDER BY index`,
+		new(pkg_v1.City).DatabaseFields(),
+		condition,
+		msql.SoftDeleted,
+	))
+	CheckOperation(op, err, started)
+	if err != nil {
+		return results, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			curr    = &pkg_v1.City{}
+			updated sql.NullTime
+		)
+		if err = rows.Scan(
+			&curr.Index,
+			&curr.ContinentIndex,
+			&curr.CountryIndex,
+			&curr.Uuid,
+			&curr.Name,
+			&curr.Population,
+			&curr.Timezone,
+			&curr.ElevationByM,
+			&curr.PostalCodePatterns,
+			&curr.AlternateNames,
+			&curr.Details,
+			&curr.Creator,
+			&curr.Created,
+			&updated,
+			&curr.DeletedState,
+		); err != nil {
+			CheckOperation(op+" Scan error", err, started)
+			return results, err
+		}
+		if updated.Valid {
+			curr.Updated = updated.Time
+		}
+		results = append(results, curr)
+	}
+
+	return results, rows.Err()
+}
+
 // Note: Country can have only one capital
-func (db *Database) IsCapitalExist(tx *sql.Tx, city *pkg_v1.City, country *pkg_v1.Country) (exist bool, err error) {
+func (db *Database) IsCapitalExist(ctx context.Context, tx *sql.Tx, city *pkg_v1.City, country *pkg_v1.Country) (exist bool, err error) {
 
 	if city.Details != nil && !city.Details.IsCapital {
 		return false, nil
@@ -279,24 +459,26 @@ func (db *Database) IsCapitalExist(tx *sql.Tx, city *pkg_v1.City, country *pkg_v
 	if muuid.UUIDValid(city.Uuid) {
 		query += fmt.Sprintf("AND uuid != '%s'", city.Uuid.String())
 	}
-	if err := db.QueryRow(tx, fmt.Sprintf("SELECT EXISTS(%s)", query)).Scan(&exist); err != nil {
+	if err := db.ReadQueryRow(ctx, tx, fmt.Sprintf("SELECT EXISTS(%s)", query)).Scan(&exist); err != nil {
 		return exist, err
 	}
 
 	return exist, nil
 }
 
-func (db *Database) CreateCity(tx *sql.Tx, city *pkg_v1.City) (*pkg_v1.City, error) {
+func (db *Database) CreateCity(ctx context.Context, tx *sql.Tx, city *pkg_v1.City) (*pkg_v1.City, error) {
+	ctx = WithPrimary(ctx)
+
 	if err := city.ValidateCreate(); err != nil {
 		return nil, err
 	}
 
-	country, err := DB.CountryByUuid(tx, city.CountryUuid.String())
+	country, err := db.CountryByUuid(ctx, tx, city.CountryUuid.String())
 	if err != nil {
-		return nil, err
+		return nil, parentNotFound(err, "country", city.CountryUuid)
 	}
 
-	is_exist, err := DB.IsCapitalExist(tx, city, country)
+	is_exist, err := db.IsCapitalExist(ctx, tx, city, country)
 	if err != nil {
 		return nil, err
 	}
@@ -318,38 +500,72 @@ func (db *Database) CreateCity(tx *sql.Tx, city *pkg_v1.City) (*pkg_v1.City, err
 			"name",
 			"details",
 			"creator",
+			"population",
+			"timezone",
+			"elevation_by_m",
+			"postal_code_patterns",
+			"alternate_names",
 		}
 	)
 
-	continent_index, err := DB.ContinentIndexByUuid(tx, city.ContinentUuid)
+	continent_index, err := db.ContinentIndexByUuid(ctx, tx, city.ContinentUuid)
 	if err != nil {
-		return nil, err
+		return nil, parentNotFound(err, "continent", city.ContinentUuid)
 	}
 
-	_, err = db.Exec(tx,
-		fmt.Sprintf(
-			`INSERT INTO city(%s)
-			VALUES(
-				%d, %d, '%s',
-				'%s', '%s', '%s'
-			)`,
-			mstring.FormatFields(fields...),
-			continent_index,
-			country.Index,
-			uuid.String(),
+	if muuid.UUIDValid(city.Uuid) {
+		if err := db.CheckUuidAvailable(ctx, tx, "city", city.Uuid); err != nil {
+			return nil, err
+		}
+		uuid = city.Uuid
+	}
+
+	var result *pkg_v1.City
+	err = db.InTx(ctx, tx, func(tx *sql.Tx) error {
+		_, err := db.Exec(ctx, tx,
+			fmt.Sprintf(
+				`INSERT INTO city(%s)
+				VALUES(
+					%d, %d, '%s',
+					$1, $2, $3,
+					$4, $5, $6, $7, $8
+				)`,
+				mstring.FormatFields(fields...),
+				continent_index,
+				country.Index,
+				uuid.String(),
+			),
 			city.Name,
-			json_details,
-			json_creator,
-		))
-	CheckOperation("CreateCity", err, started)
+			string(json_details),
+			string(json_creator),
+			city.Population,
+			city.Timezone,
+			city.ElevationByM,
+			city.PostalCodePatterns,
+			city.AlternateNames,
+		)
+		CheckOperation("CreateCity", err, started)
+		if err != nil {
+			return err
+		}
+
+		if result, err = db.CityByUuid(ctx, tx, uuid.String()); err != nil {
+			return err
+		}
+		return db.CreateWebhookOutbox(ctx, tx, pkg_v1.ChangeEntity_City, pkg_v1.ChangeOperation_Insert, result)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return DB.CityByUuid(tx, uuid.String())
+	db.AfterCommit(tx, InvalidateCache)
+	db.AfterCommit(tx, Webhooks.Wake)
+
+	return result, nil
 }
 
-func (db *Database) UpdateCity(tx *sql.Tx, city *pkg_v1.City) (*pkg_v1.City, error) {
+func (db *Database) UpdateCity(ctx context.Context, tx *sql.Tx, city *pkg_v1.City) (*pkg_v1.City, error) {
+	ctx = WithPrimary(ctx)
 
 	if !muuid.UUIDValid(city.Uuid) {
 		return nil, errors.New("Invalid uuid")
@@ -359,12 +575,12 @@ func (db *Database) UpdateCity(tx *sql.Tx, city *pkg_v1.City) (*pkg_v1.City, err
 		return nil, err
 	}
 
-	country, err := DB.CountryByUuid(tx, city.CountryUuid.String())
+	country, err := db.CountryByUuid(ctx, tx, city.CountryUuid.String())
 	if err != nil {
-		return nil, err
+		return nil, parentNotFound(err, "country", city.CountryUuid)
 	}
 
-	is_exist, err := DB.IsCapitalExist(tx, city, country)
+	is_exist, err := db.IsCapitalExist(ctx, tx, city, country)
 	if err != nil {
 		return nil, err
 	}
@@ -378,26 +594,52 @@ func (db *Database) UpdateCity(tx *sql.Tx, city *pkg_v1.City) (*pkg_v1.City, err
 		json_details, _ = json.Marshal(city.Details)
 	)
 
-	_, err = db.Exec(tx,
-		fmt.Sprintf(
-			`UPDATE city SET
-			name='%s',
-			details='%s'
-			WHERE uuid ='%s'
-			AND deleted_state != 1`,
+	var result *pkg_v1.City
+	err = db.InTx(ctx, tx, func(tx *sql.Tx) error {
+		_, err := db.Exec(ctx, tx,
+			fmt.Sprintf(
+				`UPDATE city SET
+				name=$1,
+				details=$2,
+				population=$3,
+				timezone=$4,
+				elevation_by_m=$5,
+				postal_code_patterns=$6,
+				alternate_names=$7
+				WHERE uuid ='%s'
+				AND deleted_state != 1`,
+				city.Uuid.String(),
+			),
 			city.Name,
-			json_details,
-			city.Uuid.String(),
-		))
-	CheckOperation("UpdateCity", err, started)
+			string(json_details),
+			city.Population,
+			city.Timezone,
+			city.ElevationByM,
+			city.PostalCodePatterns,
+			city.AlternateNames,
+		)
+		CheckOperation("UpdateCity", err, started)
+		if err != nil {
+			return err
+		}
+
+		if result, err = db.CityByUuid(ctx, tx, city.Uuid.String()); err != nil {
+			return err
+		}
+		return db.CreateWebhookOutbox(ctx, tx, pkg_v1.ChangeEntity_City, pkg_v1.ChangeOperation_Update, result)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return DB.CityByUuid(tx, city.Uuid.String())
+	db.AfterCommit(tx, InvalidateCache)
+	db.AfterCommit(tx, Webhooks.Wake)
+
+	return result, nil
 }
 
-func (db *Database) SoftDeleteCity(tx *sql.Tx, uuid string) error {
+func (db *Database) SoftDeleteCity(ctx context.Context, tx *sql.Tx, uuid string) error {
+	ctx = WithPrimary(ctx)
 
 	if _, err := muuid.UUIDFromString(uuid); err != nil {
 		return err
@@ -405,17 +647,54 @@ func (db *Database) SoftDeleteCity(tx *sql.Tx, uuid string) error {
 
 	started := time.Now()
 
-	_, err := db.Exec(tx, fmt.Sprintf(
-		`UPDATE city SET
-		deleted_state = %d
-		WHERE uuid ='%s';`,
-		msql.SoftDeleted,
-		uuid,
-	))
-	CheckOperation("SoftDeleteCity", err, started)
+	err := db.InTx(ctx, tx, func(tx *sql.Tx) error {
+		results, err := db.Exec(ctx, tx, fmt.Sprintf(
+			`UPDATE city SET
+			deleted_state = %d
+			WHERE uuid ='%s'
+			AND deleted_state != %d`,
+			msql.SoftDeleted,
+			uuid,
+			msql.SoftDeleted,
+		))
+		CheckOperation("SoftDeleteCity", err, started)
+		if err != nil {
+			return err
+		}
+		if !db.resultChange(results) {
+			return nil
+		}
+
+		return db.CreateWebhookOutbox(ctx, tx, pkg_v1.ChangeEntity_City, pkg_v1.ChangeOperation_Delete, map[string]string{"uuid": uuid})
+	})
 	if err != nil {
 		return err
 	}
 
+	db.AfterCommit(tx, InvalidateCache)
+	db.AfterCommit(tx, Webhooks.Wake)
+
 	return nil
 }
+
+// UpsertCity updates the city with city.Uuid, or creates it with that uuid.
+// created is true for a new city, a soft-deleted uuid is not reused.
+func (db *Database) UpsertCity(ctx context.Context, tx *sql.Tx, city *pkg_v1.City) (result *pkg_v1.City, created bool, err error) {
+	ctx = WithPrimary(ctx)
+
+	state, exist, err := db.UuidDeletedState(ctx, tx, "city", city.Uuid)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !exist {
+		result, err = db.CreateCity(ctx, tx, city)
+		return result, err == nil, err
+	}
+	if state == msql.SoftDeleted {
+		return nil, false, fmt.Errorf("%w: city %s is deleted", ErrUuidExists, city.Uuid.String())
+	}
+
+	result, err = db.UpdateCity(ctx, tx, city)
+	return result, false, err
+}
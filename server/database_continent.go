@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -44,6 +45,16 @@ type ContinentQueryOptions struct {
 	Types ContinentTypeList
 
 	Deleted bool
+
+	// fields= of the response, the SQL projection leaves out the others
+	Fields Fieldset
+
+	// filter[field][operator]=value, compiled to SQL conditions
+	Filters FilterList
+
+	// page of the list ordered by creation, 0 limit for every row
+	Limit  int
+	Offset int
 }
 
 func ContinentOptionsFromQuery(r *http.Request) (ContinentQueryOptions, error) {
@@ -57,6 +68,35 @@ func ContinentOptionsFromQuery(r *http.Request) (ContinentQueryOptions, error) {
 	if err != nil {
 		return options, err
 	}
+	if options.Fields, err = FieldsetFromQuery(r, ContinentOutput); err != nil {
+		return options, err
+	}
+	if options.Filters, err = FiltersFromQuery(r, continentFilterFields); err != nil {
+		return options, err
+	}
+
+	// expand= supersedes the with_* flags
+	expand, ok, err := ExpandFromQuery(r, "countries", "cities")
+	if err != nil {
+		return options, err
+	}
+	if ok {
+		options.WithCountries, options.WithCities = expand["countries"], expand["cities"]
+	}
+	if options.WithCountries {
+		options.Fields.Include("countries")
+	}
+	if options.WithCities && options.WithCountries {
+		options.Fields.Include("countries.cities")
+	}
+	if options.WithCities && !options.WithCountries {
+		options.Fields.Include("cities")
+	}
+
+	options.Limit, options.Offset, err = PageFromQuery(r)
+	if err != nil {
+		return options, err
+	}
 	if len(types) > 0 {
 		for _, v := range types {
 			options.Types = append(options.Types, pkg_v1.ContinentType(v))
@@ -65,7 +105,7 @@ func ContinentOptionsFromQuery(r *http.Request) (ContinentQueryOptions, error) {
 	return options, nil
 }
 
-func (db *Database) ContinentsByOptions(options ContinentQueryOptions) ([]*pkg_v1.Continent, error) {
+func (db *Database) ContinentsByOptions(ctx context.Context, options ContinentQueryOptions) ([]*pkg_v1.Continent, error) {
 	started := time.Now()
 
 	if len(options.Types) == 0 {
@@ -83,7 +123,7 @@ func (db *Database) ContinentsByOptions(options ContinentQueryOptions) ([]*pkg_v
 	var (
 		err     error
 		results = []*pkg_v1.Continent{}
-		fields  = new(pkg_v1.Continent).DatabaseFields()
+		fields  = options.Fields.Columns(new(pkg_v1.Continent).DatabaseFields(), ContinentOutput)
 	)
 
 	query := fmt.Sprintf(
@@ -93,12 +133,17 @@ func (db *Database) ContinentsByOptions(options ContinentQueryOptions) ([]*pkg_v
 	)
 
 	if !options.Deleted {
-		query += fmt.Sprintf(`AND deleted_state != %d`, msql.SoftDeleted)
+		query += fmt.Sprintf(`AND deleted_state != %d `, msql.SoftDeleted)
 	} else {
-		query += fmt.Sprintf(`AND deleted_state = %d`, msql.SoftDeleted)
+		query += fmt.Sprintf(`AND deleted_state = %d `, msql.SoftDeleted)
 	}
 
-	rows, err := db.postgres.Query(query)
+	args := []interface{}{}
+	query += options.Filters.Where(&args)
+	query += `ORDER BY index `
+	query += PageSQL(options.Limit, options.Offset)
+
+	rows, err := db.ReadQuery(ctx, nil, query, args...)
 	CheckOperation("ContinentsByOptions", err, started)
 	if err != nil {
 		return results, err
@@ -140,18 +185,67 @@ func (db *Database) ContinentsByOptions(options ContinentQueryOptions) ([]*pkg_v
 		return results, err
 	}
 
-	if options.WithCities {
-		// @todo import cities belong to continent
+	// Note: only the continents of the page are embedded
+	if options.WithCountries || options.WithCities {
+		if err = db.EmbedContinents(ctx, results, options.WithCountries, options.WithCities); err != nil {
+			return results, err
+		}
 	}
 
-	if options.WithCountries {
-		// @todo import countries belong to continent
+	return results, nil
+}
+
+// EmbedContinents loads the countries and/or cities of continents with one
+// query per level. With both, cities nest in their country, so embedding is
+// at most continent > country > city deep and embedded rows never carry
+// their parents' snapshots.
+func (db *Database) EmbedContinents(ctx context.Context, continents []*pkg_v1.Continent, with_countries bool, with_cities bool) error {
+	if len(continents) == 0 {
+		return nil
 	}
 
-	return results, nil
+	var (
+		indexes       = msql.DatabaseIndexList{}
+		continent_map = map[msql.DatabaseIndex]*pkg_v1.Continent{}
+	)
+	for _, iter := range continents {
+		indexes = append(indexes, iter.Index)
+		continent_map[iter.Index] = iter
+	}
+
+	// Note: countries are loaded for cities alone too, a city is listed
+	// only under a live country like in CitiesByOptions
+	countries, err := db.CountriesByContinentIndexes(ctx, indexes)
+	if err != nil {
+		return err
+	}
+	for _, iter := range countries {
+		continent := continent_map[iter.ContinentIndex]
+		iter.ContinentUuid = continent.Uuid
+
+		if with_countries {
+			continent.Countries = append(continent.Countries, iter)
+		}
+	}
+
+	if !with_cities {
+		return nil
+	}
+	if err = db.EmbedCountries(ctx, countries); err != nil {
+		return err
+	}
+
+	if !with_countries {
+		for _, iter := range countries {
+			continent := continent_map[iter.ContinentIndex]
+			continent.Cities = append(continent.Cities, iter.Cities...)
+			iter.Cities = nil
+		}
+	}
+	return nil
 }
 
-func (db *Database) ContinentByUuid(tx *sql.Tx, uuid string) (*pkg_v1.Continent, error) {
+func (db *Database) ContinentByUuid(ctx context.Context, tx *sql.Tx, uuid string) (*pkg_v1.Continent, error) {
 	if _, err := muuid.UUIDFromString(uuid); err != nil {
 		return nil, err
 	}
@@ -163,7 +257,7 @@ func (db *Database) ContinentByUuid(tx *sql.Tx, uuid string) (*pkg_v1.Continent,
 		updated sql.NullTime
 	)
 
-	err := db.QueryRow(tx,
+	err := db.ReadQueryRow(ctx, tx,
 		fmt.Sprintf(
 			`SELECT %s
 				FROM continent
@@ -195,7 +289,7 @@ func (db *Database) ContinentByUuid(tx *sql.Tx, uuid string) (*pkg_v1.Continent,
 	return result, nil
 }
 
-func (db *Database) ContinentUuidByIndex(tx *sql.Tx, index msql.DatabaseIndex) (muuid.UUID, error) {
+func (db *Database) ContinentUuidByIndex(ctx context.Context, tx *sql.Tx, index msql.DatabaseIndex) (muuid.UUID, error) {
 	var (
 		uuid    = muuid.UUID{}
 		started = time.Now()
@@ -205,7 +299,7 @@ func (db *Database) ContinentUuidByIndex(tx *sql.Tx, index msql.DatabaseIndex) (
 		return uuid, errors.New("Invalid index")
 	}
 
-	err := db.QueryRow(tx,
+	err := db.ReadQueryRow(ctx, tx,
 		fmt.Sprintf(
 			`SELECT
 				uuid
@@ -223,7 +317,7 @@ func (db *Database) ContinentUuidByIndex(tx *sql.Tx, index msql.DatabaseIndex) (
 	return uuid, nil
 }
 
-func (db *Database) ContinentUuidsByIndexes(indexes msql.DatabaseIndexList) (map[msql.DatabaseIndex]muuid.UUID, error) {
+func (db *Database) ContinentUuidsByIndexes(ctx context.Context, indexes msql.DatabaseIndexList) (map[msql.DatabaseIndex]muuid.UUID, error) {
 	var (
 		indexes_map = map[msql.DatabaseIndex]muuid.UUID{}
 		started     = time.Now()
@@ -233,7 +327,7 @@ func (db *Database) ContinentUuidsByIndexes(indexes msql.DatabaseIndexList) (map
 		return indexes_map, errors.New("Invalid continent index")
 	}
 
-	rows, err := db.postgres.Query(
+	rows, err := db.ReadQuery(ctx, nil,
 		fmt.Sprintf(
 			`SELECT
 				index,
@@ -270,7 +364,59 @@ func (db *Database) ContinentUuidsByIndexes(indexes msql.DatabaseIndexList) (map
 	return indexes_map, nil
 }
 
-func (db *Database) ContinentIndexByUuid(tx *sql.Tx, uuid muuid.UUID) (msql.DatabaseIndex, error) {
+// ContinentsByIndexes loads live continents by index in one query, for
+// relations of countries & cities.
+func (db *Database) ContinentsByIndexes(ctx context.Context, indexes msql.DatabaseIndexList) (map[msql.DatabaseIndex]*pkg_v1.Continent, error) {
+	var (
+		started = time.Now()
+		results = map[msql.DatabaseIndex]*pkg_v1.Continent{}
+	)
+
+	if len(indexes) == 0 {
+		return results, nil
+	}
+
+	rows, err := db.ReadQuery(ctx, nil, fmt.Sprintf(
+		`SELECT %s FROM continent WHERE index IN (%s) AND deleted_state != %d`,
+		new(pkg_v1.Continent).DatabaseFields(),
+		indexes.String(),
+		msql.SoftDeleted,
+	))
+	CheckOperation("ContinentsByIndexes", err, started)
+	if err != nil {
+		return results, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			curr    = &pkg_v1.Continent{}
+			updated sql.NullTime
+		)
+		if err = rows.Scan(
+			&curr.Index,
+			&curr.Uuid,
+			&curr.Name,
+			&curr.Type,
+			&curr.AreaByKm2,
+			&curr.Creator,
+			&curr.Created,
+			&updated,
+			&curr.DeletedState,
+		); err != nil {
+			CheckOperation("ContinentsByIndexes Scan error", err, started)
+			return results, err
+		}
+		if updated.Valid {
+			curr.Updated = updated.Time
+		}
+		results[curr.Index] = curr
+	}
+
+	return results, rows.Err()
+}
+
+func (db *Database) ContinentIndexByUuid(ctx context.Context, tx *sql.Tx, uuid muuid.UUID) (msql.DatabaseIndex, error) {
 	var (
 		index   msql.DatabaseIndex
 		started = time.Now()
@@ -280,7 +426,7 @@ func (db *Database) ContinentIndexByUuid(tx *sql.Tx, uuid muuid.UUID) (msql.Data
 		return index, errors.New("Invalid continent uuid")
 	}
 
-	err := db.QueryRow(tx,
+	err := db.ReadQueryRow(ctx, tx,
 		fmt.Sprintf(
 			`SELECT
 				index
@@ -298,7 +444,7 @@ func (db *Database) ContinentIndexByUuid(tx *sql.Tx, uuid muuid.UUID) (msql.Data
 	return index, nil
 }
 
-func (db *Database) ContinentUuidsByIndex(tx *sql.Tx, indexes msql.DatabaseIndexList) (*pkg_v1.Continent, error) {
+func (db *Database) ContinentUuidsByIndex(ctx context.Context, tx *sql.Tx, indexes msql.DatabaseIndexList) (*pkg_v1.Continent, error) {
 	if len(indexes) == 0 {
 		return nil, errors.New("Invalid index")
 	}
@@ -308,7 +454,7 @@ func (db *Database) ContinentUuidsByIndex(tx *sql.Tx, indexes msql.DatabaseIndex
 		started = time.Now()
 	)
 
-	rows, err := db.postgres.Query(
+	rows, err := db.ReadQuery(ctx, tx,
 		fmt.Sprintf(
 			`SELECT
 				uuid,
@@ -333,7 +479,7 @@ func (db *Database) ContinentUuidsByIndex(tx *sql.Tx, indexes msql.DatabaseIndex
 	return result, nil
 }
 
-func (db *Database) IsContinentTypeExist(tx *sql.Tx, continent *pkg_v1.Continent) (exist bool, err error) {
+func (db *Database) IsContinentTypeExist(ctx context.Context, tx *sql.Tx, continent *pkg_v1.Continent) (exist bool, err error) {
 
 	var query = fmt.Sprintf(
 		`SELECT uuid FROM continent WHERE type = %d AND deleted_state != %d `,
@@ -343,19 +489,21 @@ func (db *Database) IsContinentTypeExist(tx *sql.Tx, continent *pkg_v1.Continent
 	if muuid.UUIDValid(continent.Uuid) {
 		query += fmt.Sprintf("AND uuid != '%s'", continent.Uuid.String())
 	}
-	if err := db.QueryRow(tx, fmt.Sprintf("SELECT EXISTS(%s)", query)).Scan(&exist); err != nil {
+	if err := db.ReadQueryRow(ctx, tx, fmt.Sprintf("SELECT EXISTS(%s)", query)).Scan(&exist); err != nil {
 		return exist, err
 	}
 
 	return exist, nil
 }
 
-func (db *Database) CreateContinent(tx *sql.Tx, continent *pkg_v1.Continent) (*pkg_v1.Continent, error) {
+func (db *Database) CreateContinent(ctx context.Context, tx *sql.Tx, continent *pkg_v1.Continent) (*pkg_v1.Continent, error) {
+	ctx = WithPrimary(ctx)
+
 	if err := continent.ValidateCreate(); err != nil {
 		return nil, err
 	}
 
-	type_exist, err := DB.IsContinentTypeExist(tx, continent)
+	type_exist, err := db.IsContinentTypeExist(ctx, tx, continent)
 	if err != nil {
 		return nil, err
 	}
@@ -378,30 +526,52 @@ func (db *Database) CreateContinent(tx *sql.Tx, continent *pkg_v1.Continent) (*p
 		}
 	)
 
-	_, err = db.Exec(tx,
-		fmt.Sprintf(
-			`INSERT INTO continent(%s)
-			VALUES(
-				'%s', '%s', %d,
-				%f, '%s'
-			)`,
-			mstring.FormatFields(fields...),
-			uuid.String(),
-			continent.Name,
-			continent.Type,
-			continent.AreaByKm2,
-			json_creator,
-		))
-	CheckOperation("CreateContinent", err, started)
+	if muuid.UUIDValid(continent.Uuid) {
+		if err := db.CheckUuidAvailable(ctx, tx, "continent", continent.Uuid); err != nil {
+			return nil, err
+		}
+		uuid = continent.Uuid
+	}
+
+	var result *pkg_v1.Continent
+	err = db.InTx(ctx, tx, func(tx *sql.Tx) error {
+		_, err := db.Exec(ctx, tx,
+			fmt.Sprintf(
+				`INSERT INTO continent(%s)
+				VALUES(
+					'%s', '%s', %d,
+					%f, '%s'
+				)`,
+				mstring.FormatFields(fields...),
+				uuid.String(),
+				continent.Name,
+				continent.Type,
+				continent.AreaByKm2,
+				json_creator,
+			))
+		CheckOperation("CreateContinent", err, started)
+		if err != nil {
+			return err
+		}
+
+		if result, err = db.ContinentByUuid(ctx, tx, uuid.String()); err != nil {
+			return err
+		}
+		return db.CreateWebhookOutbox(ctx, tx, pkg_v1.ChangeEntity_Continent, pkg_v1.ChangeOperation_Insert, result)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return DB.ContinentByUuid(tx, uuid.String())
+	db.AfterCommit(tx, InvalidateCache)
+	db.AfterCommit(tx, Webhooks.Wake)
+
+	return result, nil
 }
 
 // update continent
-func (db *Database) UpdateContinent(tx *sql.Tx, continent *pkg_v1.Continent) (*pkg_v1.Continent, error) {
+func (db *Database) UpdateContinent(ctx context.Context, tx *sql.Tx, continent *pkg_v1.Continent) (*pkg_v1.Continent, error) {
+	ctx = WithPrimary(ctx)
 
 	if !muuid.UUIDValid(continent.Uuid) {
 		return nil, errors.New("Invalid uuid")
@@ -411,7 +581,7 @@ func (db *Database) UpdateContinent(tx *sql.Tx, continent *pkg_v1.Continent) (*p
 		return nil, err
 	}
 
-	type_exist, err := DB.IsContinentTypeExist(tx, continent)
+	type_exist, err := db.IsContinentTypeExist(ctx, tx, continent)
 	if err != nil {
 		return nil, err
 	}
@@ -424,29 +594,44 @@ func (db *Database) UpdateContinent(tx *sql.Tx, continent *pkg_v1.Continent) (*p
 		started = time.Now()
 	)
 
-	_, err = db.Exec(tx,
-		fmt.Sprintf(
-			`UPDATE continent SET
-			name='%s',
-			type=%d,
-			area_by_km2=%f
-			WHERE uuid ='%s'
-			AND deleted_state != 1`,
-			continent.Name,
-			continent.Type,
-			continent.AreaByKm2,
-			continent.Uuid.String(),
-		))
-	CheckOperation("UpdateContinent", err, started)
+	var result *pkg_v1.Continent
+	err = db.InTx(ctx, tx, func(tx *sql.Tx) error {
+		_, err := db.Exec(ctx, tx,
+			fmt.Sprintf(
+				`UPDATE continent SET
+				name='%s',
+				type=%d,
+				area_by_km2=%f
+				WHERE uuid ='%s'
+				AND deleted_state != 1`,
+				continent.Name,
+				continent.Type,
+				continent.AreaByKm2,
+				continent.Uuid.String(),
+			))
+		CheckOperation("UpdateContinent", err, started)
+		if err != nil {
+			return err
+		}
+
+		if result, err = db.ContinentByUuid(ctx, tx, continent.Uuid.String()); err != nil {
+			return err
+		}
+		return db.CreateWebhookOutbox(ctx, tx, pkg_v1.ChangeEntity_Continent, pkg_v1.ChangeOperation_Update, result)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return DB.ContinentByUuid(tx, continent.Uuid.String())
+	db.AfterCommit(tx, InvalidateCache)
+	db.AfterCommit(tx, Webhooks.Wake)
+
+	return result, nil
 }
 
 // delete continent
-func (db *Database) SoftDeleteContinent(tx *sql.Tx, uuid string) error {
+func (db *Database) SoftDeleteContinent(ctx context.Context, tx *sql.Tx, uuid string) error {
+	ctx = WithPrimary(ctx)
 
 	if _, err := muuid.UUIDFromString(uuid); err != nil {
 		return err
@@ -454,17 +639,55 @@ func (db *Database) SoftDeleteContinent(tx *sql.Tx, uuid string) error {
 
 	started := time.Now()
 
-	_, err := db.Exec(tx, fmt.Sprintf(
-		`UPDATE continent SET
-		deleted_state = %d
-		WHERE uuid ='%s';`,
-		msql.SoftDeleted,
-		uuid,
-	))
-	CheckOperation("SoftDeleteContinent", err, started)
+	err := db.InTx(ctx, tx, func(tx *sql.Tx) error {
+		results, err := db.Exec(ctx, tx, fmt.Sprintf(
+			`UPDATE continent SET
+			deleted_state = %d
+			WHERE uuid ='%s'
+			AND deleted_state != %d`,
+			msql.SoftDeleted,
+			uuid,
+			msql.SoftDeleted,
+		))
+		CheckOperation("SoftDeleteContinent", err, started)
+		if err != nil {
+			return err
+		}
+		// Note: deleting a missing or deleted row is not an event
+		if !db.resultChange(results) {
+			return nil
+		}
+
+		return db.CreateWebhookOutbox(ctx, tx, pkg_v1.ChangeEntity_Continent, pkg_v1.ChangeOperation_Delete, map[string]string{"uuid": uuid})
+	})
 	if err != nil {
 		return err
 	}
 
+	db.AfterCommit(tx, InvalidateCache)
+	db.AfterCommit(tx, Webhooks.Wake)
+
 	return nil
 }
+
+// UpsertContinent updates the continent with continent.Uuid, or creates it with that uuid.
+// created is true for a new continent, a soft-deleted uuid is not reused.
+func (db *Database) UpsertContinent(ctx context.Context, tx *sql.Tx, continent *pkg_v1.Continent) (result *pkg_v1.Continent, created bool, err error) {
+	ctx = WithPrimary(ctx)
+
+	state, exist, err := db.UuidDeletedState(ctx, tx, "continent", continent.Uuid)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !exist {
+		result, err = db.CreateContinent(ctx, tx, continent)
+		return result, err == nil, err
+	}
+	if state == msql.SoftDeleted {
+		return nil, false, fmt.Errorf("%w: continent %s is deleted", ErrUuidExists, continent.Uuid.String())
+	}
+
+	result, err = db.UpdateContinent(ctx, tx, continent)
+	return result, false, err
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -23,6 +24,19 @@ type CountryQueryOptions struct {
 	ContinentTypes ContinentTypeList
 
 	Deleted bool
+
+	// fields= of the response, the SQL projection leaves out the others
+	Fields Fieldset
+
+	// filter[field][operator]=value, compiled to SQL conditions
+	Filters FilterList
+
+	// sort=-population,name, creation order by default
+	Sort SortList
+
+	// page of the list, 0 limit for every row
+	Limit  int
+	Offset int
 }
 
 func CountryOptionsFromQuery(r *http.Request) (CountryQueryOptions, error) {
@@ -38,6 +52,35 @@ func CountryOptionsFromQuery(r *http.Request) (CountryQueryOptions, error) {
 	if err != nil {
 		return options, err
 	}
+	if options.Fields, err = FieldsetFromQuery(r, CountryOutput); err != nil {
+		return options, err
+	}
+	if options.Filters, err = FiltersFromQuery(r, countryFilterFields); err != nil {
+		return options, err
+	}
+	if options.Sort, err = SortFromQuery(r, countrySortFields); err != nil {
+		return options, err
+	}
+
+	// expand= supersedes the with_* flags
+	expand, ok, err := ExpandFromQuery(r, "continent", "cities")
+	if err != nil {
+		return options, err
+	}
+	if ok {
+		options.WithContinent, options.WithCities = expand["continent"], expand["cities"]
+	}
+	if options.WithContinent {
+		options.Fields.Include("details.continent")
+	}
+	if options.WithCities {
+		options.Fields.Include("cities")
+	}
+
+	options.Limit, options.Offset, err = PageFromQuery(r)
+	if err != nil {
+		return options, err
+	}
 	if len(continent_types) > 0 {
 		for _, v := range continent_types {
 			options.ContinentTypes = append(options.ContinentTypes, pkg_v1.ContinentType(v))
@@ -47,13 +90,13 @@ func CountryOptionsFromQuery(r *http.Request) (CountryQueryOptions, error) {
 	return options, nil
 }
 
-func (db *Database) CountriesByOptions(options CountryQueryOptions) (pkg_v1.CountryList, error) {
+func (db *Database) CountriesByOptions(ctx context.Context, options CountryQueryOptions) (pkg_v1.CountryList, error) {
 
 	var (
 		err     error
 		started = time.Now()
 		results = pkg_v1.CountryList{}
-		fields  = new(pkg_v1.Country).DatabaseFields()
+		fields  = options.Fields.Columns(new(pkg_v1.Country).DatabaseFields(), CountryOutput)
 	)
 
 	if len(options.ContinentTypes) == 0 {
@@ -82,8 +125,14 @@ func (db *Database) CountriesByOptions(options CountryQueryOptions) (pkg_v1.Coun
 	if len(options.CountryUuids) > 0 {
 		query += fmt.Sprintf("AND uuid IN (%s) ", mstring.FormatStringValues(options.CountryUuids...))
 	}
+	query += fmt.Sprintf("AND continent_index IN (%s) ", continentTypesSQL(options.ContinentTypes))
+
+	args := []interface{}{}
+	query += options.Filters.Where(&args)
+	query += options.Sort.OrderBy() + " "
+	query += PageSQL(options.Limit, options.Offset)
 
-	rows, err := db.postgres.Query(query)
+	rows, err := db.ReadQuery(ctx, nil, query, args...)
 	CheckOperation("CountrysByOptions", err, started)
 	if err != nil {
 		return results, err
@@ -101,6 +150,9 @@ func (db *Database) CountriesByOptions(options CountryQueryOptions) (pkg_v1.Coun
 			&curr.ContinentIndex,
 			&curr.Uuid,
 			&curr.Name,
+			&curr.Population,
+			&curr.AreaByKm2,
+			&curr.GDP,
 			&curr.Details,
 			&curr.Creator,
 			&curr.Created,
@@ -125,62 +177,84 @@ func (db *Database) CountriesByOptions(options CountryQueryOptions) (pkg_v1.Coun
 		return results, err
 	}
 
-	filters, err := ToCountries(started, results, options)
-	if err != nil {
+	if err = db.attachCountryContinents(ctx, results, options.WithContinent); err != nil {
 		return results, err
 	}
 
-	return filters, nil
+	if options.WithCities {
+		if err = db.EmbedCountries(ctx, results); err != nil {
+			return results, err
+		}
+	}
+
+	return results, nil
 }
 
-func ToCountries(started time.Time, countries pkg_v1.CountryList, options CountryQueryOptions) (pkg_v1.CountryList, error) {
+// EmbedCountries loads the cities of countries in one query, the cities
+// carry no country or continent snapshot.
+func (db *Database) EmbedCountries(ctx context.Context, countries pkg_v1.CountryList) error {
+	if len(countries) == 0 {
+		return nil
+	}
 
-	if started.IsZero() {
-		started = time.Now()
+	var (
+		indexes     = msql.DatabaseIndexList{}
+		country_map = map[msql.DatabaseIndex]*pkg_v1.Country{}
+	)
+	for _, iter := range countries {
+		indexes = append(indexes, iter.Index)
+		country_map[iter.Index] = iter
 	}
 
-	continents, err := DB.ContinentsByOptions(ContinentQueryOptions{Types: options.ContinentTypes})
-	CheckOperation("ToCountries ", err, started)
+	cities, err := db.CitiesByCountryIndexes(ctx, indexes)
 	if err != nil {
-		return pkg_v1.CountryList{}, err
+		return err
+	}
+	for _, iter := range cities {
+		country := country_map[iter.CountryIndex]
+		iter.ContinentUuid = country.ContinentUuid
+		iter.CountryUuid = country.Uuid
+		country.Cities = append(country.Cities, iter)
 	}
+	return nil
+}
 
-	var (
-		continent_map    = map[msql.DatabaseIndex]*pkg_v1.Continent{}
-		filter_countries = pkg_v1.CountryList{}
-	)
+// attachCountryContinents sets the continent uuid of countries, and their
+// continent snapshot when with_continent.
+func (db *Database) attachCountryContinents(ctx context.Context, countries pkg_v1.CountryList, with_continent bool) error {
+	if len(countries) == 0 {
+		return nil
+	}
 
-	// create continents map
-	for _, iter := range continents {
-		continent_map[iter.Index] = iter
+	indexes := msql.DatabaseIndexList{}
+	for _, iter := range countries {
+		indexes = append(indexes, iter.ContinentIndex)
+	}
+
+	continent_map, err := db.ContinentsByIndexes(ctx, indexes)
+	if err != nil {
+		return err
 	}
 
-	// build countries base on continent options
 	for _, iter := range countries {
 		iter_continent := continent_map[iter.ContinentIndex]
-
 		if iter_continent == nil {
 			continue
 		}
 
-		// skip country
-		if !options.ContinentTypes.Contains(iter_continent.Type) {
-			continue
-		}
-
 		// get continent snapshot if requested
-		if options.WithContinent {
+		if with_continent {
+			if iter.Details == nil {
+				iter.Details = &pkg_v1.CountryDetails{}
+			}
 			iter.Details.Continent = iter_continent
 		}
 		iter.ContinentUuid = iter_continent.Uuid
-
-		filter_countries = append(filter_countries, iter)
 	}
-
-	return filter_countries, nil
+	return nil
 }
 
-func (db *Database) CountryByUuid(tx *sql.Tx, uuid string) (*pkg_v1.Country, error) {
+func (db *Database) CountryByUuid(ctx context.Context, tx *sql.Tx, uuid string) (*pkg_v1.Country, error) {
 	if _, err := muuid.UUIDFromString(uuid); err != nil {
 		return nil, err
 	}
@@ -192,7 +266,7 @@ func (db *Database) CountryByUuid(tx *sql.Tx, uuid string) (*pkg_v1.Country, err
 		updated sql.NullTime
 	)
 
-	err := db.QueryRow(tx,
+	err := db.ReadQueryRow(ctx, tx,
 		fmt.Sprintf(
 			`SELECT %s
 				FROM country
@@ -205,6 +279,9 @@ func (db *Database) CountryByUuid(tx *sql.Tx, uuid string) (*pkg_v1.Country, err
 		&result.ContinentIndex,
 		&result.Uuid,
 		&result.Name,
+		&result.Population,
+		&result.AreaByKm2,
+		&result.GDP,
 		&result.Details,
 		&result.Creator,
 		&result.Created,
@@ -223,7 +300,7 @@ func (db *Database) CountryByUuid(tx *sql.Tx, uuid string) (*pkg_v1.Country, err
 
 	// Get continent uuid
 	{
-		continent_uuid, err := DB.ContinentUuidByIndex(tx, result.ContinentIndex)
+		continent_uuid, err := db.ContinentUuidByIndex(ctx, tx, result.ContinentIndex)
 		if err != nil {
 			return nil, err
 		}
@@ -233,7 +310,7 @@ func (db *Database) CountryByUuid(tx *sql.Tx, uuid string) (*pkg_v1.Country, err
 	return result, nil
 }
 
-func (db *Database) IsCountryExist(tx *sql.Tx, country *pkg_v1.Country) (exist bool, err error) {
+func (db *Database) IsCountryExist(ctx context.Context, tx *sql.Tx, country *pkg_v1.Country) (exist bool, err error) {
 
 	var query = fmt.Sprintf(
 		`SELECT uuid FROM country
@@ -248,19 +325,21 @@ func (db *Database) IsCountryExist(tx *sql.Tx, country *pkg_v1.Country) (exist b
 	if muuid.UUIDValid(country.Uuid) {
 		query += fmt.Sprintf("AND uuid != '%s'", country.Uuid.String())
 	}
-	if err := db.QueryRow(tx, fmt.Sprintf("SELECT EXISTS(%s)", query)).Scan(&exist); err != nil {
+	if err := db.ReadQueryRow(ctx, tx, fmt.Sprintf("SELECT EXISTS(%s)", query)).Scan(&exist); err != nil {
 		return exist, err
 	}
 
 	return exist, nil
 }
 
-func (db *Database) CreateCountry(tx *sql.Tx, country *pkg_v1.Country) (*pkg_v1.Country, error) {
+func (db *Database) CreateCountry(ctx context.Context, tx *sql.Tx, country *pkg_v1.Country) (*pkg_v1.Country, error) {
+	ctx = WithPrimary(ctx)
+
 	if err := country.ValidateCreate(); err != nil {
 		return nil, err
 	}
 
-	is_exist, err := DB.IsCountryExist(tx, country)
+	is_exist, err := db.IsCountryExist(ctx, tx, country)
 	if err != nil {
 		return nil, err
 	}
@@ -284,35 +363,57 @@ func (db *Database) CreateCountry(tx *sql.Tx, country *pkg_v1.Country) (*pkg_v1.
 		}
 	)
 
-	continent_index, err := DB.ContinentIndexByUuid(tx, country.ContinentUuid)
+	continent_index, err := db.ContinentIndexByUuid(ctx, tx, country.ContinentUuid)
 	CheckOperation("CreateCountry Continent Uuud", err, started)
 	if err != nil {
-		return nil, err
+		return nil, parentNotFound(err, "continent", country.ContinentUuid)
 	}
 
-	_, err = db.Exec(tx,
-		fmt.Sprintf(
-			`INSERT INTO country(%s)
-			VALUES(
-				%d, '%s', '%s',
-				'%s', '%s'
-			)`,
-			mstring.FormatFields(fields...),
-			continent_index,
-			uuid.String(),
-			country.Name,
-			json_details,
-			json_creator,
-		))
-	CheckOperation("CreateCountry", err, started)
+	if muuid.UUIDValid(country.Uuid) {
+		if err := db.CheckUuidAvailable(ctx, tx, "country", country.Uuid); err != nil {
+			return nil, err
+		}
+		uuid = country.Uuid
+	}
+
+	var result *pkg_v1.Country
+	err = db.InTx(ctx, tx, func(tx *sql.Tx) error {
+		_, err := db.Exec(ctx, tx,
+			fmt.Sprintf(
+				`INSERT INTO country(%s)
+				VALUES(
+					%d, '%s', '%s',
+					'%s', '%s'
+				)`,
+				mstring.FormatFields(fields...),
+				continent_index,
+				uuid.String(),
+				country.Name,
+				json_details,
+				json_creator,
+			))
+		CheckOperation("CreateCountry", err, started)
+		if err != nil {
+			return err
+		}
+
+		if result, err = db.CountryByUuid(ctx, tx, uuid.String()); err != nil {
+			return err
+		}
+		return db.CreateWebhookOutbox(ctx, tx, pkg_v1.ChangeEntity_Country, pkg_v1.ChangeOperation_Insert, result)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return DB.CountryByUuid(tx, uuid.String())
+	db.AfterCommit(tx, InvalidateCache)
+	db.AfterCommit(tx, Webhooks.Wake)
+
+	return result, nil
 }
 
-func (db *Database) UpdateCountry(tx *sql.Tx, country *pkg_v1.Country) (*pkg_v1.Country, error) {
+func (db *Database) UpdateCountry(ctx context.Context, tx *sql.Tx, country *pkg_v1.Country) (*pkg_v1.Country, error) {
+	ctx = WithPrimary(ctx)
 
 	if !muuid.UUIDValid(country.Uuid) {
 		return nil, errors.New("Invalid uuid")
@@ -322,7 +423,7 @@ func (db *Database) UpdateCountry(tx *sql.Tx, country *pkg_v1.Country) (*pkg_v1.
 		return nil, err
 	}
 
-	type_exist, err := DB.IsCountryExist(tx, country)
+	type_exist, err := db.IsCountryExist(ctx, tx, country)
 	if err != nil {
 		return nil, err
 	}
@@ -336,26 +437,41 @@ func (db *Database) UpdateCountry(tx *sql.Tx, country *pkg_v1.Country) (*pkg_v1.
 		json_details, _ = json.Marshal(country.Details)
 	)
 
-	_, err = db.Exec(tx,
-		fmt.Sprintf(
-			`UPDATE country SET
-			name='%s',
-			details='%s'
-			WHERE uuid ='%s'
-			AND deleted_state != 1`,
-			country.Name,
-			json_details,
-			country.Uuid.String(),
-		))
-	CheckOperation("UpdateCountry", err, started)
+	var result *pkg_v1.Country
+	err = db.InTx(ctx, tx, func(tx *sql.Tx) error {
+		_, err := db.Exec(ctx, tx,
+			fmt.Sprintf(
+				`UPDATE country SET
+				name='%s',
+				details='%s'
+				WHERE uuid ='%s'
+				AND deleted_state != 1`,
+				country.Name,
+				json_details,
+				country.Uuid.String(),
+			))
+		CheckOperation("UpdateCountry", err, started)
+		if err != nil {
+			return err
+		}
+
+		if result, err = db.CountryByUuid(ctx, tx, country.Uuid.String()); err != nil {
+			return err
+		}
+		return db.CreateWebhookOutbox(ctx, tx, pkg_v1.ChangeEntity_Country, pkg_v1.ChangeOperation_Update, result)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return DB.CountryByUuid(tx, country.Uuid.String())
+	db.AfterCommit(tx, InvalidateCache)
+	db.AfterCommit(tx, Webhooks.Wake)
+
+	return result, nil
 }
 
-func (db *Database) SoftDeleteCountry(tx *sql.Tx, uuid string) error {
+func (db *Database) SoftDeleteCountry(ctx context.Context, tx *sql.Tx, uuid string) error {
+	ctx = WithPrimary(ctx)
 
 	if _, err := muuid.UUIDFromString(uuid); err != nil {
 		return err
@@ -363,22 +479,110 @@ func (db *Database) SoftDeleteCountry(tx *sql.Tx, uuid string) error {
 
 	started := time.Now()
 
-	_, err := db.Exec(tx, fmt.Sprintf(
-		`UPDATE country SET
-		deleted_state = %d
-		WHERE uuid ='%s';`,
-		msql.SoftDeleted,
-		uuid,
-	))
-	CheckOperation("SoftDeleteCountry", err, started)
+	err := db.InTx(ctx, tx, func(tx *sql.Tx) error {
+		results, err := db.Exec(ctx, tx, fmt.Sprintf(
+			`UPDATE country SET
+			deleted_state = %d
+			WHERE uuid ='%s'
+			AND deleted_state != %d`,
+			msql.SoftDeleted,
+			uuid,
+			msql.SoftDeleted,
+		))
+		CheckOperation("SoftDeleteCountry", err, started)
+		if err != nil {
+			return err
+		}
+		if !db.resultChange(results) {
+			return nil
+		}
+
+		return db.CreateWebhookOutbox(ctx, tx, pkg_v1.ChangeEntity_Country, pkg_v1.ChangeOperation_Delete, map[string]string{"uuid": uuid})
+	})
 	if err != nil {
 		return err
 	}
 
+	db.AfterCommit(tx, InvalidateCache)
+	db.AfterCommit(tx, Webhooks.Wake)
+
 	return nil
 }
 
-func (db *Database) CountryUuidByIndex(tx *sql.Tx, index msql.DatabaseIndex) (muuid.UUID, error) {
+// CountriesByIndexes loads live countries by index in one query.
+func (db *Database) CountriesByIndexes(ctx context.Context, indexes msql.DatabaseIndexList) (map[msql.DatabaseIndex]*pkg_v1.Country, error) {
+	results := map[msql.DatabaseIndex]*pkg_v1.Country{}
+	if len(indexes) == 0 {
+		return results, nil
+	}
+
+	countries, err := db.countriesWhere(ctx, "CountriesByIndexes", fmt.Sprintf("index IN (%s)", indexes.String()))
+	for _, iter := range countries {
+		results[iter.Index] = iter
+	}
+	return results, err
+}
+
+// CountriesByContinentIndexes loads the live countries of continents in one query.
+func (db *Database) CountriesByContinentIndexes(ctx context.Context, indexes msql.DatabaseIndexList) (pkg_v1.CountryList, error) {
+	if len(indexes) == 0 {
+		return pkg_v1.CountryList{}, nil
+	}
+	return db.countriesWhere(ctx, "CountriesByContinentIndexes", fmt.Sprintf("continent_index IN (%s)", indexes.String()))
+}
+
+// countriesWhere lists live countries matching condition ordered by index,
+// ContinentUuid is left to the caller.
+func (db *Database) countriesWhere(ctx context.Context, op string, condition string) (pkg_v1.CountryList, error) {
+	var (
+		started = time.Now()
+		results = pkg_v1.CountryList{}
+	)
+
+	rows, err := db.ReadQuery(ctx, nil, fmt.Sprintf(
+		`SELECT %s FROM country WHERE %s AND deleted_state != %d ORDER BY index`,
+		new(pkg_v1.Country).DatabaseFields(),
+		condition,
+		msql.SoftDeleted,
+	))
+	CheckOperation(op, err, started)
+	if err != nil {
+		return results, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			curr    = &pkg_v1.Country{}
+			updated sql.NullTime
+		)
+		if err = rows.Scan(
+			&curr.Index,
+			&curr.ContinentIndex,
+			&curr.Uuid,
+			&curr.Name,
+			&curr.Population,
+			&curr.AreaByKm2,
+			&curr.GDP,
+			&curr.Details,
+			&curr.Creator,
+			&curr.Created,
+			&updated,
+			&curr.DeletedState,
+		); err != nil {
+			CheckOperation(op+" Scan error", err, started)
+			return results, err
+		}
+		if updated.Valid {
+			curr.Updated = updated.Time
+		}
+		results = append(results, curr)
+	}
+
+	return results, rows.Err()
+}
+
+func (db *Database) CountryUuidByIndex(ctx context.Context, tx *sql.Tx, index msql.DatabaseIndex) (muuid.UUID, error) {
 	var (
 		uuid    = muuid.UUID{}
 		started = time.Now()
@@ -388,7 +592,7 @@ func (db *Database) CountryUuidByIndex(tx *sql.Tx, index msql.DatabaseIndex) (mu
 		return uuid, errors.New("Invalid country index")
 	}
 
-	err := db.QueryRow(tx,
+	err := db.ReadQueryRow(ctx, tx,
 		fmt.Sprintf(
 			`SELECT
 				uuid
@@ -406,7 +610,7 @@ func (db *Database) CountryUuidByIndex(tx *sql.Tx, index msql.DatabaseIndex) (mu
 	return uuid, nil
 }
 
-func (db *Database) CountryIndexByUuid(tx *sql.Tx, uuid muuid.UUID) (msql.DatabaseIndex, error) {
+func (db *Database) CountryIndexByUuid(ctx context.Context, tx *sql.Tx, uuid muuid.UUID) (msql.DatabaseIndex, error) {
 	var (
 		index   msql.DatabaseIndex
 		started = time.Now()
@@ -416,7 +620,7 @@ func (db *Database) CountryIndexByUuid(tx *sql.Tx, uuid muuid.UUID) (msql.Databa
 		return index, errors.New("Invalid country uuid")
 	}
 
-	err := db.QueryRow(tx,
+	err := db.ReadQueryRow(ctx, tx,
 		fmt.Sprintf(
 			`SELECT
 				index
@@ -433,3 +637,102 @@ func (db *Database) CountryIndexByUuid(tx *sql.Tx, uuid muuid.UUID) (msql.Databa
 
 	return index, nil
 }
+
+// UpsertCountry updates the country with country.Uuid, or creates it with that uuid.
+// created is true for a new country, a soft-deleted uuid is not reused.
+func (db *Database) UpsertCountry(ctx context.Context, tx *sql.Tx, country *pkg_v1.Country) (result *pkg_v1.Country, created bool, err error) {
+	ctx = WithPrimary(ctx)
+
+	state, exist, err := db.UuidDeletedState(ctx, tx, "country", country.Uuid)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !exist {
+		result, err = db.CreateCountry(ctx, tx, country)
+		return result, err == nil, err
+	}
+	if state == msql.SoftDeleted {
+		return nil, false, fmt.Errorf("%w: country %s is deleted", ErrUuidExists, country.Uuid.String())
+	}
+
+	result, err = db.UpdateCountry(ctx, tx, country)
+	return result, false, err
+}
+
+////////////////////////////
+/////// Country summary
+
+// CountrySummaries aggregates the cities of countries, with one count query
+// and one capital query for all of them.
+func (db *Database) CountrySummaries(ctx context.Context, countries pkg_v1.CountryList) ([]*pkg_v1.CountrySummary, error) {
+	var (
+		started     = time.Now()
+		results     = []*pkg_v1.CountrySummary{}
+		indexes     = msql.DatabaseIndexList{}
+		summary_map = map[msql.DatabaseIndex]*pkg_v1.CountrySummary{}
+	)
+
+	if len(countries) == 0 {
+		return results, nil
+	}
+
+	for _, iter := range countries {
+		summary := &pkg_v1.CountrySummary{
+			Uuid:          iter.Uuid,
+			ContinentUuid: iter.ContinentUuid,
+			Name:          iter.Name,
+			Population:    iter.Population,
+			AreaByKm2:     iter.AreaByKm2,
+		}
+		indexes = append(indexes, iter.Index)
+		summary_map[iter.Index] = summary
+		results = append(results, summary)
+	}
+
+	rows, err := db.ReadQuery(ctx, nil, fmt.Sprintf(
+		`SELECT country_index, COUNT(*), SUM(population) FROM city
+		WHERE country_index IN (%s)
+		AND deleted_state != %d
+		GROUP BY country_index`,
+		indexes.String(),
+		msql.SoftDeleted,
+	))
+	CheckOperation("CountrySummaries", err, started)
+	if err != nil {
+		return results, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			index      msql.DatabaseIndex
+			count      int
+			population sql.NullInt64
+		)
+		if err = rows.Scan(&index, &count, &population); err != nil {
+			Log.Warnf("DB.CountrySummaries Scan error - %s", err.Error())
+			return results, err
+		}
+		summary_map[index].CityCount = count
+		if population.Valid {
+			summary_map[index].CityPopulation = &population.Int64
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return results, err
+	}
+
+	capitals, err := db.citiesWhere(ctx, "CountryCapitals", fmt.Sprintf("country_index IN (%s) AND %s", indexes.String(), cityCapitalColumn))
+	if err != nil {
+		return results, err
+	}
+	for _, iter := range capitals {
+		summary := summary_map[iter.CountryIndex]
+		iter.ContinentUuid = summary.ContinentUuid
+		iter.CountryUuid = summary.Uuid
+		summary.Capital = iter
+	}
+
+	return results, nil
+}
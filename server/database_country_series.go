@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+	"github.com/nhht77/earth-rest-api/server/pkg/mhttp"
+	muuid "github.com/nhht77/earth-rest-api/server/pkg/muuid"
+)
+
+type CountrySeriesQueryOptions struct {
+	// inclusive year range, the whole series by default
+	From int
+	To   int
+}
+
+func CountrySeriesOptionsFromQuery(r *http.Request) (CountrySeriesQueryOptions, error) {
+	options := CountrySeriesQueryOptions{From: pkg_v1.CountryYear_Min, To: pkg_v1.CountryYear_Max}
+
+	for _, key := range []string{"from", "to"} {
+		dest := &options.From
+		if key == "to" {
+			dest = &options.To
+		}
+
+		value := mhttp.Query(r, key)
+		if len(value) == 0 {
+			continue
+		}
+		year, err := strconv.Atoi(value)
+		if err != nil || year < pkg_v1.CountryYear_Min || year > pkg_v1.CountryYear_Max {
+			return options, fmt.Errorf("%s must be a year between %d and %d", key, pkg_v1.CountryYear_Min, pkg_v1.CountryYear_Max)
+		}
+		*dest = year
+	}
+
+	if options.From > options.To {
+		return options, errors.New("from must not be after to")
+	}
+	return options, nil
+}
+
+// CountrySeriesByUuid lists the years of a live country within the options
+// range, oldest first.
+func (db *Database) CountrySeriesByUuid(ctx context.Context, tx *sql.Tx, uuid muuid.UUID, options CountrySeriesQueryOptions) (pkg_v1.CountrySeries, error) {
+	results := pkg_v1.CountrySeries{}
+
+	country_index, err := db.CountryIndexByUuid(ctx, tx, uuid)
+	if err != nil {
+		return results, err
+	}
+
+	started := time.Now()
+	rows, err := db.ReadQuery(ctx, tx,
+		`SELECT year, population, area_by_km2, gdp, COALESCE(updated, created)
+		FROM country_series
+		WHERE country_index = $1 AND year BETWEEN $2 AND $3
+		ORDER BY year`,
+		country_index,
+		options.From,
+		options.To,
+	)
+	CheckOperation("CountrySeriesByUuid", err, started)
+	if err != nil {
+		return results, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		curr := &pkg_v1.CountryYear{}
+		if err = rows.Scan(
+			&curr.Year,
+			&curr.Population,
+			&curr.AreaByKm2,
+			&curr.GDP,
+			&curr.Updated,
+		); err != nil {
+			CheckOperation("CountrySeriesByUuid Scan error", err, started)
+			return results, err
+		}
+		results = append(results, curr)
+	}
+
+	return results, rows.Err()
+}
+
+// UpsertCountrySeries writes the years of series, values left out keep what
+// the year had, then refreshes the latest values on the country. The
+// upserted years are returned.
+func (db *Database) UpsertCountrySeries(ctx context.Context, tx *sql.Tx, uuid muuid.UUID, series pkg_v1.CountrySeries) (pkg_v1.CountrySeries, error) {
+	ctx = WithPrimary(ctx)
+
+	if err := series.Validate(); err != nil {
+		return nil, err
+	}
+
+	var (
+		started = time.Now()
+		from    = pkg_v1.CountryYear_Max
+		to      = pkg_v1.CountryYear_Min
+		results pkg_v1.CountrySeries
+	)
+	for _, iter := range series {
+		if iter.Year < from {
+			from = iter.Year
+		}
+		if iter.Year > to {
+			to = iter.Year
+		}
+	}
+
+	err := db.InTx(ctx, tx, func(tx *sql.Tx) error {
+		country_index, err := db.CountryIndexByUuid(ctx, tx, uuid)
+		if err != nil {
+			return err
+		}
+
+		for _, iter := range series {
+			_, err := db.Exec(ctx, tx,
+				`INSERT INTO country_series(country_index, year, population, area_by_km2, gdp)
+				VALUES($1, $2, $3, $4, $5)
+				ON CONFLICT (country_index, year) DO UPDATE SET
+					population = COALESCE(EXCLUDED.population, country_series.population),
+					area_by_km2 = COALESCE(EXCLUDED.area_by_km2, country_series.area_by_km2),
+					gdp = COALESCE(EXCLUDED.gdp, country_series.gdp)`,
+				country_index,
+				iter.Year,
+				iter.Population,
+				iter.AreaByKm2,
+				iter.GDP,
+			)
+			CheckOperation("UpsertCountrySeries", err, started)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Note: each value is the one of the latest year that has it
+		_, err = db.Exec(ctx, tx,
+			`UPDATE country SET
+				population = (SELECT population FROM country_series WHERE country_index = $1 AND population IS NOT NULL ORDER BY year DESC LIMIT 1),
+				area_by_km2 = (SELECT area_by_km2 FROM country_series WHERE country_index = $1 AND area_by_km2 IS NOT NULL ORDER BY year DESC LIMIT 1),
+				gdp = (SELECT gdp FROM country_series WHERE country_index = $1 AND gdp IS NOT NULL ORDER BY year DESC LIMIT 1)
+			WHERE index = $1`,
+			country_index,
+		)
+		CheckOperation("UpsertCountrySeries Latest", err, started)
+		if err != nil {
+			return err
+		}
+
+		if results, err = db.CountrySeriesByUuid(ctx, tx, uuid, CountrySeriesQueryOptions{From: from, To: to}); err != nil {
+			return err
+		}
+
+		country, err := db.CountryByUuid(ctx, tx, uuid.String())
+		if err != nil {
+			return err
+		}
+		return db.CreateWebhookOutbox(ctx, tx, pkg_v1.ChangeEntity_Country, pkg_v1.ChangeOperation_Update, country)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	db.AfterCommit(tx, InvalidateCache)
+	db.AfterCommit(tx, Webhooks.Wake)
+
+	return upsertedYears(results, series), nil
+}
+
+// upsertedYears keeps the years of results listed in series, the read range
+// may hold years that were not upserted.
+func upsertedYears(results pkg_v1.CountrySeries, series pkg_v1.CountrySeries) pkg_v1.CountrySeries {
+	years := map[int]bool{}
+	for _, iter := range series {
+		years[iter.Year] = true
+	}
+
+	filtered := pkg_v1.CountrySeries{}
+	for _, iter := range results {
+		if years[iter.Year] {
+			filtered = append(filtered, iter)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,67 @@
+package main_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	main "github.com/nhht77/earth-rest-api/server"
+)
+
+func TestWriteDatabaseError(t *testing.T) {
+	for _, iter := range []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"statement timeout", &pq.Error{Code: "57014", Message: "canceling statement due to statement timeout"}, http.StatusGatewayTimeout},
+		{"wrapped statement timeout", fmt.Errorf("CityByUuid: %w", &pq.Error{Code: "57014"}), http.StatusGatewayTimeout},
+		{"context deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), http.StatusGatewayTimeout},
+		{"unique violation", &pq.Error{Code: "23505"}, http.StatusConflict},
+		{"uuid exists", main.ErrUuidExists, http.StatusConflict},
+		{"no rows", sql.ErrNoRows, http.StatusNotFound},
+		{"wrapped no rows", fmt.Errorf("ContinentByUuid: %w", sql.ErrNoRows), http.StatusNotFound},
+		{"syntax error", &pq.Error{Code: "42601"}, http.StatusBadRequest},
+		{"other", errors.New("invalid input"), http.StatusBadRequest},
+	} {
+		recorder := httptest.NewRecorder()
+		main.WriteDatabaseError(recorder, iter.err)
+		if recorder.Code != iter.expected {
+			t.Errorf("%s: status %d, expected %d", iter.name, recorder.Code, iter.expected)
+		}
+	}
+}
+
+func TestStatementTimeoutAnswersGatewayTimeout(t *testing.T) {
+	RequireDB(t)
+
+	config := *AppConfig
+	config.Database.Pool.StatementTimeout = main.Duration(100 * time.Millisecond)
+
+	db := &main.Database{}
+	if err := db.Initialize(&config); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	rows, err := db.ReadQuery(context.Background(), nil, "SELECT pg_sleep(1)")
+	if err == nil {
+		rows.Close()
+		t.Fatal("expected the statement timeout to cancel the query")
+	}
+	if !main.DatabaseTimeout(err) {
+		t.Errorf("DatabaseTimeout(%v) = false", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	main.WriteDatabaseError(recorder, err)
+	if recorder.Code != http.StatusGatewayTimeout {
+		t.Errorf("status %d, expected %d", recorder.Code, http.StatusGatewayTimeout)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/nhht77/earth-rest-api/server/pkg/msql"
+)
+
+// IdempotencyRecord is the stored outcome of the first request sent with a key.
+type IdempotencyRecord struct {
+	Index       msql.DatabaseIndex
+	RequestHash string
+
+	// claimed_at in microseconds, identifies the claim that runs the request
+	claim int64
+
+	// 0 while the first request is still running
+	StatusCode  int
+	ContentType string
+	Response    string
+}
+
+// claimed_at as compared between a claim and its completion
+const idempotencyClaim = `(EXTRACT(EPOCH FROM claimed_at) * 1000000)::bigint`
+
+// ClaimIdempotencyKey stores key of the scope's caller for this request,
+// claimed is true when the caller runs it. Otherwise record is the earlier request with the key,
+// keys older than window are reclaimed, as are keys left running longer than
+// lease by a request that never completed.
+func (db *Database) ClaimIdempotencyKey(ctx context.Context, key string, method string, path string, scope string, request_hash string, window time.Duration, lease time.Duration) (bool, *IdempotencyRecord, error) {
+	started := time.Now()
+
+	// Note: a second pass covers a key released between the insert and the select
+	for pass := 0; pass < 2; pass++ {
+		var (
+			index msql.DatabaseIndex
+			claim int64
+		)
+
+		err := db.QueryRow(ctx, nil,
+			fmt.Sprintf(
+				`INSERT INTO idempotency_key(key, method, path, scope, request_hash)
+				VALUES(%s, %s, %s, '%s', '%s')
+				ON CONFLICT (key, method, path, scope) DO UPDATE SET
+					request_hash = EXCLUDED.request_hash,
+					status_code = NULL,
+					content_type = NULL,
+					response = NULL,
+					created = NOW(),
+					claimed_at = NOW()
+				WHERE idempotency_key.created < NOW() - interval '%d milliseconds'
+				OR (idempotency_key.status_code IS NULL AND idempotency_key.claimed_at < NOW() - interval '%d milliseconds')
+				RETURNING index, %s`,
+				pq.QuoteLiteral(key),
+				pq.QuoteLiteral(method),
+				pq.QuoteLiteral(path),
+				scope,
+				request_hash,
+				window.Milliseconds(),
+				lease.Milliseconds(),
+				idempotencyClaim,
+			)).Scan(&index, &claim)
+		if err == nil {
+			CheckOperation("ClaimIdempotencyKey", err, started)
+			return true, &IdempotencyRecord{Index: index, RequestHash: request_hash, claim: claim}, nil
+		}
+		if !DatabaseNoResults(err) {
+			CheckOperation("ClaimIdempotencyKey", err, started)
+			return false, nil, err
+		}
+
+		var (
+			record       = &IdempotencyRecord{}
+			status_code  sql.NullInt64
+			content_type sql.NullString
+			response     sql.NullString
+		)
+		err = db.QueryRow(ctx, nil,
+			fmt.Sprintf(
+				`SELECT index, request_hash, status_code, content_type, response
+				FROM idempotency_key
+				WHERE key = %s AND method = %s AND path = %s AND scope = '%s'`,
+				pq.QuoteLiteral(key),
+				pq.QuoteLiteral(method),
+				pq.QuoteLiteral(path),
+				scope,
+			)).Scan(
+			&record.Index,
+			&record.RequestHash,
+			&status_code,
+			&content_type,
+			&response,
+		)
+		if DatabaseNoResults(err) {
+			continue
+		}
+		CheckOperation("ClaimIdempotencyKey", err, started)
+		if err != nil {
+			return false, nil, err
+		}
+
+		record.StatusCode = int(status_code.Int64)
+		record.ContentType = content_type.String
+		record.Response = response.String
+		return false, record, nil
+	}
+
+	return false, nil, fmt.Errorf("Idempotency-Key %s could not be claimed", key)
+}
+
+// CompleteIdempotencyKey stores the response of a claimed request, unless
+// its lease expired and another request took the key over.
+func (db *Database) CompleteIdempotencyKey(ctx context.Context, record *IdempotencyRecord, status_code int, content_type string, response string) error {
+	started := time.Now()
+
+	_, err := db.Exec(ctx, nil,
+		fmt.Sprintf(
+			`UPDATE idempotency_key SET
+			status_code = %d,
+			content_type = %s,
+			response = %s
+			WHERE index = %d AND status_code IS NULL AND %s = %d`,
+			status_code,
+			pq.QuoteLiteral(content_type),
+			pq.QuoteLiteral(response),
+			record.Index,
+			idempotencyClaim,
+			record.claim,
+		))
+	CheckOperation("CompleteIdempotencyKey", err, started)
+	return err
+}
+
+// ReleaseIdempotencyKey forgets a key whose request failed, so a retry runs again.
+func (db *Database) ReleaseIdempotencyKey(ctx context.Context, record *IdempotencyRecord) error {
+	started := time.Now()
+
+	_, err := db.Exec(ctx, nil,
+		fmt.Sprintf(
+			`DELETE FROM idempotency_key WHERE index = %d AND status_code IS NULL AND %s = %d`,
+			record.Index,
+			idempotencyClaim,
+			record.claim,
+		))
+	CheckOperation("ReleaseIdempotencyKey", err, started)
+	return err
+}
+
+func (db *Database) DeleteExpiredIdempotencyKeys(ctx context.Context, window time.Duration) (int64, error) {
+	started := time.Now()
+
+	results, err := db.Exec(ctx, nil,
+		fmt.Sprintf(
+			`DELETE FROM idempotency_key WHERE created < NOW() - interval '%d milliseconds'`,
+			window.Milliseconds(),
+		))
+	CheckOperation("DeleteExpiredIdempotencyKeys", err, started)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, _ := results.RowsAffected()
+	return deleted, nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	ReplicaHealthInterval = 5 * time.Second
+	ReplicaHealthTimeout  = 2 * time.Second
+
+	// Note: opt out of replica reads to see the request's own writes
+	HeaderReadYourWrites = "X-Read-Your-Writes"
+)
+
+type replica struct {
+	name     string
+	postgres *sql.DB
+	healthy  int32
+}
+
+func (r *replica) isHealthy() bool {
+	return atomic.LoadInt32(&r.healthy) == 1
+}
+
+type primaryKey struct{}
+
+// WithPrimary routes every read made with ctx to the primary.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func usePrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
+
+func (db *Database) openReplicas(c *Config) error {
+	for _, address := range c.Database.Replicas {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+
+		Log.Infof("[postgre] Replica Source: %s", c.DatabaseSourcePrintable(host, port))
+
+		postgres, err := openPool(c, host, port)
+		if err != nil {
+			return err
+		}
+		db.replicas = append(db.replicas, &replica{name: address, postgres: postgres})
+	}
+
+	if len(db.replicas) > 0 {
+		db.stopHealth = make(chan struct{})
+		db.checkReplicas()
+		go db.watchReplicas(db.stopHealth)
+	}
+	return nil
+}
+
+func (db *Database) watchReplicas(stop chan struct{}) {
+	ticker := time.NewTicker(ReplicaHealthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			db.checkReplicas()
+		}
+	}
+}
+
+func (db *Database) checkReplicas() {
+	var wg sync.WaitGroup
+	for _, iter := range db.replicas {
+		wg.Add(1)
+		go func(iter *replica) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), ReplicaHealthTimeout)
+			defer cancel()
+
+			var healthy int32
+			err := iter.postgres.PingContext(ctx)
+			if err == nil {
+				healthy = 1
+			}
+
+			if previous := atomic.SwapInt32(&iter.healthy, healthy); previous != healthy {
+				if err != nil {
+					Log.Warnf("[postgre] Replica %s unhealthy, reads fall back: %s", iter.name, err.Error())
+				} else {
+					Log.Infof("[postgre] Replica %s healthy", iter.name)
+				}
+			}
+		}(iter)
+	}
+	wg.Wait()
+}
+
+// reader picks a healthy replica round-robin, or the primary when there is
+// none or the request asked to read its own writes.
+func (db *Database) reader(ctx context.Context) *sql.DB {
+	if len(db.replicas) == 0 || usePrimary(ctx) {
+		return db.postgres
+	}
+
+	start := atomic.AddUint32(&db.replicaNext, 1)
+	for i := 0; i < len(db.replicas); i++ {
+		iter := db.replicas[(int(start)+i)%len(db.replicas)]
+		if iter.isHealthy() {
+			return iter.postgres
+		}
+	}
+	return db.postgres
+}
+
+func (db *Database) closeReplicas() {
+	if db.stopHealth != nil {
+		close(db.stopHealth)
+		db.stopHealth = nil
+	}
+	for _, iter := range db.replicas {
+		iter.postgres.Close()
+	}
+	db.replicas = nil
+}
+
+type ReplicaStatus struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+}
+
+func (db *Database) ReplicaStatus() []ReplicaStatus {
+	status := []ReplicaStatus{}
+	for _, iter := range db.replicas {
+		status = append(status, ReplicaStatus{Name: iter.name, Healthy: iter.isHealthy()})
+	}
+	return status
+}
+
+// ReadQuery runs a read on a replica unless it belongs to a transaction,
+// transactions always run on the primary. args are the $n placeholders of
+// query.
+func (db *Database) ReadQuery(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) (*sql.Rows, error) {
+	if tx != nil {
+		return tx.QueryContext(ctx, query, args...)
+	}
+	return db.reader(ctx).QueryContext(ctx, query, args...)
+}
+
+func (db *Database) ReadQueryRow(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) *sql.Row {
+	if tx != nil {
+		return tx.QueryRowContext(ctx, query, args...)
+	}
+	return db.reader(ctx).QueryRowContext(ctx, query, args...)
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+	"github.com/nhht77/earth-rest-api/server/pkg/mhttp"
+	"github.com/nhht77/earth-rest-api/server/pkg/msql"
+)
+
+// StatsMaxBuckets bounds the activity series of a request.
+const StatsMaxBuckets = 1000
+
+type StatsQueryOptions struct {
+	// day, week (from Monday) or month
+	Bucket string
+
+	// activity range [From, To), truncated to the bucket
+	From time.Time
+	To   time.Time
+}
+
+func StatsOptionsFromQuery(r *http.Request) (StatsQueryOptions, error) {
+	options := StatsQueryOptions{Bucket: mhttp.Query(r, "bucket")}
+	if len(options.Bucket) == 0 {
+		options.Bucket = "day"
+	}
+
+	var err error
+	if options.To, err = statsTimeFromQuery(r, "to", time.Now().UTC()); err != nil {
+		return options, err
+	}
+
+	// default to the last 30 days, 12 weeks or 12 months
+	var from time.Time
+	switch options.Bucket {
+	case "day":
+		from = options.To.AddDate(0, 0, -30)
+	case "week":
+		from = options.To.AddDate(0, 0, -7*12)
+	case "month":
+		from = options.To.AddDate(0, -12, 0)
+	default:
+		return options, fmt.Errorf("invalid bucket %q, expected day, week or month", options.Bucket)
+	}
+	if options.From, err = statsTimeFromQuery(r, "from", from); err != nil {
+		return options, err
+	}
+
+	return options, options.Validate()
+}
+
+func statsTimeFromQuery(r *http.Request, key string, default_value time.Time) (time.Time, error) {
+	value := mhttp.Query(r, key)
+	if len(value) == 0 {
+		return default_value, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid %s %q, expected an RFC 3339 time or a 2006-01-02 date", key, value)
+}
+
+func (options *StatsQueryOptions) Validate() error {
+	options.From = truncateBucket(options.From, options.Bucket)
+	if !options.To.After(options.From) {
+		return errors.New("from must be before to")
+	}
+
+	count := 0
+	for start := options.From; start.Before(options.To); start = nextBucket(start, options.Bucket) {
+		if count++; count > StatsMaxBuckets {
+			return fmt.Errorf("more than %d buckets, use a larger bucket or a shorter range", StatsMaxBuckets)
+		}
+	}
+	return nil
+}
+
+// truncateBucket matches date_trunc on the timestamp columns, written by
+// sessions in UTC (see Config.DatabaseSource).
+func truncateBucket(t time.Time, bucket string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch bucket {
+	case "week":
+		// date_trunc weeks start on Monday
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day
+}
+
+func nextBucket(start time.Time, bucket string) time.Time {
+	switch bucket {
+	case "week":
+		return start.AddDate(0, 0, 7)
+	case "month":
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+func (db *Database) Stats(ctx context.Context, options StatsQueryOptions) (*pkg_v1.Stats, error) {
+	stats := &pkg_v1.Stats{}
+
+	var err error
+	if stats.ContinentTypes, err = db.ContinentTypeStats(ctx); err != nil {
+		return nil, err
+	}
+	if stats.Rows, err = db.RowCounts(ctx); err != nil {
+		return nil, err
+	}
+	if stats.Activity, err = db.Activity(ctx, options); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// ContinentTypeStats aggregates the live continents, countries & cities of
+// every continent type, types without rows count 0.
+func (db *Database) ContinentTypeStats(ctx context.Context) ([]*pkg_v1.ContinentTypeStats, error) {
+	var (
+		started  = time.Now()
+		results  = []*pkg_v1.ContinentTypeStats{}
+		type_map = map[pkg_v1.ContinentType]*pkg_v1.ContinentTypeStats{}
+	)
+
+	for continent_type := pkg_v1.ContinentType_Asia; continent_type <= pkg_v1.ContinentType_Antarctica; continent_type++ {
+		stats := &pkg_v1.ContinentTypeStats{Type: continent_type}
+		type_map[continent_type] = stats
+		results = append(results, stats)
+	}
+
+	// Note: countries & cities are counted per continent first, so joining
+	// them does not repeat continent areas
+	rows, err := db.ReadQuery(ctx, nil, fmt.Sprintf(
+		`SELECT
+			continent.type,
+			COUNT(*),
+			COALESCE(SUM(continent.area_by_km2), 0),
+			COALESCE(SUM(country.count), 0),
+			COALESCE(SUM(city.count), 0)
+		FROM continent
+		LEFT JOIN (
+			SELECT continent_index, COUNT(*) AS count FROM country
+			WHERE deleted_state != %d GROUP BY continent_index
+		) country ON country.continent_index = continent.index
+		LEFT JOIN (
+			SELECT continent_index, COUNT(*) AS count FROM city
+			WHERE deleted_state != %d GROUP BY continent_index
+		) city ON city.continent_index = continent.index
+		WHERE continent.deleted_state != %d
+		GROUP BY continent.type`,
+		msql.SoftDeleted,
+		msql.SoftDeleted,
+		msql.SoftDeleted,
+	))
+	CheckOperation("ContinentTypeStats", err, started)
+	if err != nil {
+		return results, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		curr := &pkg_v1.ContinentTypeStats{}
+		if err = rows.Scan(
+			&curr.Type,
+			&curr.Continents,
+			&curr.AreaByKm2,
+			&curr.Countries,
+			&curr.Cities,
+		); err != nil {
+			Log.Warnf("DB.ContinentTypeStats Scan error - %s", err.Error())
+			return results, err
+		}
+
+		// Note: rows with an invalid type are not reported
+		if stats := type_map[curr.Type]; stats != nil {
+			*stats = *curr
+		}
+	}
+	return results, rows.Err()
+}
+
+// RowCounts counts live & soft-deleted rows of each entity table.
+func (db *Database) RowCounts(ctx context.Context) (map[string]*pkg_v1.RowCounts, error) {
+	var (
+		started = time.Now()
+		results = map[string]*pkg_v1.RowCounts{}
+		query   = ""
+	)
+
+	for _, table := range []string{"continent", "country", "city"} {
+		if len(query) > 0 {
+			query += " UNION ALL "
+		}
+		query += fmt.Sprintf(
+			`SELECT '%s',
+				COUNT(*) FILTER (WHERE deleted_state != %d),
+				COUNT(*) FILTER (WHERE deleted_state = %d)
+			FROM %s`,
+			table,
+			msql.SoftDeleted,
+			msql.SoftDeleted,
+			table,
+		)
+	}
+
+	rows, err := db.ReadQuery(ctx, nil, query)
+	CheckOperation("RowCounts", err, started)
+	if err != nil {
+		return results, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			table string
+			curr  = &pkg_v1.RowCounts{}
+		)
+		if err = rows.Scan(&table, &curr.Live, &curr.Deleted); err != nil {
+			Log.Warnf("DB.RowCounts Scan error - %s", err.Error())
+			return results, err
+		}
+		results[table] = curr
+	}
+	return results, rows.Err()
+}
+
+// Activity counts the rows created & updated in each bucket of the range,
+// soft-deleted rows included. Every bucket is listed, empty ones with 0.
+func (db *Database) Activity(ctx context.Context, options StatsQueryOptions) (*pkg_v1.Activity, error) {
+	var (
+		started = time.Now()
+		result  = &pkg_v1.Activity{Bucket: options.Bucket, From: options.From, To: options.To, Buckets: []*pkg_v1.ActivityBucket{}}
+		buckets = map[int64]*pkg_v1.ActivityBucket{}
+		query   = ""
+	)
+
+	for start := options.From; start.Before(options.To); start = nextBucket(start, options.Bucket) {
+		bucket := &pkg_v1.ActivityBucket{Start: start}
+		buckets[start.Unix()] = bucket
+		result.Buckets = append(result.Buckets, bucket)
+	}
+
+	for _, table := range []string{"continent", "country", "city"} {
+		for _, column := range []string{"created", "updated"} {
+			if len(query) > 0 {
+				query += " UNION ALL "
+			}
+			// Note: the bucket is checked by StatsOptionsFromQuery, range values are arguments
+			query += fmt.Sprintf(
+				`SELECT '%s', '%s', date_trunc('%s', %s), COUNT(*) FROM %s
+				WHERE %s >= $1 AND %s < $2
+				GROUP BY 3`,
+				table, column, options.Bucket, column, table,
+				column, column,
+			)
+		}
+	}
+
+	rows, err := db.ReadQuery(ctx, nil, query, options.From, options.To)
+	CheckOperation("Activity", err, started)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			table  string
+			column string
+			start  time.Time
+			count  int64
+		)
+		if err = rows.Scan(&table, &column, &start, &count); err != nil {
+			Log.Warnf("DB.Activity Scan error - %s", err.Error())
+			return result, err
+		}
+
+		bucket := buckets[start.Unix()]
+		if bucket == nil {
+			continue
+		}
+
+		counts := map[string]*pkg_v1.ActivityCount{
+			"continent": &bucket.Continent,
+			"country":   &bucket.Country,
+			"city":      &bucket.City,
+		}[table]
+		if column == "created" {
+			counts.Created = count
+		} else {
+			counts.Updated = count
+		}
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,780 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/lib/pq"
+
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+	"github.com/nhht77/earth-rest-api/server/pkg/mhttp"
+	"github.com/nhht77/earth-rest-api/server/pkg/msql"
+	"github.com/nhht77/earth-rest-api/server/pkg/mstring"
+	muuid "github.com/nhht77/earth-rest-api/server/pkg/muuid"
+	"github.com/nhht77/earth-rest-api/server/pkg/mwebhook"
+)
+
+////////////////////////////////////
+/////// Webhook subscription function
+
+func (db *Database) WebhookSubscriptions(ctx context.Context) ([]*pkg_v1.WebhookSubscription, error) {
+	var (
+		err     error
+		started = time.Now()
+		results = []*pkg_v1.WebhookSubscription{}
+	)
+
+	rows, err := db.ReadQuery(ctx, nil,
+		fmt.Sprintf(
+			`SELECT %s FROM webhook_subscription WHERE deleted_state != %d ORDER BY index`,
+			new(pkg_v1.WebhookSubscription).DatabaseFields(),
+			msql.SoftDeleted,
+		))
+	CheckOperation("WebhookSubscriptions", err, started)
+	if err != nil {
+		return results, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		curr, err := scanWebhookSubscription(rows)
+		if err != nil {
+			Log.Warnf("DB.WebhookSubscriptions Scan error - %s", err.Error())
+			return results, err
+		}
+		results = append(results, curr)
+	}
+	if err = rows.Err(); err != nil {
+		Log.Warnf("DB.WebhookSubscriptions error - %s", err.Error())
+		return results, err
+	}
+
+	return results, nil
+}
+
+func (db *Database) WebhookSubscriptionByUuid(ctx context.Context, tx *sql.Tx, uuid string) (*pkg_v1.WebhookSubscription, error) {
+	if _, err := muuid.UUIDFromString(uuid); err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+
+	result, err := scanWebhookSubscription(db.ReadQueryRow(ctx, tx,
+		fmt.Sprintf(
+			`SELECT %s
+				FROM webhook_subscription
+			WHERE uuid = '%s'
+			AND deleted_state != %d`,
+			new(pkg_v1.WebhookSubscription).DatabaseFields(),
+			uuid,
+			msql.SoftDeleted,
+		)))
+
+	CheckOperation("WebhookSubscriptionByUuid", err, started)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func scanWebhookSubscription(row interface{ Scan(...interface{}) error }) (*pkg_v1.WebhookSubscription, error) {
+	var (
+		result  = &pkg_v1.WebhookSubscription{}
+		updated sql.NullTime
+	)
+
+	err := row.Scan(
+		&result.Index,
+		&result.Uuid,
+		&result.URL,
+		&result.Events,
+		&result.Creator,
+		&result.Created,
+		&updated,
+		&result.DeletedState,
+	)
+	if updated.Valid {
+		result.Updated = updated.Time
+	}
+	return result, err
+}
+
+// newWebhookSecret is used when the subscriber does not choose one.
+func newWebhookSecret() (string, error) {
+	secret_b := make([]byte, 32)
+	if _, err := rand.Read(secret_b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret_b), nil
+}
+
+// CreateWebhookSubscription returns the subscription with its secret, the
+// only response that includes it.
+func (db *Database) CreateWebhookSubscription(ctx context.Context, tx *sql.Tx, subscription *pkg_v1.WebhookSubscription) (*pkg_v1.WebhookSubscription, error) {
+	ctx = WithPrimary(ctx)
+
+	if err := subscription.ValidateCreate(); err != nil {
+		return nil, err
+	}
+
+	secret := subscription.Secret
+	if len(secret) == 0 {
+		generated, err := newWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	}
+
+	var (
+		started         = time.Now()
+		uuid            = muuid.NewUUID()
+		json_creator, _ = json.Marshal(subscription.Creator)
+		json_events, _  = json.Marshal(subscription.Events)
+
+		fields = []string{
+			"uuid",
+			"url",
+			"secret",
+			"events",
+			"creator",
+		}
+	)
+
+	_, err := db.Exec(ctx, tx,
+		fmt.Sprintf(
+			`INSERT INTO webhook_subscription(%s)
+			VALUES(
+				'%s', %s, %s,
+				'%s', '%s'
+			)`,
+			mstring.FormatFields(fields...),
+			uuid.String(),
+			pq.QuoteLiteral(subscription.URL),
+			pq.QuoteLiteral(secret),
+			json_events,
+			json_creator,
+		))
+	CheckOperation("CreateWebhookSubscription", err, started)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := db.WebhookSubscriptionByUuid(ctx, tx, uuid.String())
+	if err != nil {
+		return nil, err
+	}
+	result.Secret = secret
+
+	return result, nil
+}
+
+// UpdateWebhookSubscription keeps the current secret unless a new one is sent.
+func (db *Database) UpdateWebhookSubscription(ctx context.Context, tx *sql.Tx, subscription *pkg_v1.WebhookSubscription) (*pkg_v1.WebhookSubscription, error) {
+	ctx = WithPrimary(ctx)
+
+	if err := subscription.ValidateUpdate(); err != nil {
+		return nil, err
+	}
+
+	var (
+		started        = time.Now()
+		json_events, _ = json.Marshal(subscription.Events)
+		secret         = ""
+	)
+
+	if len(subscription.Secret) > 0 {
+		secret = fmt.Sprintf(", secret=%s", pq.QuoteLiteral(subscription.Secret))
+	}
+
+	results, err := db.Exec(ctx, tx,
+		fmt.Sprintf(
+			`UPDATE webhook_subscription SET
+			url=%s,
+			events='%s'%s
+			WHERE uuid ='%s'
+			AND deleted_state != %d`,
+			pq.QuoteLiteral(subscription.URL),
+			json_events,
+			secret,
+			subscription.Uuid.String(),
+			msql.SoftDeleted,
+		))
+	CheckOperation("UpdateWebhookSubscription", err, started)
+	if err != nil {
+		return nil, err
+	}
+	if !db.resultChange(results) {
+		return nil, sql.ErrNoRows
+	}
+
+	return db.WebhookSubscriptionByUuid(ctx, tx, subscription.Uuid.String())
+}
+
+// SoftDeleteWebhookSubscription dead-letters the deliveries still pending.
+func (db *Database) SoftDeleteWebhookSubscription(ctx context.Context, uuid string) error {
+	ctx = WithPrimary(ctx)
+
+	if _, err := muuid.UUIDFromString(uuid); err != nil {
+		return err
+	}
+
+	started := time.Now()
+
+	err := db.InTx(ctx, nil, func(tx *sql.Tx) error {
+		results, err := db.Exec(ctx, tx, fmt.Sprintf(
+			`UPDATE webhook_subscription SET
+			deleted_state = %d
+			WHERE uuid ='%s'
+			AND deleted_state != %d`,
+			msql.SoftDeleted,
+			uuid,
+			msql.SoftDeleted,
+		))
+		if err != nil {
+			return err
+		}
+		if !db.resultChange(results) {
+			return sql.ErrNoRows
+		}
+
+		_, err = db.Exec(ctx, tx, fmt.Sprintf(
+			`UPDATE webhook_delivery SET
+			state = %d,
+			last_error = 'subscription deleted'
+			WHERE state = %d
+			AND subscription_index = (SELECT index FROM webhook_subscription WHERE uuid = '%s')`,
+			pkg_v1.WebhookDeliveryState_Dead,
+			pkg_v1.WebhookDeliveryState_Pending,
+			uuid,
+		))
+		return err
+	})
+	CheckOperation("SoftDeleteWebhookSubscription", err, started)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+//////////////////////////////
+/////// Webhook outbox function
+
+// CreateWebhookOutbox queues an event for every matching subscription. Call
+// it with the transaction of the write so the event exists only if the
+// write is committed.
+func (db *Database) CreateWebhookOutbox(ctx context.Context, tx *sql.Tx, entity pkg_v1.ChangeEntity, operation pkg_v1.ChangeOperation, data interface{}) error {
+	if tx == nil {
+		return errors.New("CreateWebhookOutbox requires a transaction")
+	}
+
+	var (
+		started = time.Now()
+		payload = &pkg_v1.WebhookPayload{
+			Uuid:    muuid.NewUUID(),
+			Event:   pkg_v1.WebhookEvent(entity, operation),
+			Created: time.Now().UTC(),
+			Data:    data,
+		}
+	)
+
+	json_payload, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(ctx, tx,
+		fmt.Sprintf(
+			`INSERT INTO webhook_outbox(uuid, event, payload)
+			VALUES('%s', '%s', %s)`,
+			payload.Uuid.String(),
+			payload.Event,
+			pq.QuoteLiteral(string(json_payload)),
+		))
+	CheckOperation("CreateWebhookOutbox", err, started)
+	return err
+}
+
+// DispatchWebhookOutbox turns up to limit outbox events into one delivery per
+// matching subscription. Rows locked by another instance are skipped.
+func (db *Database) DispatchWebhookOutbox(ctx context.Context, limit int) (int, error) {
+	var (
+		started    = time.Now()
+		dispatched = 0
+	)
+
+	err := db.InTx(ctx, nil, func(tx *sql.Tx) error {
+		subscriptions, err := db.webhookSubscriptionFilters(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		rows, err := db.Query(ctx, tx,
+			fmt.Sprintf(
+				`SELECT index, event FROM webhook_outbox
+				WHERE dispatched = false
+				ORDER BY index
+				LIMIT %d
+				FOR UPDATE SKIP LOCKED`,
+				limit,
+			))
+		if err != nil {
+			return err
+		}
+
+		var (
+			indexes = msql.DatabaseIndexList{}
+			events  = []string{}
+		)
+		for rows.Next() {
+			var (
+				index msql.DatabaseIndex
+				event string
+			)
+			if err := rows.Scan(&index, &event); err != nil {
+				rows.Close()
+				return err
+			}
+			indexes = append(indexes, index)
+			events = append(events, event)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if len(indexes) == 0 {
+			return nil
+		}
+
+		for i, index := range indexes {
+			for subscription_index, filters := range subscriptions {
+				if !mwebhook.MatchEvent(filters, events[i]) {
+					continue
+				}
+				if _, err := db.Exec(ctx, tx,
+					fmt.Sprintf(
+						`INSERT INTO webhook_delivery(uuid, subscription_index, outbox_index)
+						VALUES('%s', %d, %d)`,
+						muuid.NewUUID().String(),
+						subscription_index,
+						index,
+					)); err != nil {
+					return err
+				}
+			}
+		}
+
+		_, err = db.Exec(ctx, tx,
+			fmt.Sprintf(
+				`UPDATE webhook_outbox SET dispatched = true WHERE index IN (%s)`,
+				indexes.String(),
+			))
+		dispatched = len(indexes)
+		return err
+	})
+	CheckOperation("DispatchWebhookOutbox", err, started)
+	if err != nil {
+		return 0, err
+	}
+
+	return dispatched, nil
+}
+
+func (db *Database) webhookSubscriptionFilters(ctx context.Context, tx *sql.Tx) (map[msql.DatabaseIndex]pkg_v1.WebhookEventList, error) {
+	results := map[msql.DatabaseIndex]pkg_v1.WebhookEventList{}
+
+	rows, err := db.Query(ctx, tx,
+		fmt.Sprintf(
+			`SELECT index, events FROM webhook_subscription WHERE deleted_state != %d`,
+			msql.SoftDeleted,
+		))
+	if err != nil {
+		return results, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			index  msql.DatabaseIndex
+			events pkg_v1.WebhookEventList
+		)
+		if err := rows.Scan(&index, &events); err != nil {
+			return results, err
+		}
+		results[index] = events
+	}
+	return results, rows.Err()
+}
+
+////////////////////////////////
+/////// Webhook delivery function
+
+// WebhookJob is a claimed delivery with what is needed to send it.
+type WebhookJob struct {
+	Index    msql.DatabaseIndex
+	Uuid     muuid.UUID
+	Attempts int
+
+	URL     string
+	Secret  string
+	Event   string
+	Payload []byte
+}
+
+// ClaimWebhookDeliveries picks up to limit pending deliveries that are due
+// and moves their next attempt lease into the future, so another instance
+// does not send them while this one is.
+func (db *Database) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*WebhookJob, error) {
+	var (
+		err     error
+		started = time.Now()
+		results = []*WebhookJob{}
+	)
+
+	rows, err := db.Query(ctx, nil,
+		fmt.Sprintf(
+			`WITH claimed AS (
+				UPDATE webhook_delivery SET
+				next_attempt = NOW() + interval '%d milliseconds'
+				WHERE index IN (
+					SELECT index FROM webhook_delivery
+					WHERE state = %d
+					AND next_attempt <= NOW()
+					ORDER BY next_attempt
+					LIMIT %d
+					FOR UPDATE SKIP LOCKED
+				)
+				RETURNING index, uuid, attempts, subscription_index, outbox_index
+			)
+			SELECT
+				claimed.index, claimed.uuid, claimed.attempts,
+				webhook_subscription.url, webhook_subscription.secret,
+				webhook_outbox.event, webhook_outbox.payload
+			FROM claimed
+			JOIN webhook_subscription ON webhook_subscription.index = claimed.subscription_index
+			JOIN webhook_outbox ON webhook_outbox.index = claimed.outbox_index`,
+			lease.Milliseconds(),
+			pkg_v1.WebhookDeliveryState_Pending,
+			limit,
+		))
+	CheckOperation("ClaimWebhookDeliveries", err, started)
+	if err != nil {
+		return results, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		curr := &WebhookJob{}
+		if err = rows.Scan(
+			&curr.Index,
+			&curr.Uuid,
+			&curr.Attempts,
+			&curr.URL,
+			&curr.Secret,
+			&curr.Event,
+			&curr.Payload,
+		); err != nil {
+			Log.Warnf("DB.ClaimWebhookDeliveries Scan error - %s", err.Error())
+			return results, err
+		}
+		results = append(results, curr)
+	}
+
+	return results, rows.Err()
+}
+
+// ErrWebhookLeaseLost is returned when the delivery changed since it was
+// claimed, its lease expired and another worker attempted it.
+var ErrWebhookLeaseLost = errors.New("webhook delivery lease lost")
+
+// RecordWebhookAttempt stores the attempt and moves the delivery to
+// delivered, dead after config.MaxAttempts, or schedules the next retry.
+// The delivery still moves when the attempt row cannot be stored.
+func (db *Database) RecordWebhookAttempt(ctx context.Context, job *WebhookJob, result mwebhook.Result, send_err error, config WebhookConfig) (pkg_v1.WebhookDeliveryState, error) {
+	var (
+		started       = time.Now()
+		attempt       = job.Attempts + 1
+		state         = pkg_v1.WebhookDeliveryState_Pending
+		attempt_error = ""
+	)
+
+	switch {
+	case send_err != nil:
+		attempt_error = mwebhook.CleanText(send_err.Error())
+	case !result.Success():
+		attempt_error = fmt.Sprintf("unexpected status %d", result.StatusCode)
+	}
+
+	if len(attempt_error) == 0 {
+		state = pkg_v1.WebhookDeliveryState_Delivered
+	} else if attempt >= config.MaxAttempts {
+		state = pkg_v1.WebhookDeliveryState_Dead
+	}
+
+	next_attempt := mwebhook.Backoff(attempt, time.Duration(config.BackoffBase), time.Duration(config.BackoffMax))
+
+	advance := func(tx *sql.Tx) error {
+		results, err := db.Exec(ctx, tx,
+			fmt.Sprintf(
+				`UPDATE webhook_delivery SET
+				state = %d,
+				attempts = %d,
+				last_error = $1,
+				next_attempt = NOW() + interval '%d milliseconds'
+				WHERE index = %d
+				AND state = %d
+				AND attempts = %d`,
+				state,
+				attempt,
+				next_attempt.Milliseconds(),
+				job.Index,
+				pkg_v1.WebhookDeliveryState_Pending,
+				job.Attempts,
+			), attempt_error)
+		if err != nil {
+			return err
+		}
+		if !db.resultChange(results) {
+			return fmt.Errorf("%w: %s", ErrWebhookLeaseLost, job.Uuid.String())
+		}
+		return nil
+	}
+
+	err := db.InTx(ctx, nil, func(tx *sql.Tx) error {
+		if err := advance(tx); err != nil {
+			return err
+		}
+
+		_, err := db.Exec(ctx, tx,
+			fmt.Sprintf(
+				`INSERT INTO webhook_delivery_attempt(delivery_index, attempt, status_code, response, error, duration_ms)
+				VALUES(%d, %d, %d, $1, $2, %f)`,
+				job.Index,
+				attempt,
+				result.StatusCode,
+				float64(result.Duration.Microseconds())/1000,
+			), result.Response, attempt_error)
+		return err
+	})
+	if err != nil && !errors.Is(err, ErrWebhookLeaseLost) && ctx.Err() == nil {
+		// Note: a delivery left pending would be sent again after every lease and never dead-letter
+		CheckOperation("RecordWebhookAttempt", err, started)
+		err = db.InTx(ctx, nil, advance)
+	}
+	CheckOperation("RecordWebhookAttempt", err, started)
+	if err != nil {
+		return state, err
+	}
+
+	return state, nil
+}
+
+// RetryWebhookDelivery sends a dead-lettered delivery again with a fresh
+// attempt budget.
+func (db *Database) RetryWebhookDelivery(ctx context.Context, uuid string) error {
+	if _, err := muuid.UUIDFromString(uuid); err != nil {
+		return err
+	}
+
+	started := time.Now()
+
+	results, err := db.Exec(ctx, nil,
+		fmt.Sprintf(
+			`UPDATE webhook_delivery SET
+			state = %d,
+			attempts = 0,
+			next_attempt = NOW()
+			WHERE uuid = '%s'
+			AND state = %d
+			AND subscription_index IN (SELECT index FROM webhook_subscription WHERE deleted_state != %d)`,
+			pkg_v1.WebhookDeliveryState_Pending,
+			uuid,
+			pkg_v1.WebhookDeliveryState_Dead,
+			msql.SoftDeleted,
+		))
+	CheckOperation("RetryWebhookDelivery", err, started)
+	if err != nil {
+		return err
+	}
+	if !db.resultChange(results) {
+		return fmt.Errorf("%w: no dead-lettered delivery %s", sql.ErrNoRows, uuid)
+	}
+
+	return nil
+}
+
+type WebhookDeliveryQueryOptions struct {
+	SubscriptionUuid string
+	States           []pkg_v1.WebhookDeliveryState
+
+	Limit int
+}
+
+func WebhookDeliveryOptionsFromQuery(r *http.Request) (WebhookDeliveryQueryOptions, error) {
+	options := WebhookDeliveryQueryOptions{
+		SubscriptionUuid: mhttp.Query(r, "uuid"),
+		Limit:            100,
+	}
+
+	if _, err := muuid.UUIDFromString(options.SubscriptionUuid); err != nil {
+		return options, err
+	}
+
+	states, err := mhttp.QueryIntList(r, "states", ",")
+	if err != nil {
+		return options, err
+	}
+	for _, v := range states {
+		options.States = append(options.States, pkg_v1.WebhookDeliveryState(v))
+	}
+
+	if limit := mhttp.Query(r, "limit"); len(limit) > 0 {
+		if _, err := fmt.Sscanf(limit, "%d", &options.Limit); err != nil || options.Limit < 1 || options.Limit > 1000 {
+			return options, errors.New("limit must be between 1 and 1000")
+		}
+	}
+
+	return options, nil
+}
+
+// WebhookDeliveriesByOptions lists the newest deliveries of a subscription
+// with their attempts.
+func (db *Database) WebhookDeliveriesByOptions(ctx context.Context, options WebhookDeliveryQueryOptions) ([]*pkg_v1.WebhookDelivery, error) {
+	var (
+		err      error
+		started  = time.Now()
+		results  = []*pkg_v1.WebhookDelivery{}
+		indexes  = msql.DatabaseIndexList{}
+		by_index = map[msql.DatabaseIndex]*pkg_v1.WebhookDelivery{}
+	)
+
+	query := fmt.Sprintf(
+		`SELECT
+			webhook_delivery.index, webhook_delivery.uuid,
+			webhook_subscription.uuid, webhook_outbox.event, webhook_outbox.uuid,
+			webhook_delivery.state, webhook_delivery.attempts, webhook_delivery.next_attempt,
+			webhook_delivery.last_error, webhook_delivery.created, webhook_delivery.updated
+		FROM webhook_delivery
+		JOIN webhook_subscription ON webhook_subscription.index = webhook_delivery.subscription_index
+		JOIN webhook_outbox ON webhook_outbox.index = webhook_delivery.outbox_index
+		WHERE webhook_subscription.uuid = '%s' `,
+		options.SubscriptionUuid,
+	)
+
+	if len(options.States) > 0 {
+		states := ""
+		for _, iter := range options.States {
+			if len(states) > 0 {
+				states += ","
+			}
+			states += fmt.Sprintf("%d", iter)
+		}
+		query += fmt.Sprintf(`AND webhook_delivery.state IN (%s) `, states)
+	}
+
+	query += fmt.Sprintf(`ORDER BY webhook_delivery.index DESC LIMIT %d`, options.Limit)
+
+	rows, err := db.ReadQuery(ctx, nil, query)
+	CheckOperation("WebhookDeliveriesByOptions", err, started)
+	if err != nil {
+		return results, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			curr       = &pkg_v1.WebhookDelivery{AttemptList: []*pkg_v1.WebhookDeliveryAttempt{}}
+			last_error sql.NullString
+			updated    sql.NullTime
+		)
+		if err = rows.Scan(
+			&curr.Index,
+			&curr.Uuid,
+			&curr.SubscriptionUuid,
+			&curr.Event,
+			&curr.EventUuid,
+			&curr.State,
+			&curr.Attempts,
+			&curr.NextAttempt,
+			&last_error,
+			&curr.Created,
+			&updated,
+		); err != nil {
+			Log.Warnf("DB.WebhookDeliveriesByOptions Scan error - %s", err.Error())
+			return results, err
+		}
+		curr.LastError = last_error.String
+		if updated.Valid {
+			curr.Updated = updated.Time
+		}
+
+		results = append(results, curr)
+		indexes = append(indexes, curr.Index)
+		by_index[curr.Index] = curr
+	}
+	if err = rows.Err(); err != nil {
+		return results, err
+	}
+
+	if len(indexes) == 0 {
+		return results, nil
+	}
+
+	attempt_rows, err := db.ReadQuery(ctx, nil,
+		fmt.Sprintf(
+			`SELECT index, delivery_index, attempt, status_code, response, error, duration_ms, created
+			FROM webhook_delivery_attempt
+			WHERE delivery_index IN (%s)
+			ORDER BY index`,
+			indexes.String(),
+		))
+	CheckOperation("WebhookDeliveriesByOptions Attempts", err, started)
+	if err != nil {
+		return results, err
+	}
+
+	defer attempt_rows.Close()
+
+	for attempt_rows.Next() {
+		var (
+			curr           = &pkg_v1.WebhookDeliveryAttempt{}
+			delivery_index msql.DatabaseIndex
+			response       sql.NullString
+			attempt_error  sql.NullString
+		)
+		if err = attempt_rows.Scan(
+			&curr.Index,
+			&delivery_index,
+			&curr.Attempt,
+			&curr.StatusCode,
+			&response,
+			&attempt_error,
+			&curr.DurationMs,
+			&curr.Created,
+		); err != nil {
+			Log.Warnf("DB.WebhookDeliveriesByOptions Scan error - %s", err.Error())
+			return results, err
+		}
+		curr.Response = response.String
+		curr.Error = attempt_error.String
+
+		if delivery, ok := by_index[delivery_index]; ok {
+			delivery.AttemptList = append(delivery.AttemptList, curr)
+		}
+	}
+
+	return results, attempt_rows.Err()
+}
@@ -0,0 +1,124 @@
+package main_test
+
+import (
+	"context"
+	"testing"
+
+	main "github.com/nhht77/earth-rest-api/server"
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+)
+
+func TestEmbedContinentCountriesCities(t *testing.T) {
+	RequireDB(t)
+
+	main.DB = DB
+	main.AppConfig = AppConfig
+
+	var (
+		ctx     = context.Background()
+		creator = &pkg_v1.UserMinimal{Email: "embed@earth.test", Name: "embed"}
+	)
+
+	continent, err := DB.CreateContinent(ctx, nil, &pkg_v1.Continent{
+		Name:      "Antarctica",
+		Type:      pkg_v1.ContinentType_Antarctica,
+		AreaByKm2: 14200000,
+		Creator:   creator,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { DB.SoftDeleteContinent(context.Background(), nil, continent.Uuid.String()) })
+
+	for _, name := range []string{"Ross", "Adelie"} {
+		country, err := DB.CreateCountry(ctx, nil, &pkg_v1.Country{
+			ContinentUuid: continent.Uuid,
+			Name:          name,
+			Details:       &pkg_v1.CountryDetails{PhoneCode: "embed-" + name, ISOCode: "E" + name[:1], Currency: "EMB"},
+			Creator:       creator,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, city := range []string{name + " Station", name + " Base"} {
+			if _, err = DB.CreateCity(ctx, nil, &pkg_v1.City{
+				ContinentUuid: continent.Uuid,
+				CountryUuid:   country.Uuid,
+				Name:          city,
+				Details:       &pkg_v1.CityDetails{},
+				Creator:       creator,
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	find := func(continents []*pkg_v1.Continent) *pkg_v1.Continent {
+		for _, iter := range continents {
+			if iter.Uuid == continent.Uuid {
+				return iter
+			}
+		}
+		t.Fatalf("continent %s not listed", continent.Uuid)
+		return nil
+	}
+
+	countries_queries := operationCount(t, "CountriesByContinentIndexes")
+	cities_queries := operationCount(t, "CitiesByCountryIndexes")
+
+	nested, err := DB.ContinentsByOptions(ctx, main.ContinentQueryOptions{
+		Types:         main.ContinentTypeList{pkg_v1.ContinentType_Antarctica},
+		WithCountries: true,
+		WithCities:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	embedded := find(nested)
+	if len(embedded.Countries) != 2 || len(embedded.Cities) != 0 {
+		t.Fatalf("expected 2 countries & cities nested in them, got %d countries & %d cities", len(embedded.Countries), len(embedded.Cities))
+	}
+	for _, country := range embedded.Countries {
+		if country.ContinentUuid != continent.Uuid || len(country.Cities) != 2 {
+			t.Errorf("expected %s in %s with 2 cities, got %s with %d", country.Name, continent.Uuid, country.ContinentUuid, len(country.Cities))
+		}
+		for _, city := range country.Cities {
+			if city.CountryUuid != country.Uuid || city.Details.Country != nil || len(city.Name) == 0 {
+				t.Errorf("unexpected embedded city %+v", city)
+			}
+		}
+	}
+
+	first_country := embedded.Countries[0]
+
+	// one query per level, whatever the number of continents & countries
+	if after := operationCount(t, "CountriesByContinentIndexes"); after != countries_queries+1 {
+		t.Errorf("CountriesByContinentIndexes ran %d times, expected 1", after-countries_queries)
+	}
+	if after := operationCount(t, "CitiesByCountryIndexes"); after != cities_queries+1 {
+		t.Errorf("CitiesByCountryIndexes ran %d times, expected 1", after-cities_queries)
+	}
+
+	flat, err := DB.ContinentsByOptions(ctx, main.ContinentQueryOptions{
+		Types:      main.ContinentTypeList{pkg_v1.ContinentType_Antarctica},
+		WithCities: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if embedded = find(flat); len(embedded.Countries) != 0 || len(embedded.Cities) != 4 {
+		t.Errorf("expected 4 cities without countries, got %d countries & %d cities", len(embedded.Countries), len(embedded.Cities))
+	}
+
+	countries, err := DB.CountriesByOptions(ctx, main.CountryQueryOptions{
+		CountryUuids: []string{first_country.Uuid.String()},
+		WithCities:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(countries) != 1 || len(countries[0].Cities) != 2 {
+		t.Errorf("expected 1 country with 2 cities, got %v", countries)
+	}
+}
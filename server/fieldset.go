@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/nhht77/earth-rest-api/server/pkg/mhttp"
+	"github.com/nhht77/earth-rest-api/server/pkg/msql"
+)
+
+// Fieldset is a tree of json fields, a field without children stands for
+// its whole value. It describes both what an entity can output and what a
+// request selects with fields=.
+type Fieldset map[string]Fieldset
+
+var (
+	userFieldset = Fieldset{"email": nil, "name": nil}
+
+	continentFieldset = Fieldset{
+		"uuid": nil, "name": nil, "type": nil, "area_by_km2": nil,
+		"created": nil, "updated": nil, "creator": userFieldset,
+	}
+	countryFieldset = Fieldset{
+		"uuid": nil, "continent_uuid": nil, "name": nil,
+		"population": nil, "area_by_km2": nil, "gdp": nil,
+		"details": Fieldset{"phone_code": nil, "iso_code": nil, "currency": nil},
+		"created": nil, "updated": nil, "creator": userFieldset,
+	}
+	cityFieldset = Fieldset{
+		"uuid": nil, "continent_uuid": nil, "country_uuid": nil, "name": nil,
+		"population": nil, "timezone": nil, "elevation_by_m": nil,
+		"postal_code_patterns": nil, "alternate_names": nil,
+		"details": Fieldset{"is_capital": nil},
+		"created": nil, "updated": nil, "creator": userFieldset,
+	}
+
+	// output of each endpoint with its expandable relations, relations of
+	// relations are not expanded
+	ContinentOutput = continentFieldset.With("countries", countryFieldset.With("cities", cityFieldset)).With("cities", cityFieldset)
+	CountryOutput   = countryFieldset.With("details.continent", continentFieldset).With("cities", cityFieldset)
+	CityOutput      = cityFieldset.With("details.continent", continentFieldset).With("details.country", countryFieldset)
+)
+
+// With copies fields with sub set at path.
+func (fields Fieldset) With(path string, sub Fieldset) Fieldset {
+	var (
+		result = fields.copy()
+		parts  = strings.Split(path, ".")
+		node   = result
+	)
+	for _, part := range parts[:len(parts)-1] {
+		node = node[part]
+	}
+	node[parts[len(parts)-1]] = sub
+	return result
+}
+
+func (fields Fieldset) copy() Fieldset {
+	if fields == nil {
+		return nil
+	}
+	result := Fieldset{}
+	for key, sub := range fields {
+		result[key] = sub.copy()
+	}
+	return result
+}
+
+// FieldsetFromQuery reads fields=uuid,name,details.iso_code against the
+// output of an endpoint, an empty Fieldset selects everything.
+func FieldsetFromQuery(r *http.Request, output Fieldset) (Fieldset, error) {
+	fields := Fieldset{}
+	for _, path := range mhttp.QueryList(r, "fields", ",") {
+		if len(path) == 0 {
+			continue
+		}
+		if err := fields.add(path, output); err != nil {
+			return nil, err
+		}
+	}
+	return fields, nil
+}
+
+func (fields Fieldset) add(path string, output Fieldset) error {
+	var (
+		parts = strings.Split(path, ".")
+		node  = fields
+	)
+	for i, part := range parts {
+		sub, ok := output[part]
+		if !ok || (sub == nil && i < len(parts)-1) {
+			return fmt.Errorf("unknown field %q", path)
+		}
+
+		next, selected := node[part]
+		if selected && next == nil {
+			// whole value already selected
+			return nil
+		}
+		if i == len(parts)-1 {
+			node[part] = nil
+			return nil
+		}
+		if next == nil {
+			next = Fieldset{}
+			node[part] = next
+		}
+		node, output = next, sub
+	}
+	return nil
+}
+
+// Include selects path as a whole unless fields already narrow it, so an
+// expanded relation is listed with fields= that do not mention it.
+func (fields Fieldset) Include(path string) {
+	if len(fields) == 0 {
+		return
+	}
+
+	var (
+		parts = strings.Split(path, ".")
+		node  = fields
+	)
+	for _, part := range parts[:len(parts)-1] {
+		next, selected := node[part]
+		if selected && next == nil {
+			return
+		}
+		if next == nil {
+			next = Fieldset{}
+			node[part] = next
+		}
+		node = next
+	}
+	if _, selected := node[parts[len(parts)-1]]; !selected {
+		node[parts[len(parts)-1]] = nil
+	}
+}
+
+// String lists the selected paths sorted, for cache keys.
+func (fields Fieldset) String() string {
+	paths := []string{}
+	for key, sub := range fields {
+		if sub == nil {
+			paths = append(paths, key)
+			continue
+		}
+		for _, iter := range strings.Split(sub.String(), ",") {
+			paths = append(paths, key+"."+iter)
+		}
+	}
+	sort.Strings(paths)
+	return strings.Join(paths, ",")
+}
+
+// Columns narrows database_fields to fields of the entity output. Scalar
+// columns are always selected, JSONB columns (those with sub fields) are
+// selected as NULL when not requested or built from the requested keys only,
+// so rows scan in the same order.
+func (fields Fieldset) Columns(database_fields string, entity Fieldset) string {
+	if len(fields) == 0 {
+		return database_fields
+	}
+
+	columns := strings.Split(database_fields, ", ")
+	for i, column := range columns {
+		keys := entity[column]
+		if len(keys) == 0 {
+			continue
+		}
+
+		selected, requested := fields[column]
+		if !requested {
+			columns[i] = "NULL AS " + column
+			continue
+		}
+		if selected == nil {
+			continue
+		}
+
+		pairs := []string{}
+		for key, sub := range keys {
+			// keys with fields of their own are relations, not stored
+			if sub != nil {
+				continue
+			}
+			if _, ok := selected[key]; ok {
+				pairs = append(pairs, fmt.Sprintf("'%s', %s->'%s'", key, column, key))
+			}
+		}
+		sort.Strings(pairs)
+
+		// Note: only relations requested, like details.continent
+		if len(pairs) == 0 {
+			columns[i] = "NULL AS " + column
+			continue
+		}
+		columns[i] = fmt.Sprintf("jsonb_build_object(%s) AS %s", strings.Join(pairs, ", "), column)
+	}
+	return msql.FormatFields(columns...)
+}
+
+// Select returns the json of value with only fields, each element of a list
+// is narrowed alike.
+func (fields Fieldset) Select(value interface{}) (interface{}, error) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		decoded interface{}
+		decoder = json.NewDecoder(bytes.NewReader(body))
+	)
+	decoder.UseNumber()
+	if err = decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+	return fields.prune(decoded), nil
+}
+
+func (fields Fieldset) prune(value interface{}) interface{} {
+	switch value := value.(type) {
+	case []interface{}:
+		for i := range value {
+			value[i] = fields.prune(value[i])
+		}
+	case map[string]interface{}:
+		for key, iter := range value {
+			sub, selected := fields[key]
+			if !selected {
+				delete(value, key)
+				continue
+			}
+			if sub != nil {
+				value[key] = sub.prune(iter)
+			}
+		}
+	}
+	return value
+}
+
+// WriteBodyFields writes value narrowed to fields, or whole without fields.
+func WriteBodyFields(w http.ResponseWriter, value interface{}, fields Fieldset) {
+	if len(fields) == 0 {
+		mhttp.WriteBodyJSON(w, value)
+		return
+	}
+
+	selected, err := fields.Select(value)
+	if err != nil {
+		mhttp.WriteInternalServerError(w, err.Error())
+		return
+	}
+	mhttp.WriteBodyJSON(w, selected)
+}
+
+// ExpandFromQuery reads expand=country,continent against the relations of
+// an endpoint. ok is false without the parameter, the with_* flags apply then.
+func ExpandFromQuery(r *http.Request, relations ...string) (expand map[string]bool, ok bool, err error) {
+	if _, ok = r.URL.Query()["expand"]; !ok {
+		return nil, false, nil
+	}
+
+	expand = map[string]bool{}
+	for _, iter := range mhttp.QueryList(r, "expand", ",") {
+		relation := strings.TrimSpace(iter)
+		if len(relation) == 0 {
+			continue
+		}
+		found := false
+		for _, allowed := range relations {
+			found = found || relation == allowed
+		}
+		if !found {
+			return nil, true, fmt.Errorf("unknown expand %q, expected one of %s", relation, strings.Join(relations, ", "))
+		}
+		expand[relation] = true
+	}
+	return expand, true, nil
+}
@@ -0,0 +1,76 @@
+package main_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	main "github.com/nhht77/earth-rest-api/server"
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+	muuid "github.com/nhht77/earth-rest-api/server/pkg/muuid"
+)
+
+func TestCountryFieldsAndExpand(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/v1/countries?fields=uuid,details.iso_code&expand=continent&with_cities=true", nil)
+
+	options, err := main.CountryOptionsFromQuery(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !options.WithContinent || options.WithCities {
+		t.Errorf("expected expand to supersede with_cities, got continent %t cities %t", options.WithContinent, options.WithCities)
+	}
+	if fields := options.Fields.String(); fields != "details.continent,details.iso_code,uuid" {
+		t.Errorf("unexpected fields %q", fields)
+	}
+
+	columns := options.Fields.Columns(new(pkg_v1.Country).DatabaseFields(), main.CountryOutput)
+	expected := "index, continent_index, uuid, name, population, area_by_km2, gdp, jsonb_build_object('iso_code', details->'iso_code') AS details, NULL AS creator, created, updated, deleted_state"
+	if columns != expected {
+		t.Errorf("unexpected projection\n%s\nexpected\n%s", columns, expected)
+	}
+
+	country := &pkg_v1.Country{
+		Uuid:    muuid.NewUUID(),
+		Name:    "Portugal",
+		Details: &pkg_v1.CountryDetails{ISOCode: "PT", Currency: "EUR", Continent: &pkg_v1.Continent{Name: "Europe"}},
+	}
+	selected, err := options.Fields.Select(pkg_v1.CountryList{country})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(selected)
+
+	expected = `[{"details":{"continent":{"area_by_km2":0,"created":"0001-01-01T00:00:00Z","creator":null,"name":"Europe","type":0,"updated":"0001-01-01T00:00:00Z","uuid":"00000000-0000-0000-0000-000000000000"},"iso_code":"PT"},"uuid":"` + country.Uuid.String() + `"}]`
+	if string(body) != expected {
+		t.Errorf("unexpected body\n%s\nexpected\n%s", body, expected)
+	}
+}
+
+func TestFieldsValidation(t *testing.T) {
+	for _, query := range []string{
+		"/api/v1/cities?fields=density",
+		"/api/v1/cities?fields=name.first",
+		"/api/v1/cities?fields=details.country.cities",
+		"/api/v1/cities?expand=cities",
+	} {
+		if _, err := main.CityOptionsFromQuery(httptest.NewRequest("GET", query, nil)); err == nil {
+			t.Errorf("expected an error for %s", query)
+		}
+	}
+
+	options, err := main.CityOptionsFromQuery(httptest.NewRequest("GET", "/api/v1/cities?fields=name,details.country.name&expand=country", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the narrowed relation is kept as asked
+	if fields := options.Fields.String(); fields != "details.country.name,name" {
+		t.Errorf("unexpected fields %q", fields)
+	}
+
+	// without fields the projection is the full row
+	all, _ := main.ContinentOptionsFromQuery(httptest.NewRequest("GET", "/api/v1/continents?expand=countries", nil))
+	if fields := new(pkg_v1.Continent).DatabaseFields(); all.Fields.Columns(fields, main.ContinentOutput) != fields || len(all.Fields) != 0 {
+		t.Errorf("expected every column without fields, got %v", all.Fields)
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Filter is one filter[field][operator]=value of a list query.
+type Filter struct {
+	Field    string
+	Operator string
+	Value    interface{}
+
+	// SQL expression of Field
+	column string
+}
+
+type FilterList []Filter
+
+type filterKind int
+
+const (
+	filterText filterKind = iota
+	filterNumber
+	filterInteger
+	filterTime
+	filterBool
+)
+
+// filterField is the SQL expression of a filterable json field. Expressions
+// are fixed here, request values only ever reach the query as arguments.
+type filterField struct {
+	column string
+	kind   filterKind
+}
+
+var filterOperators = map[filterKind][]string{
+	filterText:    {"eq", "ne", "like", "ilike", "in"},
+	filterNumber:  {"eq", "ne", "gt", "gte", "lt", "lte", "in"},
+	filterInteger: {"eq", "ne", "gt", "gte", "lt", "lte", "in"},
+	filterTime:    {"eq", "gt", "gte", "lt", "lte"},
+	filterBool:    {"eq", "ne"},
+}
+
+var filterSQL = map[string]string{
+	"eq": "=", "ne": "!=",
+	"gt": ">", "gte": ">=", "lt": "<", "lte": "<=",
+	"like": "LIKE", "ilike": "ILIKE",
+}
+
+var (
+	creatorFilterFields = map[string]filterField{
+		"creator.email": {`creator->>'email'`, filterText},
+		"creator.name":  {`creator->>'name'`, filterText},
+		"created":       {"created", filterTime},
+		"updated":       {"updated", filterTime},
+	}
+
+	continentFilterFields = withFilterFields(creatorFilterFields, map[string]filterField{
+		"name":        {"name", filterText},
+		"type":        {"type", filterInteger},
+		"area_by_km2": {"area_by_km2", filterNumber},
+	})
+	countryFilterFields = withFilterFields(creatorFilterFields, map[string]filterField{
+		"name":               {"name", filterText},
+		"details.phone_code": {`details->>'phone_code'`, filterText},
+		"details.iso_code":   {`details->>'iso_code'`, filterText},
+		"details.currency":   {`details->>'currency'`, filterText},
+		"population":         {"population", filterInteger},
+		"area_by_km2":        {"area_by_km2", filterNumber},
+		"gdp":                {"gdp", filterNumber},
+	})
+	cityFilterFields = withFilterFields(creatorFilterFields, map[string]filterField{
+		"name":               {"name", filterText},
+		"details.is_capital": {cityCapitalColumn, filterBool},
+		"population":         {"population", filterInteger},
+		"elevation_by_m":     {"elevation_by_m", filterNumber},
+		"timezone":           {"timezone", filterText},
+	})
+)
+
+func withFilterFields(base map[string]filterField, fields map[string]filterField) map[string]filterField {
+	result := map[string]filterField{}
+	for key, iter := range base {
+		result[key] = iter
+	}
+	for key, iter := range fields {
+		result[key] = iter
+	}
+	return result
+}
+
+// FiltersFromQuery reads filter[field]=value (eq) and
+// filter[field][operator]=value against the filterable fields of an entity,
+// in=a,b,c takes a comma separated list.
+func FiltersFromQuery(r *http.Request, fields map[string]filterField) (FilterList, error) {
+	filters := FilterList{}
+
+	for key, values := range r.URL.Query() {
+		if !strings.HasPrefix(key, "filter[") {
+			continue
+		}
+
+		name, operator, err := parseFilterKey(key)
+		if err != nil {
+			return nil, err
+		}
+
+		field, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown filter field %q", name)
+		}
+		if !filterAllows(field.kind, operator) {
+			return nil, fmt.Errorf("filter operator %q not supported on %q, expected one of %s", operator, name, strings.Join(filterOperators[field.kind], ", "))
+		}
+
+		for _, raw := range values {
+			value, err := parseFilterValue(field.kind, operator, raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter[%s][%s] value %q: %s", name, operator, raw, err.Error())
+			}
+			filters = append(filters, Filter{Field: name, Operator: operator, Value: value, column: field.column})
+		}
+	}
+
+	// Note: sorted so equal queries have equal cache keys
+	sort.Slice(filters, func(i, j int) bool {
+		return filters[i].String() < filters[j].String()
+	})
+	return filters, nil
+}
+
+func parseFilterKey(key string) (name string, operator string, err error) {
+	rest := strings.TrimPrefix(key, "filter[")
+
+	end := strings.Index(rest, "]")
+	if end <= 0 {
+		return "", "", fmt.Errorf("invalid filter %q", key)
+	}
+	name, rest = rest[:end], rest[end+1:]
+
+	if len(rest) == 0 {
+		return name, "eq", nil
+	}
+	if !strings.HasPrefix(rest, "[") || !strings.HasSuffix(rest, "]") || len(rest) < 3 {
+		return "", "", fmt.Errorf("invalid filter %q", key)
+	}
+	return name, rest[1 : len(rest)-1], nil
+}
+
+func filterAllows(kind filterKind, operator string) bool {
+	for _, iter := range filterOperators[kind] {
+		if iter == operator {
+			return true
+		}
+	}
+	return false
+}
+
+func parseFilterValue(kind filterKind, operator string, raw string) (interface{}, error) {
+	if operator == "in" {
+		parts := strings.Split(raw, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		if kind == filterText {
+			return pq.Array(parts), nil
+		}
+		if kind == filterInteger {
+			integers := []int64{}
+			for _, iter := range parts {
+				integer, err := strconv.ParseInt(iter, 10, 64)
+				if err != nil {
+					return nil, err
+				}
+				integers = append(integers, integer)
+			}
+			return pq.Array(integers), nil
+		}
+
+		numbers := []float64{}
+		for _, iter := range parts {
+			number, err := strconv.ParseFloat(iter, 64)
+			if err != nil {
+				return nil, err
+			}
+			numbers = append(numbers, number)
+		}
+		return pq.Array(numbers), nil
+	}
+
+	switch kind {
+	case filterNumber:
+		return strconv.ParseFloat(raw, 64)
+	case filterInteger:
+		// Note: bigint columns reject fractional arguments
+		return strconv.ParseInt(raw, 10, 64)
+	case filterBool:
+		return strconv.ParseBool(raw)
+	case filterTime:
+		for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+			if parsed, err := time.Parse(layout, raw); err == nil {
+				return parsed, nil
+			}
+		}
+		return nil, fmt.Errorf("expected an RFC 3339 time or a 2006-01-02 date")
+	}
+	return raw, nil
+}
+
+// Where returns the AND conditions of filters, their values appended to
+// args as $n placeholders.
+func (filters FilterList) Where(args *[]interface{}) string {
+	where := ""
+	for _, iter := range filters {
+		*args = append(*args, iter.Value)
+
+		if iter.Operator == "in" {
+			where += fmt.Sprintf("AND %s = ANY($%d) ", iter.column, len(*args))
+			continue
+		}
+		where += fmt.Sprintf("AND %s %s $%d ", iter.column, filterSQL[iter.Operator], len(*args))
+	}
+	return where
+}
+
+func (filter Filter) String() string {
+	value := filter.Value
+	if array, ok := value.(driver.Valuer); ok {
+		value, _ = array.Value()
+	}
+	return fmt.Sprintf("%s[%s]=%v", filter.Field, filter.Operator, value)
+}
+
+// String lists filters for cache keys.
+func (filters FilterList) String() string {
+	list := []string{}
+	for _, iter := range filters {
+		list = append(list, iter.String())
+	}
+	return strings.Join(list, "&")
+}
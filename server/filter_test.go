@@ -0,0 +1,71 @@
+package main_test
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	main "github.com/nhht77/earth-rest-api/server"
+)
+
+func TestFiltersCompile(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/v1/countries?filter[details.currency]=EUR&filter[created][gte]=2022-01-01&filter[name][ilike]=ber%25&filter[details.iso_code][in]=DE,FR", nil)
+
+	options, err := main.CountryOptionsFromQuery(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := []interface{}{"first"}
+	where := options.Filters.Where(&args)
+
+	expected := "AND created >= $2 AND details->>'currency' = $3 AND details->>'iso_code' = ANY($4) AND name ILIKE $5 "
+	if where != expected {
+		t.Errorf("unexpected conditions\n%s\nexpected\n%s", where, expected)
+	}
+	if len(args) != 5 || args[2] != "EUR" || args[4] != "ber%" {
+		t.Fatalf("unexpected args %v", args)
+	}
+	if created, ok := args[1].(time.Time); !ok || !created.Equal(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the created date as a time, got %v", args[1])
+	}
+
+	if codes, ok := args[3].(*pq.StringArray); !ok || len(*codes) != 2 || (*codes)[1] != "FR" {
+		t.Errorf("expected trimmed iso codes, got %v", args[3])
+	}
+
+	// the cache key does not depend on the order of the query
+	reordered, _ := main.CountryOptionsFromQuery(httptest.NewRequest("GET", "/api/v1/countries?filter[name][ilike]=ber%25&filter[details.iso_code][in]=DE,%20FR&filter[created][gte]=2022-01-01&filter[details.currency]=EUR", nil))
+	if options.CacheKey() != reordered.CacheKey() {
+		t.Errorf("expected equal cache keys\n%s\n%s", options.CacheKey(), reordered.CacheKey())
+	}
+}
+
+func TestFiltersValidation(t *testing.T) {
+	for _, query := range []string{
+		"/api/v1/cities?filter[density]=1",
+		"/api/v1/cities?filter[name][gte]=a",
+		"/api/v1/cities?filter[details.is_capital]=maybe",
+		"/api/v1/cities?filter[created][lt]=yesterday",
+		"/api/v1/cities?filter[name",
+		"/api/v1/cities?filter[name]gte=a",
+		"/api/v1/cities?filter[name)%3Bdrop%20table%20city%3B--]=a",
+	} {
+		if _, err := main.CityOptionsFromQuery(httptest.NewRequest("GET", query, nil)); err == nil {
+			t.Errorf("expected an error for %s", query)
+		}
+	}
+
+	options, err := main.ContinentOptionsFromQuery(httptest.NewRequest("GET", "/api/v1/continents?filter[area_by_km2][gt]=1e7&filter[type][in]=1,3", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := []interface{}{}
+	if where := options.Filters.Where(&args); where != "AND area_by_km2 > $1 AND type = ANY($2) " || args[0] != 1e7 {
+		t.Errorf("unexpected conditions %q with %v", where, args)
+	}
+	if _, err := main.ContinentOptionsFromQuery(httptest.NewRequest("GET", "/api/v1/continents?filter[type]=1.5", nil)); err == nil {
+		t.Error("expected an error for a fractional continent type")
+	}
+}
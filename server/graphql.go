@@ -0,0 +1,938 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+
+	graphql "github.com/graph-gophers/graphql-go"
+
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+	"github.com/nhht77/earth-rest-api/server/pkg/mhttp"
+	"github.com/nhht77/earth-rest-api/server/pkg/msql"
+	muuid "github.com/nhht77/earth-rest-api/server/pkg/muuid"
+)
+
+const (
+	// continents { countries { cities { country { continent { name } } } } } is 6
+	GraphQLMaxDepth       = 10
+	GraphQLMaxParallelism = 10
+)
+
+const GraphQLSchema = `
+schema {
+	query: Query
+	mutation: Mutation
+}
+
+scalar Time
+
+type Query {
+	continents(types: [Int!], deleted: Boolean, limit: Int, offset: Int): [Continent!]!
+	continent(uuid: ID!): Continent
+	countries(countries: [ID!], continent_types: [Int!], deleted: Boolean, limit: Int, offset: Int): [Country!]!
+	country(uuid: ID!): Country
+	cities(countries: [ID!], cities: [ID!], continent_types: [Int!], deleted: Boolean, limit: Int, offset: Int): [City!]!
+	city(uuid: ID!): City
+}
+
+type Mutation {
+	create_continent(input: ContinentInput!): Continent!
+	update_continent(input: ContinentInput!): Continent!
+	delete_continent(uuid: ID!): Boolean!
+	create_country(input: CountryInput!): Country!
+	update_country(input: CountryInput!): Country!
+	delete_country(uuid: ID!): Boolean!
+	create_city(input: CityInput!): City!
+	update_city(input: CityInput!): City!
+	delete_city(uuid: ID!): Boolean!
+}
+
+type User {
+	email: String!
+	name: String!
+}
+
+type Continent {
+	uuid: ID!
+	name: String!
+	type: Int!
+	area_by_km2: Float!
+	countries: [Country!]!
+	cities: [City!]!
+	created: Time!
+	updated: Time
+	creator: User
+}
+
+type CountryDetails {
+	phone_code: String!
+	iso_code: String!
+	currency: String!
+}
+
+type Country {
+	uuid: ID!
+	continent_uuid: ID!
+	name: String!
+	# latest values of the yearly series, population as Float since Int is 32-bit
+	population: Float
+	area_by_km2: Float
+	gdp: Float
+	details: CountryDetails!
+	continent: Continent
+	cities: [City!]!
+	created: Time!
+	updated: Time
+	creator: User
+}
+
+type City {
+	uuid: ID!
+	continent_uuid: ID!
+	country_uuid: ID!
+	name: String!
+	is_capital: Boolean!
+	population: Float
+	timezone: String!
+	elevation_by_m: Float
+	postal_code_patterns: [String!]!
+	alternate_names: [String!]!
+	continent: Continent
+	country: Country
+	created: Time!
+	updated: Time
+	creator: User
+}
+
+input UserInput {
+	email: String!
+	name: String!
+}
+
+input ContinentInput {
+	uuid: ID
+	name: String!
+	type: Int!
+	area_by_km2: Float!
+	creator: UserInput
+}
+
+input CountryDetailsInput {
+	phone_code: String!
+	iso_code: String!
+	currency: String!
+}
+
+input CountryInput {
+	uuid: ID
+	continent_uuid: ID!
+	name: String!
+	details: CountryDetailsInput!
+	creator: UserInput
+}
+
+input CityInput {
+	uuid: ID
+	continent_uuid: ID!
+	country_uuid: ID!
+	name: String!
+	is_capital: Boolean
+	population: Float
+	timezone: String
+	elevation_by_m: Float
+	postal_code_patterns: [String!]
+	alternate_names: [String!]
+	creator: UserInput
+}
+`
+
+var GraphQL = graphql.MustParseSchema(GraphQLSchema, &graphQLResolver{},
+	graphql.UseFieldResolvers(),
+	graphql.MaxDepth(GraphQLMaxDepth),
+	graphql.MaxParallelism(GraphQLMaxParallelism),
+)
+
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func HandleGraphQL(w http.ResponseWriter, r *http.Request) {
+	request := graphQLRequest{}
+	if err := mhttp.ReadBodyJSON(r, &request); err != nil {
+		mhttp.WriteBadRequest(w, err.Error())
+		return
+	}
+	if len(request.Query) == 0 {
+		mhttp.WriteBadRequest(w, "Empty query")
+		return
+	}
+
+	// Note: errors of fields are in the response body with the data resolved, status stays 200
+	response := GraphQL.Exec(r.Context(), request.Query, request.OperationName, request.Variables)
+	mhttp.WriteBodyJSON(w, response)
+}
+
+////////////////////////////
+/////// Batches
+
+// Rows resolved together share a batch: a relation asked on one row loads for
+// every row of the batch in one query, so nested lists are not N+1.
+
+type batchLoad struct {
+	once sync.Once
+	err  error
+}
+
+func (load *batchLoad) do(fn func() error) error {
+	load.once.Do(func() { load.err = fn() })
+	return load.err
+}
+
+type continentBatch struct {
+	continents []*pkg_v1.Continent
+
+	countries_load batchLoad
+	countries      map[msql.DatabaseIndex][]*countryResolver
+
+	cities_load batchLoad
+	cities      map[msql.DatabaseIndex][]*cityResolver
+}
+
+type countryBatch struct {
+	countries pkg_v1.CountryList
+
+	continents_load batchLoad
+	continents      map[msql.DatabaseIndex]*continentResolver
+
+	cities_load batchLoad
+	cities      map[msql.DatabaseIndex][]*cityResolver
+}
+
+type cityBatch struct {
+	cities pkg_v1.CityList
+
+	continents_load batchLoad
+	continents      map[msql.DatabaseIndex]*continentResolver
+
+	countries_load batchLoad
+	countries      map[msql.DatabaseIndex]*countryResolver
+}
+
+func newContinentResolvers(continents []*pkg_v1.Continent) []*continentResolver {
+	var (
+		batch   = &continentBatch{continents: continents}
+		results = []*continentResolver{}
+	)
+	for _, iter := range continents {
+		results = append(results, &continentResolver{continent: iter, batch: batch})
+	}
+	return results
+}
+
+func newCountryResolvers(countries pkg_v1.CountryList) []*countryResolver {
+	var (
+		batch   = &countryBatch{countries: countries}
+		results = []*countryResolver{}
+	)
+	for _, iter := range countries {
+		results = append(results, &countryResolver{country: iter, batch: batch})
+	}
+	return results
+}
+
+func newCityResolvers(cities pkg_v1.CityList) []*cityResolver {
+	var (
+		batch   = &cityBatch{cities: cities}
+		results = []*cityResolver{}
+	)
+	for _, iter := range cities {
+		results = append(results, &cityResolver{city: iter, batch: batch})
+	}
+	return results
+}
+
+func continentResolversByIndex(ctx context.Context, indexes msql.DatabaseIndexList) (map[msql.DatabaseIndex]*continentResolver, error) {
+	results := map[msql.DatabaseIndex]*continentResolver{}
+
+	continents, err := DB.ContinentsByIndexes(ctx, indexes)
+	if err != nil {
+		return results, err
+	}
+
+	list := []*pkg_v1.Continent{}
+	for _, iter := range continents {
+		list = append(list, iter)
+	}
+	for _, iter := range newContinentResolvers(list) {
+		results[iter.continent.Index] = iter
+	}
+	return results, nil
+}
+
+func (batch *continentBatch) loadCountries(ctx context.Context) error {
+	return batch.countries_load.do(func() error {
+		indexes := msql.DatabaseIndexList{}
+		for _, iter := range batch.continents {
+			indexes = append(indexes, iter.Index)
+		}
+
+		countries, err := DB.CountriesByContinentIndexes(ctx, indexes)
+		if err != nil {
+			return err
+		}
+
+		batch.countries = map[msql.DatabaseIndex][]*countryResolver{}
+		for _, iter := range newCountryResolvers(countries) {
+			batch.countries[iter.country.ContinentIndex] = append(batch.countries[iter.country.ContinentIndex], iter)
+		}
+		return nil
+	})
+}
+
+func (batch *continentBatch) loadCities(ctx context.Context) error {
+	return batch.cities_load.do(func() error {
+		indexes := msql.DatabaseIndexList{}
+		for _, iter := range batch.continents {
+			indexes = append(indexes, iter.Index)
+		}
+
+		cities, err := DB.CitiesByContinentIndexes(ctx, indexes)
+		if err != nil {
+			return err
+		}
+
+		batch.cities = map[msql.DatabaseIndex][]*cityResolver{}
+		for _, iter := range newCityResolvers(cities) {
+			batch.cities[iter.city.ContinentIndex] = append(batch.cities[iter.city.ContinentIndex], iter)
+		}
+		return nil
+	})
+}
+
+func (batch *countryBatch) loadContinents(ctx context.Context) error {
+	return batch.continents_load.do(func() (err error) {
+		batch.continents, err = continentResolversByIndex(ctx, batch.countries.GetContinentIndexes())
+		return err
+	})
+}
+
+func (batch *countryBatch) loadCities(ctx context.Context) error {
+	return batch.cities_load.do(func() error {
+		indexes := msql.DatabaseIndexList{}
+		for _, iter := range batch.countries {
+			indexes = append(indexes, iter.Index)
+		}
+
+		cities, err := DB.CitiesByCountryIndexes(ctx, indexes)
+		if err != nil {
+			return err
+		}
+
+		batch.cities = map[msql.DatabaseIndex][]*cityResolver{}
+		for _, iter := range newCityResolvers(cities) {
+			batch.cities[iter.city.CountryIndex] = append(batch.cities[iter.city.CountryIndex], iter)
+		}
+		return nil
+	})
+}
+
+func (batch *cityBatch) loadContinents(ctx context.Context) error {
+	return batch.continents_load.do(func() (err error) {
+		indexes := msql.DatabaseIndexList{}
+		for _, iter := range batch.cities {
+			indexes = append(indexes, iter.ContinentIndex)
+		}
+		batch.continents, err = continentResolversByIndex(ctx, indexes)
+		return err
+	})
+}
+
+func (batch *cityBatch) loadCountries(ctx context.Context) error {
+	return batch.countries_load.do(func() error {
+		indexes := msql.DatabaseIndexList{}
+		for _, iter := range batch.cities {
+			indexes = append(indexes, iter.CountryIndex)
+		}
+
+		countries, err := DB.CountriesByIndexes(ctx, indexes)
+		if err != nil {
+			return err
+		}
+
+		list := pkg_v1.CountryList{}
+		for _, iter := range countries {
+			list = append(list, iter)
+		}
+		batch.countries = map[msql.DatabaseIndex]*countryResolver{}
+		for _, iter := range newCountryResolvers(list) {
+			batch.countries[iter.country.Index] = iter
+		}
+		return nil
+	})
+}
+
+////////////////////////////
+/////// Object resolvers
+
+func graphQLUpdated(updated graphql.Time) *graphql.Time {
+	if updated.IsZero() {
+		return nil
+	}
+	return &updated
+}
+
+type continentResolver struct {
+	continent *pkg_v1.Continent
+	batch     *continentBatch
+}
+
+func (r *continentResolver) Uuid() graphql.ID      { return graphql.ID(r.continent.Uuid.String()) }
+func (r *continentResolver) Name() string          { return r.continent.Name }
+func (r *continentResolver) Type() int32           { return int32(r.continent.Type) }
+func (r *continentResolver) AreaByKm2() float64    { return r.continent.AreaByKm2 }
+func (r *continentResolver) Created() graphql.Time { return graphql.Time{Time: r.continent.Created} }
+func (r *continentResolver) Updated() *graphql.Time {
+	return graphQLUpdated(graphql.Time{Time: r.continent.Updated})
+}
+func (r *continentResolver) Creator() *pkg_v1.UserMinimal { return r.continent.Creator }
+
+func (r *continentResolver) Countries(ctx context.Context) ([]*countryResolver, error) {
+	if err := r.batch.loadCountries(ctx); err != nil {
+		return nil, err
+	}
+	if results := r.batch.countries[r.continent.Index]; results != nil {
+		return results, nil
+	}
+	return []*countryResolver{}, nil
+}
+
+func (r *continentResolver) Cities(ctx context.Context) ([]*cityResolver, error) {
+	if err := r.batch.loadCities(ctx); err != nil {
+		return nil, err
+	}
+	if results := r.batch.cities[r.continent.Index]; results != nil {
+		return results, nil
+	}
+	return []*cityResolver{}, nil
+}
+
+type countryResolver struct {
+	country *pkg_v1.Country
+	batch   *countryBatch
+}
+
+func (r *countryResolver) Uuid() graphql.ID                { return graphql.ID(r.country.Uuid.String()) }
+func (r *countryResolver) Name() string                    { return r.country.Name }
+func (r *countryResolver) Details() *pkg_v1.CountryDetails { return r.country.Details }
+func (r *countryResolver) AreaByKm2() *float64             { return r.country.AreaByKm2 }
+func (r *countryResolver) Gdp() *float64                   { return r.country.GDP }
+func (r *countryResolver) Population() *float64 {
+	if r.country.Population == nil {
+		return nil
+	}
+	population := float64(*r.country.Population)
+	return &population
+}
+func (r *countryResolver) Created() graphql.Time { return graphql.Time{Time: r.country.Created} }
+func (r *countryResolver) Updated() *graphql.Time {
+	return graphQLUpdated(graphql.Time{Time: r.country.Updated})
+}
+func (r *countryResolver) Creator() *pkg_v1.UserMinimal { return r.country.Creator }
+
+func (r *countryResolver) ContinentUuid(ctx context.Context) (graphql.ID, error) {
+	if muuid.UUIDValid(r.country.ContinentUuid) {
+		return graphql.ID(r.country.ContinentUuid.String()), nil
+	}
+	continent, err := r.Continent(ctx)
+	if err != nil || continent == nil {
+		return "", err
+	}
+	return continent.Uuid(), nil
+}
+
+func (r *countryResolver) Continent(ctx context.Context) (*continentResolver, error) {
+	if err := r.batch.loadContinents(ctx); err != nil {
+		return nil, err
+	}
+	return r.batch.continents[r.country.ContinentIndex], nil
+}
+
+func (r *countryResolver) Cities(ctx context.Context) ([]*cityResolver, error) {
+	if err := r.batch.loadCities(ctx); err != nil {
+		return nil, err
+	}
+	if results := r.batch.cities[r.country.Index]; results != nil {
+		return results, nil
+	}
+	return []*cityResolver{}, nil
+}
+
+type cityResolver struct {
+	city  *pkg_v1.City
+	batch *cityBatch
+}
+
+func (r *cityResolver) Uuid() graphql.ID      { return graphql.ID(r.city.Uuid.String()) }
+func (r *cityResolver) Name() string          { return r.city.Name }
+func (r *cityResolver) IsCapital() bool       { return r.city.Details != nil && r.city.Details.IsCapital }
+func (r *cityResolver) Created() graphql.Time { return graphql.Time{Time: r.city.Created} }
+func (r *cityResolver) Updated() *graphql.Time {
+	return graphQLUpdated(graphql.Time{Time: r.city.Updated})
+}
+func (r *cityResolver) Creator() *pkg_v1.UserMinimal { return r.city.Creator }
+
+func (r *cityResolver) Timezone() string       { return r.city.Timezone }
+func (r *cityResolver) ElevationByM() *float64 { return r.city.ElevationByM }
+func (r *cityResolver) Population() *float64 {
+	if r.city.Population == nil {
+		return nil
+	}
+	population := float64(*r.city.Population)
+	return &population
+}
+func (r *cityResolver) PostalCodePatterns() []string {
+	return append([]string{}, r.city.PostalCodePatterns...)
+}
+func (r *cityResolver) AlternateNames() []string {
+	return append([]string{}, r.city.AlternateNames...)
+}
+
+func (r *cityResolver) ContinentUuid(ctx context.Context) (graphql.ID, error) {
+	if muuid.UUIDValid(r.city.ContinentUuid) {
+		return graphql.ID(r.city.ContinentUuid.String()), nil
+	}
+	continent, err := r.Continent(ctx)
+	if err != nil || continent == nil {
+		return "", err
+	}
+	return continent.Uuid(), nil
+}
+
+func (r *cityResolver) CountryUuid(ctx context.Context) (graphql.ID, error) {
+	if muuid.UUIDValid(r.city.CountryUuid) {
+		return graphql.ID(r.city.CountryUuid.String()), nil
+	}
+	country, err := r.Country(ctx)
+	if err != nil || country == nil {
+		return "", err
+	}
+	return country.Uuid(), nil
+}
+
+func (r *cityResolver) Continent(ctx context.Context) (*continentResolver, error) {
+	if err := r.batch.loadContinents(ctx); err != nil {
+		return nil, err
+	}
+	return r.batch.continents[r.city.ContinentIndex], nil
+}
+
+func (r *cityResolver) Country(ctx context.Context) (*countryResolver, error) {
+	if err := r.batch.loadCountries(ctx); err != nil {
+		return nil, err
+	}
+	return r.batch.countries[r.city.CountryIndex], nil
+}
+
+////////////////////////////
+/////// Query
+
+type graphQLResolver struct{}
+
+type graphQLPageArgs struct {
+	Deleted *bool
+	Limit   *int32
+	Offset  *int32
+}
+
+func (args graphQLPageArgs) page() (deleted bool, limit int, offset int, err error) {
+	if args.Deleted != nil {
+		deleted = *args.Deleted
+	}
+	if args.Limit != nil {
+		limit = int(*args.Limit)
+	}
+	if args.Offset != nil {
+		offset = int(*args.Offset)
+	}
+	if limit < 0 || limit > ListMaxLimit || offset < 0 {
+		return deleted, limit, offset, errors.New("limit must be between 0 and 1000 and offset 0 or more")
+	}
+	return deleted, limit, offset, nil
+}
+
+func graphQLContinentTypes(types *[]int32) ContinentTypeList {
+	results := ContinentTypeList{}
+	if types != nil {
+		for _, iter := range *types {
+			results = append(results, pkg_v1.ContinentType(iter))
+		}
+	}
+	return results
+}
+
+func graphQLUuids(uuids *[]graphql.ID) []string {
+	results := []string{}
+	if uuids != nil {
+		for _, iter := range *uuids {
+			results = append(results, string(iter))
+		}
+	}
+	return results
+}
+
+func (*graphQLResolver) Continents(ctx context.Context, args struct {
+	Types *[]int32
+	graphQLPageArgs
+}) ([]*continentResolver, error) {
+	var (
+		options = ContinentQueryOptions{Types: graphQLContinentTypes(args.Types)}
+		err     error
+	)
+	if options.Deleted, options.Limit, options.Offset, err = args.page(); err != nil {
+		return nil, err
+	}
+
+	continents, _, err := DB.CachedContinentsByOptions(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	return newContinentResolvers(continents), nil
+}
+
+func (*graphQLResolver) Continent(ctx context.Context, args struct{ Uuid graphql.ID }) (*continentResolver, error) {
+	continent, _, err := DB.CachedContinentByUuid(ctx, string(args.Uuid))
+	if DatabaseNoResults(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newContinentResolvers([]*pkg_v1.Continent{continent})[0], nil
+}
+
+func (*graphQLResolver) Countries(ctx context.Context, args struct {
+	Countries      *[]graphql.ID
+	ContinentTypes *[]int32
+	graphQLPageArgs
+}) ([]*countryResolver, error) {
+	var (
+		options = CountryQueryOptions{
+			CountryUuids:   graphQLUuids(args.Countries),
+			ContinentTypes: graphQLContinentTypes(args.ContinentTypes),
+		}
+		err error
+	)
+	if options.Deleted, options.Limit, options.Offset, err = args.page(); err != nil {
+		return nil, err
+	}
+
+	countries, _, err := DB.CachedCountriesByOptions(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	return newCountryResolvers(countries), nil
+}
+
+func (*graphQLResolver) Country(ctx context.Context, args struct{ Uuid graphql.ID }) (*countryResolver, error) {
+	country, _, err := DB.CachedCountryByUuid(ctx, string(args.Uuid))
+	if DatabaseNoResults(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newCountryResolvers(pkg_v1.CountryList{country})[0], nil
+}
+
+func (*graphQLResolver) Cities(ctx context.Context, args struct {
+	Countries      *[]graphql.ID
+	Cities         *[]graphql.ID
+	ContinentTypes *[]int32
+	graphQLPageArgs
+}) ([]*cityResolver, error) {
+	var (
+		options = CityQueryOptions{
+			CountryUuids:   graphQLUuids(args.Countries),
+			CityUuids:      graphQLUuids(args.Cities),
+			ContinentTypes: graphQLContinentTypes(args.ContinentTypes),
+		}
+		err error
+	)
+	if options.Deleted, options.Limit, options.Offset, err = args.page(); err != nil {
+		return nil, err
+	}
+
+	cities, err := DB.CitiesByOptions(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	return newCityResolvers(cities), nil
+}
+
+func (*graphQLResolver) City(ctx context.Context, args struct{ Uuid graphql.ID }) (*cityResolver, error) {
+	city, _, err := DB.CachedCityByUuid(ctx, string(args.Uuid))
+	if DatabaseNoResults(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newCityResolvers(pkg_v1.CityList{city})[0], nil
+}
+
+////////////////////////////
+/////// Mutation
+
+type graphQLUserInput struct {
+	Email string
+	Name  string
+}
+
+func (input *graphQLUserInput) user() *pkg_v1.UserMinimal {
+	if input == nil {
+		return nil
+	}
+	return &pkg_v1.UserMinimal{Email: input.Email, Name: input.Name}
+}
+
+// graphQLUuid parses an optional uuid, nil gives the zero uuid.
+func graphQLUuid(id *graphql.ID) (muuid.UUID, error) {
+	if id == nil {
+		return muuid.UUID{}, nil
+	}
+	return muuid.UUIDFromString(string(*id))
+}
+
+type graphQLContinentInput struct {
+	Uuid      *graphql.ID
+	Name      string
+	Type      int32
+	AreaByKm2 float64
+	Creator   *graphQLUserInput
+}
+
+func (input graphQLContinentInput) continent() (*pkg_v1.Continent, error) {
+	uuid, err := graphQLUuid(input.Uuid)
+	if err != nil {
+		return nil, err
+	}
+	return &pkg_v1.Continent{
+		Uuid:      uuid,
+		Name:      input.Name,
+		Type:      pkg_v1.ContinentType(input.Type),
+		AreaByKm2: input.AreaByKm2,
+		Creator:   input.Creator.user(),
+	}, nil
+}
+
+type graphQLCountryInput struct {
+	Uuid          *graphql.ID
+	ContinentUuid graphql.ID
+	Name          string
+	Details       struct {
+		PhoneCode string
+		IsoCode   string
+		Currency  string
+	}
+	Creator *graphQLUserInput
+}
+
+func (input graphQLCountryInput) country() (*pkg_v1.Country, error) {
+	uuid, err := graphQLUuid(input.Uuid)
+	if err != nil {
+		return nil, err
+	}
+	continent_uuid, err := muuid.UUIDFromString(string(input.ContinentUuid))
+	if err != nil {
+		return nil, err
+	}
+	return &pkg_v1.Country{
+		Uuid:          uuid,
+		ContinentUuid: continent_uuid,
+		Name:          input.Name,
+		Details: &pkg_v1.CountryDetails{
+			PhoneCode: input.Details.PhoneCode,
+			ISOCode:   input.Details.IsoCode,
+			Currency:  input.Details.Currency,
+		},
+		Creator: input.Creator.user(),
+	}, nil
+}
+
+type graphQLCityInput struct {
+	Uuid          *graphql.ID
+	ContinentUuid graphql.ID
+	CountryUuid   graphql.ID
+	Name          string
+	IsCapital     *bool
+
+	Population         *float64
+	Timezone           *string
+	ElevationByM       *float64
+	PostalCodePatterns *[]string
+	AlternateNames     *[]string
+
+	Creator *graphQLUserInput
+}
+
+func (input graphQLCityInput) city() (*pkg_v1.City, error) {
+	uuid, err := graphQLUuid(input.Uuid)
+	if err != nil {
+		return nil, err
+	}
+	continent_uuid, err := muuid.UUIDFromString(string(input.ContinentUuid))
+	if err != nil {
+		return nil, err
+	}
+	country_uuid, err := muuid.UUIDFromString(string(input.CountryUuid))
+	if err != nil {
+		return nil, err
+	}
+	city := &pkg_v1.City{
+		Uuid:          uuid,
+		ContinentUuid: continent_uuid,
+		CountryUuid:   country_uuid,
+		Name:          input.Name,
+		Details:       &pkg_v1.CityDetails{IsCapital: input.IsCapital != nil && *input.IsCapital},
+		ElevationByM:  input.ElevationByM,
+		Creator:       input.Creator.user(),
+	}
+
+	// Note: population is a Float as GraphQL Int is 32-bit
+	if input.Population != nil {
+		if *input.Population != math.Trunc(*input.Population) || *input.Population >= 1<<63 || *input.Population < -(1<<63) {
+			return nil, errors.New("Invalid city population")
+		}
+		population := int64(*input.Population)
+		city.Population = &population
+	}
+	if input.Timezone != nil {
+		city.Timezone = *input.Timezone
+	}
+	if input.PostalCodePatterns != nil {
+		city.PostalCodePatterns = *input.PostalCodePatterns
+	}
+	if input.AlternateNames != nil {
+		city.AlternateNames = *input.AlternateNames
+	}
+	return city, nil
+}
+
+func (*graphQLResolver) CreateContinent(ctx context.Context, args struct{ Input graphQLContinentInput }) (*continentResolver, error) {
+	continent, err := args.Input.continent()
+	if err != nil {
+		return nil, err
+	}
+	if err = continent.ValidateCreate(); err != nil {
+		return nil, err
+	}
+	if continent, err = DB.CreateContinent(ctx, nil, continent); err != nil {
+		return nil, err
+	}
+	return newContinentResolvers([]*pkg_v1.Continent{continent})[0], nil
+}
+
+func (*graphQLResolver) UpdateContinent(ctx context.Context, args struct{ Input graphQLContinentInput }) (*continentResolver, error) {
+	continent, err := args.Input.continent()
+	if err != nil {
+		return nil, err
+	}
+	if err = continent.ValidateUpdate(); err != nil {
+		return nil, err
+	}
+	if continent, err = DB.UpdateContinent(ctx, nil, continent); err != nil {
+		return nil, err
+	}
+	return newContinentResolvers([]*pkg_v1.Continent{continent})[0], nil
+}
+
+func (*graphQLResolver) DeleteContinent(ctx context.Context, args struct{ Uuid graphql.ID }) (bool, error) {
+	if _, err := muuid.UUIDFromString(string(args.Uuid)); err != nil {
+		return false, err
+	}
+	if err := DB.SoftDeleteContinent(ctx, nil, string(args.Uuid)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (*graphQLResolver) CreateCountry(ctx context.Context, args struct{ Input graphQLCountryInput }) (*countryResolver, error) {
+	country, err := args.Input.country()
+	if err != nil {
+		return nil, err
+	}
+	if err = country.ValidateCreate(); err != nil {
+		return nil, err
+	}
+	if country, err = DB.CreateCountry(ctx, nil, country); err != nil {
+		return nil, err
+	}
+	return newCountryResolvers(pkg_v1.CountryList{country})[0], nil
+}
+
+func (*graphQLResolver) UpdateCountry(ctx context.Context, args struct{ Input graphQLCountryInput }) (*countryResolver, error) {
+	country, err := args.Input.country()
+	if err != nil {
+		return nil, err
+	}
+	if err = country.ValidateUpdate(); err != nil {
+		return nil, err
+	}
+	if country, err = DB.UpdateCountry(ctx, nil, country); err != nil {
+		return nil, err
+	}
+	return newCountryResolvers(pkg_v1.CountryList{country})[0], nil
+}
+
+func (*graphQLResolver) DeleteCountry(ctx context.Context, args struct{ Uuid graphql.ID }) (bool, error) {
+	if _, err := muuid.UUIDFromString(string(args.Uuid)); err != nil {
+		return false, err
+	}
+	if err := DB.SoftDeleteCountry(ctx, nil, string(args.Uuid)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (*graphQLResolver) CreateCity(ctx context.Context, args struct{ Input graphQLCityInput }) (*cityResolver, error) {
+	city, err := args.Input.city()
+	if err != nil {
+		return nil, err
+	}
+	if err = city.ValidateCreate(); err != nil {
+		return nil, err
+	}
+	if city, err = DB.CreateCity(ctx, nil, city); err != nil {
+		return nil, err
+	}
+	return newCityResolvers(pkg_v1.CityList{city})[0], nil
+}
+
+func (*graphQLResolver) UpdateCity(ctx context.Context, args struct{ Input graphQLCityInput }) (*cityResolver, error) {
+	city, err := args.Input.city()
+	if err != nil {
+		return nil, err
+	}
+	if err = city.ValidateUpdate(); err != nil {
+		return nil, err
+	}
+	if city, err = DB.UpdateCity(ctx, nil, city); err != nil {
+		return nil, err
+	}
+	return newCityResolvers(pkg_v1.CityList{city})[0], nil
+}
+
+func (*graphQLResolver) DeleteCity(ctx context.Context, args struct{ Uuid graphql.ID }) (bool, error) {
+	if _, err := muuid.UUIDFromString(string(args.Uuid)); err != nil {
+		return false, err
+	}
+	if err := DB.SoftDeleteCity(ctx, nil, string(args.Uuid)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
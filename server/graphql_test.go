@@ -0,0 +1,200 @@
+package main_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	main "github.com/nhht77/earth-rest-api/server"
+)
+
+type graphQLResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func execGraphQL(t *testing.T, router http.Handler, query string, variables map[string]interface{}) graphQLResponse {
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/graphql", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("graphql answered %d: %s", w.Code, w.Body.String())
+	}
+
+	response := graphQLResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+// operationCount reads how many times CheckOperation saw op succeed.
+func operationCount(t *testing.T, op string) int {
+	buffer := &bytes.Buffer{}
+	if err := main.Metrics.WriteText(buffer); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(buffer.String(), "\n") {
+		if strings.HasPrefix(line, "earth_database_operation_duration_seconds_count{") &&
+			strings.Contains(line, fmt.Sprintf(`operation="%s"`, op)) &&
+			strings.Contains(line, `result="ok"`) {
+			count, _ := strconv.Atoi(line[strings.LastIndex(line, " ")+1:])
+			return count
+		}
+	}
+	return 0
+}
+
+func TestGraphQLNestedBatched(t *testing.T) {
+	RequireDB(t)
+
+	main.DB = DB
+	main.AppConfig = AppConfig
+
+	var (
+		router  = main.NewRouter()
+		creator = map[string]interface{}{"email": "graphql@earth.test", "name": "graphql"}
+	)
+
+	created := execGraphQL(t, router, `mutation($input: ContinentInput!) { create_continent(input: $input) { uuid } }`,
+		map[string]interface{}{"input": map[string]interface{}{
+			"name": "North America", "type": 4, "area_by_km2": 24709000, "creator": creator,
+		}})
+	if len(created.Errors) > 0 {
+		t.Fatal(created.Errors)
+	}
+	var continent struct{ Uuid string }
+	json.Unmarshal(created.Data["create_continent"], &continent)
+	t.Cleanup(func() { DB.SoftDeleteContinent(context.Background(), nil, continent.Uuid) })
+
+	countries := map[string]string{"Canada": "CA", "Mexico": "MX"}
+	for name, iso := range countries {
+		result := execGraphQL(t, router, `mutation($input: CountryInput!) { create_country(input: $input) { uuid continent_uuid } }`,
+			map[string]interface{}{"input": map[string]interface{}{
+				"continent_uuid": continent.Uuid,
+				"name":           name,
+				"details":        map[string]interface{}{"phone_code": "graphql-" + iso, "iso_code": "G" + iso, "currency": iso + "D"},
+				"creator":        creator,
+			}})
+		if len(result.Errors) > 0 {
+			t.Fatal(result.Errors)
+		}
+		var country struct{ Uuid, ContinentUuid string }
+		json.Unmarshal(result.Data["create_country"], &country)
+
+		for _, city := range []string{name + " City", name + " Town"} {
+			result = execGraphQL(t, router, `mutation($input: CityInput!) { create_city(input: $input) { uuid } }`,
+				map[string]interface{}{"input": map[string]interface{}{
+					"continent_uuid": continent.Uuid,
+					"country_uuid":   country.Uuid,
+					"name":           city,
+					"creator":        creator,
+				}})
+			if len(result.Errors) > 0 {
+				t.Fatal(result.Errors)
+			}
+		}
+	}
+
+	var (
+		countries_queries = operationCount(t, "CountriesByContinentIndexes")
+		cities_queries    = operationCount(t, "CitiesByCountryIndexes")
+		country_queries   = operationCount(t, "CountriesByIndexes")
+	)
+
+	result := execGraphQL(t, router, `query($uuid: ID!) {
+		continent(uuid: $uuid) {
+			name
+			countries {
+				name
+				cities { name country_uuid country { name } }
+			}
+		}
+	}`, map[string]interface{}{"uuid": continent.Uuid})
+	if len(result.Errors) > 0 {
+		t.Fatal(result.Errors)
+	}
+
+	var nested struct {
+		Name      string
+		Countries []struct {
+			Name   string
+			Cities []struct {
+				Name    string
+				Country struct{ Name string }
+			}
+		}
+	}
+	json.Unmarshal(result.Data["continent"], &nested)
+
+	if nested.Name != "North America" || len(nested.Countries) != 2 {
+		t.Fatalf("unexpected continent %+v", nested)
+	}
+	for _, country := range nested.Countries {
+		if len(country.Cities) != 2 {
+			t.Errorf("expected 2 cities in %s, got %d", country.Name, len(country.Cities))
+		}
+		for _, city := range country.Cities {
+			if city.Country.Name != country.Name {
+				t.Errorf("city %s resolved country %s, expected %s", city.Name, city.Country.Name, country.Name)
+			}
+		}
+	}
+
+	// one query per level, whatever the number of countries & cities
+	for op, before := range map[string]int{
+		"CountriesByContinentIndexes": countries_queries,
+		"CitiesByCountryIndexes":      cities_queries,
+		"CountriesByIndexes":          country_queries,
+	} {
+		if after := operationCount(t, op); after != before+1 {
+			t.Errorf("%s ran %d times, expected 1", op, after-before)
+		}
+	}
+}
+
+func TestGraphQLErrors(t *testing.T) {
+	RequireDB(t)
+
+	main.DB = DB
+	main.AppConfig = AppConfig
+
+	router := main.NewRouter()
+
+	invalid := execGraphQL(t, router, `mutation { create_continent(input: {name: "", type: 1, area_by_km2: 1}) { uuid } }`, nil)
+	if len(invalid.Errors) == 0 {
+		t.Error("expected a validation error for an empty continent")
+	}
+
+	// Note: 2^63 is the first float64 past math.MaxInt64
+	overflow := execGraphQL(t, router, `mutation($input: CityInput!) { create_city(input: $input) { uuid } }`,
+		map[string]interface{}{"input": map[string]interface{}{
+			"continent_uuid": "6f1c1b7e-5b0c-4a7e-9d7b-3d2c1b0a9f8e",
+			"country_uuid":   "6f1c1b7e-5b0c-4a7e-9d7b-3d2c1b0a9f8e",
+			"name":           "Overflow",
+			"population":     float64(1 << 63),
+			"creator":        map[string]interface{}{"email": "graphql@earth.test", "name": "graphql"},
+		}})
+	if len(overflow.Errors) == 0 || !strings.Contains(overflow.Errors[0].Message, "Invalid city population") {
+		t.Errorf("expected an invalid population for 2^63, got %v", overflow.Errors)
+	}
+
+	missing := execGraphQL(t, router, `query { country(uuid: "6f1c1b7e-5b0c-4a7e-9d7b-3d2c1b0a9f8e") { name } }`, nil)
+	if len(missing.Errors) > 0 || string(missing.Data["country"]) != "null" {
+		t.Errorf("expected a null country, got %s %v", missing.Data["country"], missing.Errors)
+	}
+
+	unknown := execGraphQL(t, router, `query { planets { name } }`, nil)
+	if len(unknown.Errors) == 0 {
+		t.Error("expected an error for an unknown field")
+	}
+}
@@ -0,0 +1,566 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/lib/pq"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+	"github.com/nhht77/earth-rest-api/server/pkg/grpc_v1"
+	"github.com/nhht77/earth-rest-api/server/pkg/mmetrics"
+	muuid "github.com/nhht77/earth-rest-api/server/pkg/muuid"
+)
+
+var (
+	grpcRequestsTotal = mmetrics.NewCounterVec(
+		"earth_grpc_requests_total",
+		"Total gRPC calls by method and status code.",
+		"method", "code",
+	)
+	grpcRequestDuration = mmetrics.NewHistogramVec(
+		"earth_grpc_request_duration_seconds",
+		"gRPC call latency by method and status code.",
+		mmetrics.DefaultBuckets,
+		"method", "code",
+	)
+)
+
+func init() {
+	Metrics.Register(grpcRequestsTotal, grpcRequestDuration)
+}
+
+// NewGRPCServer serves EarthService with the TLS settings of the HTTP server.
+func NewGRPCServer(config TLSConfig) (*grpc.Server, error) {
+	options := []grpc.ServerOption{
+		grpc.UnaryInterceptor(monitorUnary),
+		grpc.StreamInterceptor(monitorStream),
+	}
+
+	if config.Enabled() {
+		tls_config, err := NewTLSConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, grpc.Creds(credentials.NewTLS(tls_config)))
+	}
+
+	server := grpc.NewServer(options...)
+	grpc_v1.RegisterEarthServiceServer(server, &EarthGRPCServer{})
+	return server, nil
+}
+
+func ServeGRPC(server *grpc.Server, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	Log.Infof("[grpc] Listen and serve at %s", addr)
+	return server.Serve(listener)
+}
+
+// StopGRPC waits for running calls until ctx is done, then closes them.
+func StopGRPC(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		Log.Warn("[grpc] Shutdown deadline exceeded, closing remaining calls")
+		server.Stop()
+	}
+}
+
+func observeGRPC(method string, err error, started time.Time) {
+	code := status.Code(err).String()
+	grpcRequestsTotal.Inc(method, code)
+	grpcRequestDuration.Observe(time.Since(started).Seconds(), method, code)
+}
+
+func monitorUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	started := time.Now()
+	Log.Infof("[grpc] %s", info.FullMethod)
+
+	resp, err := handler(ctx, req)
+	observeGRPC(info.FullMethod, err, started)
+	return resp, err
+}
+
+func monitorStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	started := time.Now()
+	Log.Infof("[grpc] %s", info.FullMethod)
+
+	err := handler(srv, stream)
+	observeGRPC(info.FullMethod, err, started)
+	return err
+}
+
+// GRPCError maps errors like WriteDatabaseError maps them to HTTP statuses.
+func GRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var pq_err *pq.Error
+	switch {
+	case DatabaseNoResults(err):
+		return status.Error(codes.NotFound, err.Error())
+	case DatabaseTimeout(err):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case DatabaseConflict(err):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.As(err, &pq_err):
+		return status.Error(codes.Internal, err.Error())
+	}
+	// Note: the Database methods & validators report invalid input as plain errors
+	return status.Error(codes.InvalidArgument, err.Error())
+}
+
+////////////////////////////
+/////// Conversions
+
+func grpcTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// grpcUuid parses an optional uuid, empty gives the zero uuid.
+func grpcUuid(uuid string) (muuid.UUID, error) {
+	if len(uuid) == 0 {
+		return muuid.UUID{}, nil
+	}
+	return muuid.UUIDFromString(uuid)
+}
+
+func grpcUuidString(uuid muuid.UUID) string {
+	if !muuid.UUIDValid(uuid) {
+		return ""
+	}
+	return uuid.String()
+}
+
+func toGRPCUser(user *pkg_v1.UserMinimal) *grpc_v1.User {
+	if user == nil {
+		return nil
+	}
+	return &grpc_v1.User{Email: user.Email, Name: user.Name}
+}
+
+func fromGRPCUser(user *grpc_v1.User) *pkg_v1.UserMinimal {
+	if user == nil {
+		return nil
+	}
+	return &pkg_v1.UserMinimal{Email: user.Email, Name: user.Name}
+}
+
+func toGRPCContinentTypes(types []grpc_v1.ContinentType) ContinentTypeList {
+	results := ContinentTypeList{}
+	for _, iter := range types {
+		results = append(results, pkg_v1.ContinentType(iter))
+	}
+	return results
+}
+
+func toGRPCContinent(continent *pkg_v1.Continent) *grpc_v1.Continent {
+	if continent == nil {
+		return nil
+	}
+	return &grpc_v1.Continent{
+		Uuid:      grpcUuidString(continent.Uuid),
+		Name:      continent.Name,
+		Type:      grpc_v1.ContinentType(continent.Type),
+		AreaByKm2: continent.AreaByKm2,
+		Created:   grpcTimestamp(continent.Created),
+		Updated:   grpcTimestamp(continent.Updated),
+		Creator:   toGRPCUser(continent.Creator),
+	}
+}
+
+func fromGRPCContinent(continent *grpc_v1.Continent) (*pkg_v1.Continent, error) {
+	uuid, err := grpcUuid(continent.GetUuid())
+	if err != nil {
+		return nil, err
+	}
+	return &pkg_v1.Continent{
+		Uuid:      uuid,
+		Name:      continent.GetName(),
+		Type:      pkg_v1.ContinentType(continent.GetType()),
+		AreaByKm2: continent.GetAreaByKm2(),
+		Creator:   fromGRPCUser(continent.GetCreator()),
+	}, nil
+}
+
+func toGRPCCountry(country *pkg_v1.Country) *grpc_v1.Country {
+	if country == nil {
+		return nil
+	}
+	result := &grpc_v1.Country{
+		Uuid:          grpcUuidString(country.Uuid),
+		ContinentUuid: grpcUuidString(country.ContinentUuid),
+		Name:          country.Name,
+		Created:       grpcTimestamp(country.Created),
+		Updated:       grpcTimestamp(country.Updated),
+		Creator:       toGRPCUser(country.Creator),
+	}
+	if country.Details != nil {
+		result.Details = &grpc_v1.CountryDetails{
+			PhoneCode: country.Details.PhoneCode,
+			IsoCode:   country.Details.ISOCode,
+			Currency:  country.Details.Currency,
+			Continent: toGRPCContinent(country.Details.Continent),
+		}
+	}
+	return result
+}
+
+func fromGRPCCountry(country *grpc_v1.Country) (*pkg_v1.Country, error) {
+	uuid, err := grpcUuid(country.GetUuid())
+	if err != nil {
+		return nil, err
+	}
+	continent_uuid, err := grpcUuid(country.GetContinentUuid())
+	if err != nil {
+		return nil, err
+	}
+
+	result := &pkg_v1.Country{
+		Uuid:          uuid,
+		ContinentUuid: continent_uuid,
+		Name:          country.GetName(),
+		Creator:       fromGRPCUser(country.GetCreator()),
+	}
+	if details := country.GetDetails(); details != nil {
+		result.Details = &pkg_v1.CountryDetails{
+			PhoneCode: details.GetPhoneCode(),
+			ISOCode:   details.GetIsoCode(),
+			Currency:  details.GetCurrency(),
+		}
+	}
+	return result, nil
+}
+
+func toGRPCCity(city *pkg_v1.City) *grpc_v1.City {
+	if city == nil {
+		return nil
+	}
+	result := &grpc_v1.City{
+		Uuid:          grpcUuidString(city.Uuid),
+		ContinentUuid: grpcUuidString(city.ContinentUuid),
+		CountryUuid:   grpcUuidString(city.CountryUuid),
+		Name:          city.Name,
+		Created:       grpcTimestamp(city.Created),
+		Updated:       grpcTimestamp(city.Updated),
+		Creator:       toGRPCUser(city.Creator),
+	}
+	if city.Details != nil {
+		result.Details = &grpc_v1.CityDetails{
+			IsCapital: city.Details.IsCapital,
+			Continent: toGRPCContinent(city.Details.Continent),
+			Country:   toGRPCCountry(city.Details.Country),
+		}
+	}
+	return result
+}
+
+func fromGRPCCity(city *grpc_v1.City) (*pkg_v1.City, error) {
+	uuid, err := grpcUuid(city.GetUuid())
+	if err != nil {
+		return nil, err
+	}
+	continent_uuid, err := grpcUuid(city.GetContinentUuid())
+	if err != nil {
+		return nil, err
+	}
+	country_uuid, err := grpcUuid(city.GetCountryUuid())
+	if err != nil {
+		return nil, err
+	}
+
+	return &pkg_v1.City{
+		Uuid:          uuid,
+		ContinentUuid: continent_uuid,
+		CountryUuid:   country_uuid,
+		Name:          city.GetName(),
+		Details:       &pkg_v1.CityDetails{IsCapital: city.GetDetails().GetIsCapital()},
+		Creator:       fromGRPCUser(city.GetCreator()),
+	}, nil
+}
+
+func cityOptionsFromGRPC(req *grpc_v1.ListCitiesRequest) (CityQueryOptions, error) {
+	options := CityQueryOptions{
+		WithCountry:    req.GetWithCountry(),
+		WithContinent:  req.GetWithContinent(),
+		CountryUuids:   req.GetCountryUuids(),
+		CityUuids:      req.GetCityUuids(),
+		ContinentTypes: toGRPCContinentTypes(req.GetContinentTypes()),
+		Deleted:        req.GetDeleted(),
+		Limit:          int(req.GetLimit()),
+		Offset:         int(req.GetOffset()),
+	}
+	return options, grpcPage(options.Limit, options.Offset)
+}
+
+func grpcPage(limit int, offset int) error {
+	if limit < 0 || limit > ListMaxLimit || offset < 0 {
+		return status.Errorf(codes.InvalidArgument, "limit must be between 0 and %d and offset 0 or more", ListMaxLimit)
+	}
+	return nil
+}
+
+////////////////////////////
+/////// EarthService
+
+// EarthGRPCServer implements EarthService with the Database methods & the
+// validators of the REST handlers.
+type EarthGRPCServer struct {
+	grpc_v1.UnimplementedEarthServiceServer
+}
+
+func (*EarthGRPCServer) ListContinents(ctx context.Context, req *grpc_v1.ListContinentsRequest) (*grpc_v1.ListContinentsResponse, error) {
+	options := ContinentQueryOptions{
+		Types:   toGRPCContinentTypes(req.GetTypes()),
+		Deleted: req.GetDeleted(),
+		Limit:   int(req.GetLimit()),
+		Offset:  int(req.GetOffset()),
+	}
+	if err := grpcPage(options.Limit, options.Offset); err != nil {
+		return nil, err
+	}
+
+	continents, _, err := DB.CachedContinentsByOptions(ctx, options)
+	if err != nil {
+		return nil, GRPCError(err)
+	}
+
+	resp := &grpc_v1.ListContinentsResponse{}
+	for _, iter := range continents {
+		resp.Continents = append(resp.Continents, toGRPCContinent(iter))
+	}
+	return resp, nil
+}
+
+func (*EarthGRPCServer) GetContinent(ctx context.Context, req *grpc_v1.UuidRequest) (*grpc_v1.Continent, error) {
+	continent, _, err := DB.CachedContinentByUuid(ctx, req.GetUuid())
+	if err != nil {
+		return nil, GRPCError(err)
+	}
+	return toGRPCContinent(continent), nil
+}
+
+func (*EarthGRPCServer) CreateContinent(ctx context.Context, req *grpc_v1.Continent) (*grpc_v1.Continent, error) {
+	continent, err := fromGRPCContinent(req)
+	if err != nil {
+		return nil, GRPCError(err)
+	}
+	if err = continent.ValidateCreate(); err != nil {
+		return nil, GRPCError(err)
+	}
+
+	result, err := DB.CreateContinent(ctx, nil, continent)
+	if err != nil {
+		return nil, GRPCError(err)
+	}
+	return toGRPCContinent(result), nil
+}
+
+func (*EarthGRPCServer) UpdateContinent(ctx context.Context, req *grpc_v1.Continent) (*grpc_v1.Continent, error) {
+	continent, err := fromGRPCContinent(req)
+	if err != nil {
+		return nil, GRPCError(err)
+	}
+	if err = continent.ValidateUpdate(); err != nil {
+		return nil, GRPCError(err)
+	}
+
+	result, err := DB.UpdateContinent(ctx, nil, continent)
+	if err != nil {
+		return nil, GRPCError(err)
+	}
+	return toGRPCContinent(result), nil
+}
+
+func (*EarthGRPCServer) DeleteContinent(ctx context.Context, req *grpc_v1.UuidRequest) (*emptypb.Empty, error) {
+	if _, err := muuid.UUIDFromString(req.GetUuid()); err != nil {
+		return nil, GRPCError(err)
+	}
+	if err := DB.SoftDeleteContinent(ctx, nil, req.GetUuid()); err != nil {
+		return nil, GRPCError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (*EarthGRPCServer) ListCountries(ctx context.Context, req *grpc_v1.ListCountriesRequest) (*grpc_v1.ListCountriesResponse, error) {
+	options := CountryQueryOptions{
+		WithContinent:  req.GetWithContinent(),
+		CountryUuids:   req.GetCountryUuids(),
+		ContinentTypes: toGRPCContinentTypes(req.GetContinentTypes()),
+		Deleted:        req.GetDeleted(),
+		Limit:          int(req.GetLimit()),
+		Offset:         int(req.GetOffset()),
+	}
+	if err := grpcPage(options.Limit, options.Offset); err != nil {
+		return nil, err
+	}
+
+	countries, _, err := DB.CachedCountriesByOptions(ctx, options)
+	if err != nil {
+		return nil, GRPCError(err)
+	}
+
+	resp := &grpc_v1.ListCountriesResponse{}
+	for _, iter := range countries {
+		resp.Countries = append(resp.Countries, toGRPCCountry(iter))
+	}
+	return resp, nil
+}
+
+func (*EarthGRPCServer) GetCountry(ctx context.Context, req *grpc_v1.UuidRequest) (*grpc_v1.Country, error) {
+	country, _, err := DB.CachedCountryByUuid(ctx, req.GetUuid())
+	if err != nil {
+		return nil, GRPCError(err)
+	}
+	return toGRPCCountry(country), nil
+}
+
+func (*EarthGRPCServer) CreateCountry(ctx context.Context, req *grpc_v1.Country) (*grpc_v1.Country, error) {
+	country, err := fromGRPCCountry(req)
+	if err != nil {
+		return nil, GRPCError(err)
+	}
+	if err = country.ValidateCreate(); err != nil {
+		return nil, GRPCError(err)
+	}
+
+	result, err := DB.CreateCountry(ctx, nil, country)
+	if err != nil {
+		return nil, GRPCError(err)
+	}
+	return toGRPCCountry(result), nil
+}
+
+func (*EarthGRPCServer) UpdateCountry(ctx context.Context, req *grpc_v1.Country) (*grpc_v1.Country, error) {
+	country, err := fromGRPCCountry(req)
+	if err != nil {
+		return nil, GRPCError(err)
+	}
+	if err = country.ValidateUpdate(); err != nil {
+		return nil, GRPCError(err)
+	}
+
+	result, err := DB.UpdateCountry(ctx, nil, country)
+	if err != nil {
+		return nil, GRPCError(err)
+	}
+	return toGRPCCountry(result), nil
+}
+
+func (*EarthGRPCServer) DeleteCountry(ctx context.Context, req *grpc_v1.UuidRequest) (*emptypb.Empty, error) {
+	if _, err := muuid.UUIDFromString(req.GetUuid()); err != nil {
+		return nil, GRPCError(err)
+	}
+	if err := DB.SoftDeleteCountry(ctx, nil, req.GetUuid()); err != nil {
+		return nil, GRPCError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (*EarthGRPCServer) ListCities(ctx context.Context, req *grpc_v1.ListCitiesRequest) (*grpc_v1.ListCitiesResponse, error) {
+	options, err := cityOptionsFromGRPC(req)
+	if err != nil {
+		return nil, err
+	}
+
+	cities, err := DB.CitiesByOptions(ctx, options)
+	if err != nil {
+		return nil, GRPCError(err)
+	}
+
+	resp := &grpc_v1.ListCitiesResponse{}
+	for _, iter := range cities {
+		resp.Cities = append(resp.Cities, toGRPCCity(iter))
+	}
+	return resp, nil
+}
+
+// StreamCities sends each city as its row is read, the list is never held
+// in memory. A client that stops reading holds a database connection until
+// the call is cancelled.
+func (*EarthGRPCServer) StreamCities(req *grpc_v1.ListCitiesRequest, stream grpc_v1.EarthService_StreamCitiesServer) error {
+	options, err := cityOptionsFromGRPC(req)
+	if err != nil {
+		return err
+	}
+
+	return GRPCError(DB.EachCityByOptions(stream.Context(), options, func(city *pkg_v1.City) error {
+		return stream.Send(toGRPCCity(city))
+	}))
+}
+
+func (*EarthGRPCServer) GetCity(ctx context.Context, req *grpc_v1.UuidRequest) (*grpc_v1.City, error) {
+	city, _, err := DB.CachedCityByUuid(ctx, req.GetUuid())
+	if err != nil {
+		return nil, GRPCError(err)
+	}
+	return toGRPCCity(city), nil
+}
+
+func (*EarthGRPCServer) CreateCity(ctx context.Context, req *grpc_v1.City) (*grpc_v1.City, error) {
+	city, err := fromGRPCCity(req)
+	if err != nil {
+		return nil, GRPCError(err)
+	}
+	if err = city.ValidateCreate(); err != nil {
+		return nil, GRPCError(err)
+	}
+
+	result, err := DB.CreateCity(ctx, nil, city)
+	if err != nil {
+		return nil, GRPCError(err)
+	}
+	return toGRPCCity(result), nil
+}
+
+func (*EarthGRPCServer) UpdateCity(ctx context.Context, req *grpc_v1.City) (*grpc_v1.City, error) {
+	city, err := fromGRPCCity(req)
+	if err != nil {
+		return nil, GRPCError(err)
+	}
+	if err = city.ValidateUpdate(); err != nil {
+		return nil, GRPCError(err)
+	}
+
+	result, err := DB.UpdateCity(ctx, nil, city)
+	if err != nil {
+		return nil, GRPCError(err)
+	}
+	return toGRPCCity(result), nil
+}
+
+func (*EarthGRPCServer) DeleteCity(ctx context.Context, req *grpc_v1.UuidRequest) (*emptypb.Empty, error) {
+	if _, err := muuid.UUIDFromString(req.GetUuid()); err != nil {
+		return nil, GRPCError(err)
+	}
+	if err := DB.SoftDeleteCity(ctx, nil, req.GetUuid()); err != nil {
+		return nil, GRPCError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,146 @@
+package main_test
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	main "github.com/nhht77/earth-rest-api/server"
+	"github.com/nhht77/earth-rest-api/server/pkg/grpc_v1"
+)
+
+var grpcCreator = &grpc_v1.User{Email: "grpc@earth.test", Name: "grpc"}
+
+func newGRPCClient(t *testing.T) grpc_v1.EarthServiceClient {
+	main.DB = DB
+	main.AppConfig = AppConfig
+
+	server, err := main.NewGRPCServer(main.TLSConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return grpc_v1.NewEarthServiceClient(conn)
+}
+
+func TestGRPCStreamCities(t *testing.T) {
+	RequireDB(t)
+
+	var (
+		c   = newGRPCClient(t)
+		ctx = context.Background()
+	)
+
+	continent, err := c.CreateContinent(ctx, &grpc_v1.Continent{
+		Name:      "Oceania",
+		Type:      grpc_v1.ContinentType_CONTINENT_TYPE_OCEANIA,
+		AreaByKm2: 8525989,
+		Creator:   grpcCreator,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { DB.SoftDeleteContinent(context.Background(), nil, continent.Uuid) })
+
+	country, err := c.CreateCountry(ctx, &grpc_v1.Country{
+		ContinentUuid: continent.Uuid,
+		Name:          "New Zealand",
+		Details:       &grpc_v1.CountryDetails{PhoneCode: "grpc-64", IsoCode: "GNZ", Currency: "NZD"},
+		Creator:       grpcCreator,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{"Wellington", "Auckland", "Christchurch"}
+	for i, name := range names {
+		if _, err = c.CreateCity(ctx, &grpc_v1.City{
+			ContinentUuid: continent.Uuid,
+			CountryUuid:   country.Uuid,
+			Name:          name,
+			Details:       &grpc_v1.CityDetails{IsCapital: i == 0},
+			Creator:       grpcCreator,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	request := &grpc_v1.ListCitiesRequest{CountryUuids: []string{country.Uuid}, WithCountry: true}
+	listed, err := c.ListCities(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream, err := c.StreamCities(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	streamed := []*grpc_v1.City{}
+	for {
+		city, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		streamed = append(streamed, city)
+	}
+
+	if len(streamed) != len(names) || len(listed.Cities) != len(names) {
+		t.Fatalf("expected %d cities, streamed %d & listed %d", len(names), len(streamed), len(listed.Cities))
+	}
+	for i, city := range streamed {
+		if city.Uuid != listed.Cities[i].Uuid {
+			t.Errorf("city %d: streamed %s, listed %s", i, city.Uuid, listed.Cities[i].Uuid)
+		}
+		if city.GetDetails().GetCountry().GetName() != "New Zealand" {
+			t.Errorf("expected %s with its country, got %v", city.Name, city.GetDetails())
+		}
+	}
+}
+
+func TestGRPCErrors(t *testing.T) {
+	RequireDB(t)
+
+	var (
+		c   = newGRPCClient(t)
+		ctx = context.Background()
+	)
+
+	_, err := c.CreateContinent(ctx, &grpc_v1.Continent{Name: "Nowhere"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for an invalid continent, got %v", err)
+	}
+
+	_, err = c.GetCountry(ctx, &grpc_v1.UuidRequest{Uuid: "6f1c1b7e-5b0c-4a7e-9d7b-3d2c1b0a9f8e"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for a missing country, got %v", err)
+	}
+
+	if code := status.Code(main.GRPCError(sql.ErrNoRows)); code != codes.NotFound {
+		t.Errorf("expected NotFound for sql.ErrNoRows, got %s", code)
+	}
+	if code := status.Code(main.GRPCError(context.DeadlineExceeded)); code != codes.DeadlineExceeded {
+		t.Errorf("expected DeadlineExceeded for a timeout, got %s", code)
+	}
+}
@@ -0,0 +1,79 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	main "github.com/nhht77/earth-rest-api/server"
+)
+
+func readyz(t *testing.T) (int, map[string]*main.HealthCheck) {
+	recorder := httptest.NewRecorder()
+	main.HandleReadyz(recorder, httptest.NewRequest("GET", "/readyz", nil))
+
+	report := &main.HealthReport{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), report); err != nil {
+		t.Fatalf("%s: %s", err, recorder.Body.String())
+	}
+
+	checks := map[string]*main.HealthCheck{}
+	for _, iter := range report.Checks {
+		checks[iter.Name] = iter
+	}
+	return recorder.Code, checks
+}
+
+func TestReadyzDatabaseNotInitialized(t *testing.T) {
+	previous := main.DB
+	main.DB = &main.Database{}
+	defer func() { main.DB = previous }()
+
+	code, checks := readyz(t)
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("status %d, expected %d", code, http.StatusServiceUnavailable)
+	}
+	for _, name := range []string{"database", "migrations"} {
+		if checks[name] == nil || checks[name].Status != main.HealthStatus_Fail {
+			t.Errorf("check %s = %+v, expected %s", name, checks[name], main.HealthStatus_Fail)
+		}
+	}
+}
+
+func TestReadyzPoolSaturated(t *testing.T) {
+	RequireDB(t)
+
+	config := *AppConfig
+	config.Database.Pool.MaxOpenConns = 1
+
+	db := &main.Database{}
+	if err := db.Initialize(&config); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	previous := main.DB
+	main.DB = db
+	defer func() { main.DB = previous }()
+
+	if code, checks := readyz(t); code != http.StatusOK {
+		t.Fatalf("status %d before saturation, checks %+v", code, checks)
+	}
+
+	// holds the only connection of the pool
+	tx, err := db.Begin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Rollback(tx)
+
+	code, checks := readyz(t)
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("status %d, expected %d", code, http.StatusServiceUnavailable)
+	}
+	if pool := checks["database_pool"]; pool == nil || pool.Status != main.HealthStatus_Fail {
+		t.Errorf("database_pool = %+v, expected %s", pool, main.HealthStatus_Fail)
+	}
+}
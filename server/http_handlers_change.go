@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+	"github.com/nhht77/earth-rest-api/server/pkg/mhttp"
+)
+
+const (
+	ChangeStreamRetry     = 3 * time.Second
+	ChangeStreamHeartbeat = 15 * time.Second
+)
+
+// HandleChanges streams the change log as server-sent events, the event id
+// is the change index. Clients resume with Last-Event-ID.
+func HandleChanges(w http.ResponseWriter, r *http.Request) {
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		mhttp.WriteInternalServerError(w, "Streaming is not supported")
+		return
+	}
+
+	options, err := ChangeOptionsFromQuery(r)
+	if err != nil {
+		mhttp.WriteBadRequest(w, fmt.Sprintf("Invalid query: %s", err.Error()))
+		return
+	}
+
+	// subscribe before reading the log so nothing written in between is lost
+	changes := Changes.Subscribe()
+	if changes == nil {
+		mhttp.WriteServiceUnavailable(w, "Change feed is not available")
+		return
+	}
+	defer Changes.Unsubscribe(changes)
+
+	// Note: changes after the feed position may still miss a lower index,
+	// the feed sends them once it is resolved
+	if last, listening := Changes.Last(); listening {
+		options.Until = last
+	}
+
+	var (
+		ctx    = WithPrimary(r.Context())
+		replay = []*pkg_v1.Change{}
+	)
+	if options.After > 0 && (options.Until == 0 || options.After < options.Until) {
+		replay, err = DB.ChangesByOptions(ctx, nil, options)
+		if err != nil {
+			WriteDatabaseError(w, err)
+			return
+		}
+	}
+
+	// the stream outlives the server WriteTimeout, when the deadline can't be
+	// lifted it ends cleanly before the timeout cuts it
+	var deadline <-chan time.Time
+	if err := mhttp.ClearWriteDeadline(w); err != nil {
+		if write_timeout := time.Duration(AppConfig.Framework.WriteTimeout); write_timeout > 0 {
+			timer := time.NewTimer(write_timeout * 9 / 10)
+			defer timer.Stop()
+			deadline = timer.C
+		}
+	}
+
+	if err := mhttp.WriteEventStreamHeaders(w, ChangeStreamRetry); err != nil {
+		return
+	}
+
+	// Note: replay pages until the log is exhausted, live changes already replayed are skipped
+	for len(replay) > 0 {
+		for _, iter := range replay {
+			if err := writeChange(w, iter); err != nil {
+				return
+			}
+			options.After = iter.Index
+		}
+		flusher.Flush()
+
+		if len(replay) < options.Limit {
+			break
+		}
+		if replay, err = DB.ChangesByOptions(ctx, nil, options); err != nil {
+			Log.Warnf("[http] Change stream replay error: %s", err.Error())
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(ChangeStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-deadline:
+			return
+		case <-heartbeat.C:
+			if err := mhttp.WriteEventComment(w, "heartbeat"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case change, ok := <-changes:
+			if !ok {
+				return
+			}
+			if change.Index <= options.After || !options.Matches(change) {
+				continue
+			}
+			if err := writeChange(w, change); err != nil {
+				return
+			}
+			options.After = change.Index
+			flusher.Flush()
+		}
+	}
+}
+
+func writeChange(w http.ResponseWriter, change *pkg_v1.Change) error {
+	return mhttp.WriteEvent(w, strconv.FormatUint(uint64(change.Index), 10), string(change.Entity), change)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -18,13 +19,13 @@ func HandleCities(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	results, err := DB.CitiesByOptions(options)
+	results, err := DB.CitiesByOptions(r.Context(), options)
 	if err != nil {
-		mhttp.WriteBadRequest(w, err.Error())
+		WriteDatabaseError(w, err)
 		return
 	}
 
-	mhttp.WriteBodyJSON(w, results)
+	WriteBodyFields(w, results, options.Fields)
 }
 
 func HandleCity(w http.ResponseWriter, r *http.Request) {
@@ -35,13 +36,57 @@ func HandleCity(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := DB.CityByUuid(nil, c_uuid)
+	options, err := CityOptionsFromQuery(r)
 	if err != nil {
-		mhttp.WriteBadRequest(w, err.Error())
+		mhttp.WriteBadRequest(w, fmt.Sprintf("Invalid query: %s", err.Error()))
 		return
 	}
 
-	mhttp.WriteBodyJSON(w, result)
+	result, hit, err := DB.CachedCityByUuid(r.Context(), c_uuid)
+	if err != nil {
+		WriteDatabaseError(w, err)
+		return
+	}
+
+	if result, err = expandCity(r.Context(), result, options); err != nil {
+		WriteDatabaseError(w, err)
+		return
+	}
+
+	WriteCacheHeaders(w, hit)
+	WriteBodyFields(w, result, options.Fields)
+}
+
+// expandCity returns a copy of the cached city with the relations of
+// options.
+func expandCity(ctx context.Context, city *pkg_v1.City, options CityQueryOptions) (*pkg_v1.City, error) {
+	if !options.WithContinent && !options.WithCountry {
+		return city, nil
+	}
+
+	copied := *city
+	if copied.Details != nil {
+		details := *copied.Details
+		copied.Details = &details
+	} else {
+		copied.Details = &pkg_v1.CityDetails{}
+	}
+
+	if options.WithContinent {
+		continent, _, err := DB.CachedContinentByUuid(ctx, copied.ContinentUuid.String())
+		if err != nil {
+			return nil, err
+		}
+		copied.Details.Continent = continent
+	}
+	if options.WithCountry {
+		country, _, err := DB.CachedCountryByUuid(ctx, copied.CountryUuid.String())
+		if err != nil {
+			return nil, err
+		}
+		copied.Details.Country = country
+	}
+	return &copied, nil
 }
 
 func HandleCreateCity(w http.ResponseWriter, r *http.Request) {
@@ -58,9 +103,9 @@ func HandleCreateCity(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := DB.CreateCity(nil, continent)
+	result, err := DB.CreateCity(r.Context(), nil, continent)
 	if err != nil {
-		mhttp.WriteBadRequest(w, err.Error())
+		WriteDatabaseError(w, err)
 		return
 	}
 
@@ -83,9 +128,9 @@ func HandleUpdateCity(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := DB.UpdateCity(nil, continent)
+	result, err := DB.UpdateCity(r.Context(), nil, continent)
 	if err != nil {
-		mhttp.WriteBadRequest(w, err.Error())
+		WriteDatabaseError(w, err)
 		return
 	}
 
@@ -100,11 +145,41 @@ func HandleDeleteCity(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := DB.SoftDeleteCity(nil, query_uuid); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := DB.SoftDeleteCity(r.Context(), nil, query_uuid); err != nil {
+		WriteDatabaseError(w, err)
 		return
 	}
 
 	// Note: return 200
 	mhttp.WriteBodyJSON(w, "")
 }
+
+// HandleUpsertCity updates the city with the uuid of the query or body, or
+// creates it with that uuid (201).
+func HandleUpsertCity(w http.ResponseWriter, r *http.Request) {
+
+	city := &pkg_v1.City{}
+	if err := mhttp.ReadBodyJSON(r, &city); err != nil {
+		mhttp.WriteBadRequest(w, err.Error())
+		return
+	}
+
+	uuid, err := ResourceUuid(r, city.Uuid)
+	if err != nil {
+		mhttp.WriteBadRequest(w, err.Error())
+		return
+	}
+	city.Uuid = uuid
+
+	result, created, err := DB.UpsertCity(r.Context(), nil, city)
+	if err != nil {
+		WriteDatabaseError(w, err)
+		return
+	}
+
+	if created {
+		mhttp.WriteJSON(w, http.StatusCreated, result)
+		return
+	}
+	mhttp.WriteBodyJSON(w, result)
+}
@@ -18,13 +18,14 @@ func HandleContinents(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	results, err := DB.ContinentsByOptions(options)
+	results, hit, err := DB.CachedContinentsByOptions(r.Context(), options)
 	if err != nil {
-		mhttp.WriteBadRequest(w, err.Error())
+		WriteDatabaseError(w, err)
 		return
 	}
 
-	mhttp.WriteBodyJSON(w, results)
+	WriteCacheHeaders(w, hit)
+	WriteBodyFields(w, results, options.Fields)
 }
 
 func HandleContinent(w http.ResponseWriter, r *http.Request) {
@@ -35,13 +36,30 @@ func HandleContinent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := DB.ContinentByUuid(nil, c_uuid)
+	options, err := ContinentOptionsFromQuery(r)
 	if err != nil {
-		mhttp.WriteBadRequest(w, err.Error())
+		mhttp.WriteBadRequest(w, fmt.Sprintf("Invalid query: %s", err.Error()))
 		return
 	}
 
-	mhttp.WriteBodyJSON(w, result)
+	result, hit, err := DB.CachedContinentByUuid(r.Context(), c_uuid)
+	if err != nil {
+		WriteDatabaseError(w, err)
+		return
+	}
+
+	if options.WithCountries || options.WithCities {
+		// Note: the cached continent is shared, embed in a copy
+		copied := *result
+		if err = DB.EmbedContinents(r.Context(), []*pkg_v1.Continent{&copied}, options.WithCountries, options.WithCities); err != nil {
+			WriteDatabaseError(w, err)
+			return
+		}
+		result = &copied
+	}
+
+	WriteCacheHeaders(w, hit)
+	WriteBodyFields(w, result, options.Fields)
 }
 
 func HandleCreateContinent(w http.ResponseWriter, r *http.Request) {
@@ -58,9 +76,9 @@ func HandleCreateContinent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := DB.CreateContinent(nil, continent)
+	result, err := DB.CreateContinent(r.Context(), nil, continent)
 	if err != nil {
-		mhttp.WriteBadRequest(w, err.Error())
+		WriteDatabaseError(w, err)
 		return
 	}
 
@@ -83,9 +101,9 @@ func HandleUpdateContinent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := DB.UpdateContinent(nil, continent)
+	result, err := DB.UpdateContinent(r.Context(), nil, continent)
 	if err != nil {
-		mhttp.WriteBadRequest(w, err.Error())
+		WriteDatabaseError(w, err)
 		return
 	}
 
@@ -100,11 +118,41 @@ func HandleDeleteContinent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := DB.SoftDeleteContinent(nil, query_uuid); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := DB.SoftDeleteContinent(r.Context(), nil, query_uuid); err != nil {
+		WriteDatabaseError(w, err)
 		return
 	}
 
 	// Note: return 200
 	mhttp.WriteBodyJSON(w, "")
 }
+
+// HandleUpsertContinent updates the continent with the uuid of the query or body, or
+// creates it with that uuid (201).
+func HandleUpsertContinent(w http.ResponseWriter, r *http.Request) {
+
+	continent := &pkg_v1.Continent{}
+	if err := mhttp.ReadBodyJSON(r, &continent); err != nil {
+		mhttp.WriteBadRequest(w, err.Error())
+		return
+	}
+
+	uuid, err := ResourceUuid(r, continent.Uuid)
+	if err != nil {
+		mhttp.WriteBadRequest(w, err.Error())
+		return
+	}
+	continent.Uuid = uuid
+
+	result, created, err := DB.UpsertContinent(r.Context(), nil, continent)
+	if err != nil {
+		WriteDatabaseError(w, err)
+		return
+	}
+
+	if created {
+		mhttp.WriteJSON(w, http.StatusCreated, result)
+		return
+	}
+	mhttp.WriteBodyJSON(w, result)
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 
+	"github.com/gorilla/mux"
 	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
 	"github.com/nhht77/earth-rest-api/server/pkg/mhttp"
 	"github.com/nhht77/earth-rest-api/server/pkg/muuid"
@@ -18,13 +20,14 @@ func HandleCountries(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	results, err := DB.CountriesByOptions(options)
+	results, hit, err := DB.CachedCountriesByOptions(r.Context(), options)
 	if err != nil {
-		mhttp.WriteBadRequest(w, err.Error())
+		WriteDatabaseError(w, err)
 		return
 	}
 
-	mhttp.WriteBodyJSON(w, results)
+	WriteCacheHeaders(w, hit)
+	WriteBodyFields(w, results, options.Fields)
 }
 
 func HandleCountry(w http.ResponseWriter, r *http.Request) {
@@ -35,13 +38,55 @@ func HandleCountry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := DB.CountryByUuid(nil, c_uuid)
+	options, err := CountryOptionsFromQuery(r)
 	if err != nil {
-		mhttp.WriteBadRequest(w, err.Error())
+		mhttp.WriteBadRequest(w, fmt.Sprintf("Invalid query: %s", err.Error()))
 		return
 	}
 
-	mhttp.WriteBodyJSON(w, result)
+	result, hit, err := DB.CachedCountryByUuid(r.Context(), c_uuid)
+	if err != nil {
+		WriteDatabaseError(w, err)
+		return
+	}
+
+	if result, err = expandCountry(r.Context(), result, options); err != nil {
+		WriteDatabaseError(w, err)
+		return
+	}
+
+	WriteCacheHeaders(w, hit)
+	WriteBodyFields(w, result, options.Fields)
+}
+
+// expandCountry returns a copy of the cached country with the relations of
+// options.
+func expandCountry(ctx context.Context, country *pkg_v1.Country, options CountryQueryOptions) (*pkg_v1.Country, error) {
+	if !options.WithContinent && !options.WithCities {
+		return country, nil
+	}
+
+	copied := *country
+	if copied.Details != nil {
+		details := *copied.Details
+		copied.Details = &details
+	} else {
+		copied.Details = &pkg_v1.CountryDetails{}
+	}
+
+	if options.WithContinent {
+		continent, _, err := DB.CachedContinentByUuid(ctx, copied.ContinentUuid.String())
+		if err != nil {
+			return nil, err
+		}
+		copied.Details.Continent = continent
+	}
+	if options.WithCities {
+		if err := DB.EmbedCountries(ctx, pkg_v1.CountryList{&copied}); err != nil {
+			return nil, err
+		}
+	}
+	return &copied, nil
 }
 
 func HandleCreateCountry(w http.ResponseWriter, r *http.Request) {
@@ -58,9 +103,9 @@ func HandleCreateCountry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := DB.CreateCountry(nil, country)
+	result, err := DB.CreateCountry(r.Context(), nil, country)
 	if err != nil {
-		mhttp.WriteBadRequest(w, err.Error())
+		WriteDatabaseError(w, err)
 		return
 	}
 
@@ -83,9 +128,9 @@ func HandleUpdateCountry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := DB.UpdateCountry(nil, continent)
+	result, err := DB.UpdateCountry(r.Context(), nil, continent)
 	if err != nil {
-		mhttp.WriteBadRequest(w, err.Error())
+		WriteDatabaseError(w, err)
 		return
 	}
 
@@ -100,11 +145,142 @@ func HandleDeleteCountry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := DB.SoftDeleteCountry(nil, query_uuid); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := DB.SoftDeleteCountry(r.Context(), nil, query_uuid); err != nil {
+		WriteDatabaseError(w, err)
 		return
 	}
 
 	// Note: return 200
 	mhttp.WriteBodyJSON(w, "")
 }
+
+// HandleUpsertCountry updates the country with the uuid of the query or body, or
+// creates it with that uuid (201).
+func HandleUpsertCountry(w http.ResponseWriter, r *http.Request) {
+
+	country := &pkg_v1.Country{}
+	if err := mhttp.ReadBodyJSON(r, &country); err != nil {
+		mhttp.WriteBadRequest(w, err.Error())
+		return
+	}
+
+	uuid, err := ResourceUuid(r, country.Uuid)
+	if err != nil {
+		mhttp.WriteBadRequest(w, err.Error())
+		return
+	}
+	country.Uuid = uuid
+
+	result, created, err := DB.UpsertCountry(r.Context(), nil, country)
+	if err != nil {
+		WriteDatabaseError(w, err)
+		return
+	}
+
+	if created {
+		mhttp.WriteJSON(w, http.StatusCreated, result)
+		return
+	}
+	mhttp.WriteBodyJSON(w, result)
+}
+
+func HandleCountrySummaries(w http.ResponseWriter, r *http.Request) {
+
+	options, err := CountryOptionsFromQuery(r)
+	if err != nil {
+		mhttp.WriteBadRequest(w, fmt.Sprintf("Invalid query: %s", err.Error()))
+		return
+	}
+
+	countries, _, err := DB.CachedCountriesByOptions(r.Context(), options)
+	if err != nil {
+		WriteDatabaseError(w, err)
+		return
+	}
+
+	results, err := DB.CountrySummaries(r.Context(), countries)
+	if err != nil {
+		WriteDatabaseError(w, err)
+		return
+	}
+
+	mhttp.WriteBodyJSON(w, results)
+}
+
+func HandleCountrySummary(w http.ResponseWriter, r *http.Request) {
+
+	c_uuid := mux.Vars(r)["uuid"]
+	if _, err := muuid.UUIDFromString(c_uuid); err != nil {
+		mhttp.WriteBadRequest(w, err.Error())
+		return
+	}
+
+	country, _, err := DB.CachedCountryByUuid(r.Context(), c_uuid)
+	if err != nil {
+		WriteDatabaseError(w, err)
+		return
+	}
+
+	results, err := DB.CountrySummaries(r.Context(), pkg_v1.CountryList{country})
+	if err != nil {
+		WriteDatabaseError(w, err)
+		return
+	}
+
+	mhttp.WriteBodyJSON(w, results[0])
+}
+
+// HandleCountrySeries lists the yearly series of a country between the
+// from & to years.
+func HandleCountrySeries(w http.ResponseWriter, r *http.Request) {
+
+	c_uuid, err := muuid.UUIDFromString(mux.Vars(r)["uuid"])
+	if err != nil {
+		mhttp.WriteBadRequest(w, err.Error())
+		return
+	}
+
+	options, err := CountrySeriesOptionsFromQuery(r)
+	if err != nil {
+		mhttp.WriteBadRequest(w, fmt.Sprintf("Invalid query: %s", err.Error()))
+		return
+	}
+
+	results, err := DB.CountrySeriesByUuid(r.Context(), nil, c_uuid, options)
+	if err != nil {
+		WriteDatabaseError(w, err)
+		return
+	}
+
+	mhttp.WriteBodyJSON(w, results)
+}
+
+// HandleUpsertCountrySeries writes years of a country's series, the body is
+// a list of years.
+func HandleUpsertCountrySeries(w http.ResponseWriter, r *http.Request) {
+
+	c_uuid, err := muuid.UUIDFromString(mux.Vars(r)["uuid"])
+	if err != nil {
+		mhttp.WriteBadRequest(w, err.Error())
+		return
+	}
+
+	series := pkg_v1.CountrySeries{}
+	if err := mhttp.ReadBodyJSON(r, &series); err != nil {
+		mhttp.WriteBadRequest(w, err.Error())
+		return
+	}
+
+	if err := series.Validate(); err != nil {
+		mhttp.WriteBadRequest(w, err.Error())
+		return
+	}
+
+	results, err := DB.UpsertCountrySeries(r.Context(), nil, c_uuid, series)
+	if err != nil {
+		WriteDatabaseError(w, err)
+		return
+	}
+
+	mhttp.WriteBodyJSON(w, results)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/nhht77/earth-rest-api/server/pkg/mhttp"
+)
+
+const (
+	HealthStatus_Ok   = "ok"
+	HealthStatus_Fail = "fail"
+
+	ReadinessPingTimeout = 2 * time.Second
+)
+
+var ServerStarted = time.Now()
+
+type HealthCheck struct {
+	Name      string      `json:"name"`
+	Status    string      `json:"status"`
+	LatencyMs float64     `json:"latency_ms"`
+	Error     string      `json:"error,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+type HealthReport struct {
+	Status string         `json:"status"`
+	Checks []*HealthCheck `json:"checks"`
+}
+
+func (report *HealthReport) Run(name string, check func() (interface{}, error)) {
+	var (
+		started      = time.Now()
+		details, err = check()
+		result       = &HealthCheck{
+			Name:      name,
+			Status:    HealthStatus_Ok,
+			LatencyMs: float64(time.Since(started).Microseconds()) / 1000,
+			Details:   details,
+		}
+	)
+
+	if err != nil {
+		result.Status = HealthStatus_Fail
+		result.Error = err.Error()
+		report.Status = HealthStatus_Fail
+	}
+
+	report.Checks = append(report.Checks, result)
+}
+
+func (report *HealthReport) Write(w http.ResponseWriter) {
+	if report.Status != HealthStatus_Ok {
+		mhttp.WriteServiceUnavailable(w, report)
+		return
+	}
+	mhttp.WriteBodyJSON(w, report)
+}
+
+// Liveness: the process is up and serving requests.
+func HandleHealthz(w http.ResponseWriter, r *http.Request) {
+	report := &HealthReport{Status: HealthStatus_Ok}
+
+	report.Run("process", func() (interface{}, error) {
+		return map[string]string{"uptime": time.Since(ServerStarted).Round(time.Second).String()}, nil
+	})
+
+	report.Write(w)
+}
+
+// Readiness: the database is reachable, migrated and the pool is not exhausted.
+func HandleReadyz(w http.ResponseWriter, r *http.Request) {
+	report := &HealthReport{Status: HealthStatus_Ok}
+
+	report.Run("database", func() (interface{}, error) {
+		return nil, DB.PingTimeout(ReadinessPingTimeout)
+	})
+
+	// Note: reads fall back to the primary, unhealthy replicas do not fail readiness
+	report.Run("database_replicas", func() (interface{}, error) {
+		return DB.ReplicaStatus(), nil
+	})
+
+	report.Run("migrations", func() (interface{}, error) {
+		migrated, migrated_at := DB.MigrationStatus()
+		if !migrated {
+			return nil, errors.New("migrations have not completed")
+		}
+		return map[string]time.Time{"completed": migrated_at}, nil
+	})
+
+	report.Run("database_pool", func() (interface{}, error) {
+		var (
+			stats      = DB.PoolStats()
+			saturation = 0.0
+		)
+		if stats.MaxOpenConnections > 0 {
+			saturation = float64(stats.InUse) / float64(stats.MaxOpenConnections)
+		}
+
+		details := map[string]interface{}{
+			"open":                 stats.OpenConnections,
+			"in_use":               stats.InUse,
+			"idle":                 stats.Idle,
+			"max_open_connections": stats.MaxOpenConnections,
+			"wait_count":           stats.WaitCount,
+			"saturation":           saturation,
+		}
+		if saturation >= 1 {
+			return details, fmt.Errorf("pool saturated: %d/%d connections in use", stats.InUse, stats.MaxOpenConnections)
+		}
+		return details, nil
+	})
+
+	report.Write(w)
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/nhht77/earth-rest-api/server/pkg/mhttp"
+)
+
+func HandleStats(w http.ResponseWriter, r *http.Request) {
+
+	options, err := StatsOptionsFromQuery(r)
+	if err != nil {
+		mhttp.WriteBadRequest(w, fmt.Sprintf("Invalid query: %s", err.Error()))
+		return
+	}
+
+	stats, err := DB.Stats(r.Context(), options)
+	if err != nil {
+		WriteDatabaseError(w, err)
+		return
+	}
+
+	mhttp.WriteBodyJSON(w, stats)
+}
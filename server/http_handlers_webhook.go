@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+	"github.com/nhht77/earth-rest-api/server/pkg/mhttp"
+	muuid "github.com/nhht77/earth-rest-api/server/pkg/muuid"
+)
+
+func HandleWebhooks(w http.ResponseWriter, r *http.Request) {
+
+	results, err := DB.WebhookSubscriptions(r.Context())
+	if err != nil {
+		WriteDatabaseError(w, err)
+		return
+	}
+
+	mhttp.WriteBodyJSON(w, results)
+}
+
+func HandleWebhook(w http.ResponseWriter, r *http.Request) {
+
+	w_uuid := mhttp.Query(r, "uuid")
+	if _, err := muuid.UUIDFromString(w_uuid); err != nil {
+		mhttp.WriteBadRequest(w, err.Error())
+		return
+	}
+
+	result, err := DB.WebhookSubscriptionByUuid(r.Context(), nil, w_uuid)
+	if err != nil {
+		WriteDatabaseError(w, err)
+		return
+	}
+
+	mhttp.WriteBodyJSON(w, result)
+}
+
+func HandleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+
+	subscription := &pkg_v1.WebhookSubscription{}
+	if err := mhttp.ReadBodyJSON(r, &subscription); err != nil {
+		mhttp.WriteBadRequest(w, err.Error())
+		return
+	}
+
+	if err := subscription.ValidateCreate(); err != nil {
+		mhttp.WriteBadRequest(w, err.Error())
+		return
+	}
+	if err := Webhooks.CheckURL(r.Context(), subscription.URL); err != nil {
+		mhttp.WriteBadRequest(w, fmt.Sprintf("Invalid webhook url: %s", err.Error()))
+		return
+	}
+
+	result, err := DB.CreateWebhookSubscription(r.Context(), nil, subscription)
+	if err != nil {
+		WriteDatabaseError(w, err)
+		return
+	}
+
+	mhttp.WriteBodyJSON(w, result)
+}
+
+func HandleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+
+	subscription := &pkg_v1.WebhookSubscription{}
+	if err := mhttp.ReadBodyJSON(r, &subscription); err != nil {
+		mhttp.WriteBadRequest(w, err.Error())
+		return
+	}
+	if !muuid.UUIDValid(subscription.Uuid) {
+		mhttp.WriteBadRequest(w, errors.New("Invalid uuid").Error())
+		return
+	}
+	if err := subscription.ValidateUpdate(); err != nil {
+		mhttp.WriteBadRequest(w, err.Error())
+		return
+	}
+	if err := Webhooks.CheckURL(r.Context(), subscription.URL); err != nil {
+		mhttp.WriteBadRequest(w, fmt.Sprintf("Invalid webhook url: %s", err.Error()))
+		return
+	}
+
+	result, err := DB.UpdateWebhookSubscription(r.Context(), nil, subscription)
+	if err != nil {
+		WriteDatabaseError(w, err)
+		return
+	}
+
+	mhttp.WriteBodyJSON(w, result)
+}
+
+func HandleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	var query_uuid = mhttp.Query(r, "uuid")
+
+	if _, err := muuid.UUIDFromString(query_uuid); err != nil {
+		mhttp.WriteBadRequest(w, err.Error())
+		return
+	}
+
+	if err := DB.SoftDeleteWebhookSubscription(r.Context(), query_uuid); err != nil {
+		WriteDatabaseError(w, err)
+		return
+	}
+
+	// Note: return 200
+	mhttp.WriteBodyJSON(w, "")
+}
+
+// HandleWebhookDeliveries lists a subscription's newest deliveries and their
+// attempts, `states=2` for the dead letters.
+func HandleWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+
+	options, err := WebhookDeliveryOptionsFromQuery(r)
+	if err != nil {
+		mhttp.WriteBadRequest(w, fmt.Sprintf("Invalid query: %s", err.Error()))
+		return
+	}
+
+	results, err := DB.WebhookDeliveriesByOptions(r.Context(), options)
+	if err != nil {
+		WriteDatabaseError(w, err)
+		return
+	}
+
+	mhttp.WriteBodyJSON(w, results)
+}
+
+func HandleRetryWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	var query_uuid = mhttp.Query(r, "uuid")
+
+	if _, err := muuid.UUIDFromString(query_uuid); err != nil {
+		mhttp.WriteBadRequest(w, err.Error())
+		return
+	}
+
+	if err := DB.RetryWebhookDelivery(r.Context(), query_uuid); err != nil {
+		WriteDatabaseError(w, err)
+		return
+	}
+	Webhooks.Wake()
+
+	mhttp.WriteBodyJSON(w, "")
+}
@@ -1,51 +1,356 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/nhht77/earth-rest-api/server/pkg/mhttp"
+	"github.com/nhht77/earth-rest-api/server/pkg/mstring"
+	"github.com/nhht77/earth-rest-api/server/pkg/muuid"
+	"google.golang.org/grpc"
 )
 
-func RunHTTP() error {
+func NewRouter() *mux.Router {
 	router := mux.NewRouter().StrictSlash(true)
 	router.Use(MonitorHandle)
+	router.Use(ReadYourWritesHandle)
 
+	router.HandleFunc("/api/openapi.json", HandleOpenAPI).Methods("GET")
 	router.HandleFunc("/api/v1/ping", Ping).Methods("GET")
+	router.HandleFunc("/healthz", HandleHealthz).Methods("GET")
+	router.HandleFunc("/readyz", HandleReadyz).Methods("GET")
+	router.HandleFunc("/metrics", HandleMetrics).Methods("GET")
+
+	router.HandleFunc("/api/v1/changes", HandleChanges).Methods("GET")
+	router.HandleFunc("/api/v1/stats", HandleStats).Methods("GET")
+	router.HandleFunc("/graphql", HandleGraphQL).Methods("POST")
 
 	router.HandleFunc("/api/v1/continents", HandleContinents).Methods("GET")
 	router.HandleFunc("/api/v1/continent", HandleContinent).Methods("GET")
-	router.HandleFunc("/api/v1/continent/create", HandleCreateContinent).Methods("POST")
+	router.HandleFunc("/api/v1/continent", HandleUpsertContinent).Methods("PUT")
+	router.Handle("/api/v1/continent/create", IdempotencyHandle(HandleCreateContinent)).Methods("POST")
 	router.HandleFunc("/api/v1/continent/update", HandleUpdateContinent).Methods("PUT")
 	router.HandleFunc("/api/v1/continent/delete", HandleDeleteContinent).Methods("DELETE")
 
 	router.HandleFunc("/api/v1/countries", HandleCountries).Methods("GET")
+	router.HandleFunc("/api/v1/countries/summary", HandleCountrySummaries).Methods("GET")
+	router.HandleFunc("/api/v1/countries/{uuid}/summary", HandleCountrySummary).Methods("GET")
+	router.HandleFunc("/api/v1/countries/{uuid}/series", HandleCountrySeries).Methods("GET")
+	router.HandleFunc("/api/v1/countries/{uuid}/series", HandleUpsertCountrySeries).Methods("PUT")
 	router.HandleFunc("/api/v1/country", HandleCountry).Methods("GET")
-	router.HandleFunc("/api/v1/country/create", HandleCreateCountry).Methods("POST")
+	router.HandleFunc("/api/v1/country", HandleUpsertCountry).Methods("PUT")
+	router.Handle("/api/v1/country/create", IdempotencyHandle(HandleCreateCountry)).Methods("POST")
 	router.HandleFunc("/api/v1/country/update", HandleUpdateCountry).Methods("PUT")
 	router.HandleFunc("/api/v1/country/delete", HandleDeleteCountry).Methods("DELETE")
 
 	router.HandleFunc("/api/v1/cities", HandleCities).Methods("GET")
 	router.HandleFunc("/api/v1/city", HandleCity).Methods("GET")
-	router.HandleFunc("/api/v1/city/create", HandleCreateCity).Methods("POST")
+	router.HandleFunc("/api/v1/city", HandleUpsertCity).Methods("PUT")
+	router.Handle("/api/v1/city/create", IdempotencyHandle(HandleCreateCity)).Methods("POST")
 	router.HandleFunc("/api/v1/city/update", HandleUpdateCity).Methods("PUT")
 	router.HandleFunc("/api/v1/city/delete", HandleDeleteCity).Methods("DELETE")
 
-	return ListenAndServe(":8080", router)
+	router.HandleFunc("/api/v1/webhooks", HandleWebhooks).Methods("GET")
+	router.HandleFunc("/api/v1/webhook", HandleWebhook).Methods("GET")
+	// Note: no Idempotency-Key, a stored response would replay the webhook secret
+	router.HandleFunc("/api/v1/webhook/create", HandleCreateWebhook).Methods("POST")
+	router.HandleFunc("/api/v1/webhook/update", HandleUpdateWebhook).Methods("PUT")
+	router.HandleFunc("/api/v1/webhook/delete", HandleDeleteWebhook).Methods("DELETE")
+	router.HandleFunc("/api/v1/webhook/deliveries", HandleWebhookDeliveries).Methods("GET")
+	router.HandleFunc("/api/v1/webhook/delivery/retry", HandleRetryWebhookDelivery).Methods("POST")
+
+	return router
+}
+
+// NewHTTPHandler wraps the router with middlewares that must also run for
+// unmatched routes & methods, such as CORS preflight requests.
+func NewHTTPHandler() http.Handler {
+	return CORSHandle(RateLimitHandle(NewRouter()))
 }
 
-func ListenAndServe(addr string, handler http.Handler) error {
-	Log.Infof("[http] Listen and serve at port %s", addr[1:])
-	return http.ListenAndServe(addr, handler)
+func NewHTTPServer(addr string, handler http.Handler, config *FrameworkConfig) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       time.Duration(config.ReadTimeout),
+		ReadHeaderTimeout: time.Duration(config.ReadHeaderTimeout),
+		WriteTimeout:      time.Duration(config.WriteTimeout),
+		IdleTimeout:       time.Duration(config.IdleTimeout),
+	}
+}
+
+// RunHTTP serves until the listener fails or SIGINT/SIGTERM is received,
+// then drains in-flight requests for at most FrameworkConfig.ShutdownTimeout.
+// The gRPC service runs on FrameworkConfig.GRPCPort next to it, when set.
+func RunHTTP() error {
+	var (
+		config = AppConfig.Framework
+
+		// cancelled when the drain deadline expires so hanging queries are aborted
+		base_ctx, cancel_base = context.WithCancel(context.Background())
+
+		server    = NewHTTPServer(":"+config.ServerPort, NewHTTPHandler(), config)
+		serve_err = make(chan error, 2)
+		signals   = make(chan os.Signal, 1)
+	)
+	defer cancel_base()
+
+	server.BaseContext = func(net.Listener) context.Context { return base_ctx }
+	// change streams never finish on their own
+	server.RegisterOnShutdown(Changes.CloseSubscribers)
+
+	if AppConfig.TLS.Enabled() {
+		tls_config, err := NewTLSConfig(AppConfig.TLS)
+		if err != nil {
+			return err
+		}
+		server.TLSConfig = tls_config
+	}
+
+	var grpc_server *grpc.Server
+	if len(config.GRPCPort) > 0 {
+		var err error
+		if grpc_server, err = NewGRPCServer(AppConfig.TLS); err != nil {
+			return err
+		}
+		defer grpc_server.Stop()
+	}
+
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	go func() {
+		serve_err <- ListenAndServe(server)
+	}()
+	if grpc_server != nil {
+		go func() {
+			serve_err <- ServeGRPC(grpc_server, ":"+config.GRPCPort)
+		}()
+	}
+
+wait:
+	for {
+		select {
+		case err := <-serve_err:
+			return err
+		case sig := <-signals:
+			if sig == syscall.SIGHUP {
+				ReloadConfig()
+				continue
+			}
+			Log.Infof("[http] %s received, draining in-flight requests", sig)
+			break wait
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.ShutdownTimeout))
+	defer cancel()
+
+	grpc_stopped := make(chan struct{})
+	go func() {
+		if grpc_server != nil {
+			StopGRPC(ctx, grpc_server)
+		}
+		close(grpc_stopped)
+	}()
+
+	DrainHTTP(ctx, server, cancel_base)
+	<-grpc_stopped
+
+	Log.Info("[http] Server stopped")
+	return nil
+}
+
+// DrainHTTP stops accepting connections and waits for in-flight requests
+// until ctx expires, then cancels base, the BaseContext of the server, so
+// hanging queries are aborted and closes the remaining connections.
+func DrainHTTP(ctx context.Context, server *http.Server, cancel_base context.CancelFunc) {
+	if err := server.Shutdown(ctx); err != nil {
+		Log.Warnf("[http] Shutdown deadline exceeded, closing remaining connections: %s", err.Error())
+		cancel_base()
+		server.Close()
+	}
+}
+
+func NewTLSConfig(config TLSConfig) (*tls.Config, error) {
+	reloader, err := mhttp.NewCertReloader(config.CertFile, config.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	client_auth, err := config.ClientAuthType()
+	if err != nil {
+		return nil, err
+	}
+
+	tls_config := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+		ClientAuth:     client_auth,
+	}
+
+	if len(config.ClientCAFile) > 0 {
+		ca_b, err := ioutil.ReadFile(config.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		tls_config.ClientCAs = x509.NewCertPool()
+		if !tls_config.ClientCAs.AppendCertsFromPEM(ca_b) {
+			return nil, fmt.Errorf("%s: no certificates found", config.ClientCAFile)
+		}
+	}
+
+	return tls_config, nil
+}
+
+func ListenAndServe(server *http.Server) error {
+	var err error
+	if server.TLSConfig != nil {
+		Log.Infof("[http] Listen and serve TLS at %s (client auth %s)", server.Addr, server.TLSConfig.ClientAuth)
+		// Note: certificates come from TLSConfig.GetCertificate
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		Log.Infof("[http] Listen and serve at %s", server.Addr)
+		err = server.ListenAndServe()
+	}
+
+	if err != http.ErrServerClosed {
+		return err
+	}
+	return nil
 }
 
 func MonitorHandle(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			started  = time.Now()
+			recorder = &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		)
 		Log.Infof("[http] %s %s", r.Method, r.URL)
+		h.ServeHTTP(recorder, r)
+		ObserveHTTP(r, recorder.status, started)
+	})
+}
+
+// ReadYourWritesHandle sends the request's reads to the primary when the
+// client sets X-Read-Your-Writes: true, skipping replica lag.
+func ReadYourWritesHandle(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if value, _ := strconv.ParseBool(r.Header.Get(HeaderReadYourWrites)); value {
+			r = r.WithContext(WithPrimary(r.Context()))
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func RateLimitHandle(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !RateLimiter.Allow(mhttp.ClientIP(r)) {
+			w.Header().Set("Retry-After", "1")
+			mhttp.WriteTooManyRequests(w, "Rate limit exceeded")
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func CORSHandle(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			cors   = CurrentCORS()
+			origin = r.Header.Get("Origin")
+		)
+
+		if len(origin) == 0 || len(cors.AllowedOrigins) == 0 {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		if !mstring.SliceContains(cors.AllowedOrigins, "*") && !mstring.SliceContains(cors.AllowedOrigins, origin) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+
+		// preflight
+		if r.Method == http.MethodOptions && len(r.Header.Get("Access-Control-Request-Method")) > 0 {
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(cors.AllowedMethods, ", "))
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(cors.AllowedHeaders, ", "))
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(time.Duration(cors.MaxAge).Seconds())))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
 		h.ServeHTTP(w, r)
 	})
 }
 
+// WriteDatabaseError answers 504 when the query hit its deadline or the
+// statement timeout, 404 for a missing row, 409 on a uuid conflict, 400 otherwise.
+func WriteDatabaseError(w http.ResponseWriter, err error) error {
+	if DatabaseNoResults(err) {
+		return mhttp.WriteNotFound(w, err.Error())
+	}
+	if DatabaseTimeout(err) {
+		return mhttp.WriteGatewayTimeout(w, err.Error())
+	}
+	if DatabaseConflict(err) {
+		return mhttp.WriteConflict(w, err.Error())
+	}
+	return mhttp.WriteBadRequest(w, err.Error())
+}
+
+// PageFromQuery reads `limit` (0 to ListMaxLimit, 0 for every row) and `offset`.
+func PageFromQuery(r *http.Request) (limit int, offset int, err error) {
+	if value := mhttp.Query(r, "limit"); len(value) > 0 {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 0 || limit > ListMaxLimit {
+			return 0, 0, fmt.Errorf("limit must be between 0 and %d", ListMaxLimit)
+		}
+	}
+	if value := mhttp.Query(r, "offset"); len(value) > 0 {
+		if offset, err = strconv.Atoi(value); err != nil || offset < 0 {
+			return 0, 0, errors.New("offset must be 0 or more")
+		}
+	}
+	return limit, offset, nil
+}
+
+// ResourceUuid returns the uuid of a PUT on a resource: the `uuid` query
+// parameter, the body uuid, or both when they are equal.
+func ResourceUuid(r *http.Request, body_uuid muuid.UUID) (muuid.UUID, error) {
+	uuid := body_uuid
+
+	if query_uuid := mhttp.Query(r, "uuid"); len(query_uuid) > 0 {
+		parsed, err := muuid.UUIDFromString(query_uuid)
+		if err != nil {
+			return uuid, err
+		}
+		if muuid.UUIDValid(uuid) && uuid != parsed {
+			return uuid, errors.New("uuid in query and body differ")
+		}
+		uuid = parsed
+	}
+
+	if err := muuid.UUIDCheck(uuid); err != nil {
+		return uuid, fmt.Errorf("Invalid uuid: %s", err.Error())
+	}
+	return uuid, nil
+}
+
 func Ping(w http.ResponseWriter, r *http.Request) {
 	mhttp.WriteBodyJSON(w, "")
 }
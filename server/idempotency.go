@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/nhht77/earth-rest-api/server/pkg/mhttp"
+)
+
+const (
+	HeaderIdempotencyKey      = "Idempotency-Key"
+	HeaderIdempotentReplayed  = "Idempotent-Replayed"
+	IdempotencyKeyMaxLength   = 255
+	IdempotencyCleanupPeriod  = time.Hour
+	idempotencyReleaseTimeout = 5 * time.Second
+
+	// a claim left running longer is taken over by the next request with the
+	// key, well above WriteTimeout so a live request is not run twice
+	IdempotencyClaimLease = 5 * time.Minute
+)
+
+var idempotencyStop chan struct{}
+
+// responseCapture keeps a copy of the response written through it.
+type responseCapture struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (capture *responseCapture) WriteHeader(status int) {
+	capture.status = status
+	capture.ResponseWriter.WriteHeader(status)
+}
+
+func (capture *responseCapture) Write(b []byte) (int, error) {
+	capture.body.Write(b)
+	return capture.ResponseWriter.Write(b)
+}
+
+// IdempotencyHandle replays the stored response when a request is retried
+// by the same caller, see IdempotencyScope, with the same Idempotency-Key and
+// body within IdempotencyConfig.Window.
+// The same key with another body answers 422, a key whose first request is
+// still running 409. Failed (5xx) and panicking requests are not stored so
+// they can be retried.
+func IdempotencyHandle(h http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(HeaderIdempotencyKey)
+		if len(key) == 0 {
+			h(w, r)
+			return
+		}
+		if len(key) > IdempotencyKeyMaxLength {
+			mhttp.WriteBadRequest(w, fmt.Sprintf("%s longer than %d characters", HeaderIdempotencyKey, IdempotencyKeyMaxLength))
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			mhttp.WriteBadRequest(w, err.Error())
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		var (
+			hash_b = sha256.Sum256(body)
+			hash   = hex.EncodeToString(hash_b[:])
+			window = time.Duration(AppConfig.Idempotency.Window)
+		)
+
+		claimed, record, err := DB.ClaimIdempotencyKey(WithPrimary(r.Context()), key, r.Method, r.URL.Path, IdempotencyScope(r), hash, window, IdempotencyClaimLease)
+		if err != nil {
+			WriteDatabaseError(w, err)
+			return
+		}
+
+		if !claimed {
+			switch {
+			case record.RequestHash != hash:
+				mhttp.WriteUnprocessableEntity(w, fmt.Sprintf("%s was already used with a different request body", HeaderIdempotencyKey))
+			case record.StatusCode == 0:
+				w.Header().Set("Retry-After", "1")
+				mhttp.WriteConflict(w, fmt.Sprintf("A request with this %s is in progress", HeaderIdempotencyKey))
+			default:
+				if len(record.ContentType) > 0 {
+					w.Header().Set("Content-Type", record.ContentType)
+				}
+				w.Header().Set(HeaderIdempotentReplayed, "true")
+				w.WriteHeader(record.StatusCode)
+				w.Write([]byte(record.Response))
+			}
+			return
+		}
+
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				finishIdempotencyKey(key, record, nil)
+				panic(recovered)
+			}
+		}()
+
+		capture := &responseCapture{ResponseWriter: w, status: http.StatusOK}
+		h(capture, r)
+
+		finishIdempotencyKey(key, record, capture)
+	})
+}
+
+// IdempotencyScope identifies the caller a key belongs to, as a SHA-256 of
+// its client certificate, else of its Authorization header, else of its
+// address, so a key cannot replay the response of another caller.
+func IdempotencyScope(r *http.Request) string {
+	var identity string
+	switch {
+	case r.TLS != nil && len(r.TLS.PeerCertificates) > 0:
+		identity = "certificate:" + string(r.TLS.PeerCertificates[0].Raw)
+	case len(r.Header.Get("Authorization")) > 0:
+		identity = "authorization:" + r.Header.Get("Authorization")
+	default:
+		identity = "address:" + mhttp.ClientIP(r)
+	}
+
+	hash := sha256.Sum256([]byte(identity))
+	return hex.EncodeToString(hash[:])
+}
+
+// finishIdempotencyKey stores the captured response of a claimed key, or
+// releases the key when the request failed or panicked (capture is nil).
+func finishIdempotencyKey(key string, record *IdempotencyRecord, capture *responseCapture) {
+
+	// Note: the request context may be cancelled by now
+	ctx, cancel := context.WithTimeout(context.Background(), idempotencyReleaseTimeout)
+	defer cancel()
+
+	var err error
+	if capture == nil || capture.status >= http.StatusInternalServerError {
+		err = DB.ReleaseIdempotencyKey(ctx, record)
+	} else {
+		err = DB.CompleteIdempotencyKey(ctx, record, capture.status, capture.Header().Get("Content-Type"), capture.body.String())
+	}
+	if err != nil {
+		Log.Warnf("[http] %s %s not stored: %s", HeaderIdempotencyKey, key, err.Error())
+	}
+}
+
+// StartIdempotencyCleanup deletes expired keys every IdempotencyCleanupPeriod.
+func StartIdempotencyCleanup(window time.Duration) {
+	idempotencyStop = make(chan struct{})
+
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(IdempotencyCleanupPeriod)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				DB.DeleteExpiredIdempotencyKeys(context.Background(), window)
+			}
+		}
+	}(idempotencyStop)
+}
+
+func StopIdempotencyCleanup() {
+	if idempotencyStop != nil {
+		close(idempotencyStop)
+		idempotencyStop = nil
+	}
+}
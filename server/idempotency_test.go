@@ -0,0 +1,200 @@
+package main_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	main "github.com/nhht77/earth-rest-api/server"
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+	"github.com/nhht77/earth-rest-api/server/pkg/mhttp"
+	muuid "github.com/nhht77/earth-rest-api/server/pkg/muuid"
+)
+
+func postWithIdempotencyKey(router http.Handler, key string, body interface{}) *httptest.ResponseRecorder {
+	body_b, _ := json.Marshal(body)
+
+	r := httptest.NewRequest("POST", "/api/v1/continent/create", bytes.NewReader(body_b))
+	r.Header.Set(main.HeaderIdempotencyKey, key)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func TestIdempotencyKeyReplaysCreate(t *testing.T) {
+	RequireDB(t)
+
+	main.DB = DB
+	main.AppConfig = AppConfig
+
+	var (
+		router    = main.NewRouter()
+		key       = muuid.NewUUID().String()
+		continent = &pkg_v1.Continent{
+			Name:      "Antarctica",
+			Type:      pkg_v1.ContinentType_Antarctica,
+			AreaByKm2: 14200000,
+			Creator:   &pkg_v1.UserMinimal{Email: "idempotency@earth.test", Name: "idempotency"},
+		}
+	)
+
+	first := postWithIdempotencyKey(router, key, continent)
+	if first.Code != http.StatusOK {
+		t.Fatalf("create answered %d: %s", first.Code, first.Body.String())
+	}
+	created := &pkg_v1.Continent{}
+	json.Unmarshal(first.Body.Bytes(), created)
+	defer DB.SoftDeleteContinent(context.Background(), nil, created.Uuid.String())
+
+	retry := postWithIdempotencyKey(router, key, continent)
+	if retry.Code != http.StatusOK || retry.Header().Get(main.HeaderIdempotentReplayed) != "true" {
+		t.Fatalf("retry answered %d replayed=%q", retry.Code, retry.Header().Get(main.HeaderIdempotentReplayed))
+	}
+	if retry.Body.String() != first.Body.String() {
+		t.Errorf("replayed body differs:\n%s\n%s", retry.Body.String(), first.Body.String())
+	}
+
+	continent.Name = "Antarctica 2"
+	if changed := postWithIdempotencyKey(router, key, continent); changed.Code != http.StatusUnprocessableEntity {
+		t.Errorf("same key with another body answered %d, expected 422", changed.Code)
+	}
+}
+
+func TestIdempotencyStaleClaimTakenOver(t *testing.T) {
+	RequireDB(t)
+
+	main.DB = DB
+	main.AppConfig = AppConfig
+
+	var (
+		ctx       = context.Background()
+		router    = main.NewRouter()
+		key       = muuid.NewUUID().String()
+		window    = time.Duration(AppConfig.Idempotency.Window)
+		continent = &pkg_v1.Continent{
+			Name:      "North America",
+			Type:      pkg_v1.ContinentType_North_America,
+			AreaByKm2: 24709000,
+			Creator:   &pkg_v1.UserMinimal{Email: "idempotency@earth.test", Name: "idempotency"},
+		}
+		body_b, _ = json.Marshal(continent)
+		hash_b    = sha256.Sum256(body_b)
+		hash      = hex.EncodeToString(hash_b[:])
+	)
+
+	// a first request that claimed the key and never finished
+	scope := main.IdempotencyScope(httptest.NewRequest("POST", "/api/v1/continent/create", nil))
+	claimed, stale, err := DB.ClaimIdempotencyKey(ctx, key, "POST", "/api/v1/continent/create", scope, hash, window, main.IdempotencyClaimLease)
+	if err != nil || !claimed {
+		t.Fatalf("claim = %t, %v", claimed, err)
+	}
+
+	if running := postWithIdempotencyKey(router, key, continent); running.Code != http.StatusConflict {
+		t.Fatalf("key within its lease answered %d, expected 409", running.Code)
+	}
+
+	if _, err := DB.Exec(ctx, nil, fmt.Sprintf(
+		`UPDATE idempotency_key SET claimed_at = NOW() - interval '%d milliseconds' WHERE index = %d`,
+		(main.IdempotencyClaimLease+time.Minute).Milliseconds(),
+		stale.Index,
+	)); err != nil {
+		t.Fatal(err)
+	}
+
+	retry := postWithIdempotencyKey(router, key, continent)
+	if retry.Code != http.StatusOK || len(retry.Header().Get(main.HeaderIdempotentReplayed)) > 0 {
+		t.Fatalf("key past its lease answered %d replayed=%q: %s", retry.Code, retry.Header().Get(main.HeaderIdempotentReplayed), retry.Body.String())
+	}
+	created := &pkg_v1.Continent{}
+	json.Unmarshal(retry.Body.Bytes(), created)
+	defer DB.SoftDeleteContinent(ctx, nil, created.Uuid.String())
+
+	// the stale request finishing late does not replace the stored response
+	if err := DB.CompleteIdempotencyKey(ctx, stale, http.StatusTeapot, "text/plain", "stale"); err != nil {
+		t.Fatal(err)
+	}
+	replay := postWithIdempotencyKey(router, key, continent)
+	if replay.Code != http.StatusOK || replay.Body.String() != retry.Body.String() {
+		t.Errorf("replay answered %d: %s", replay.Code, replay.Body.String())
+	}
+}
+
+func TestIdempotencyPanicReleasesKey(t *testing.T) {
+	RequireDB(t)
+
+	main.DB = DB
+	main.AppConfig = AppConfig
+
+	var (
+		key     = muuid.NewUUID().String()
+		handler = main.IdempotencyHandle(func(w http.ResponseWriter, r *http.Request) {
+			panic("handler failed")
+		})
+	)
+
+	func() {
+		defer func() {
+			if recovered := recover(); recovered != "handler failed" {
+				t.Errorf("recovered %v, expected the handler panic", recovered)
+			}
+		}()
+
+		r := httptest.NewRequest("POST", "/api/v1/continent/create", strings.NewReader("{}"))
+		r.Header.Set(main.HeaderIdempotencyKey, key)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}()
+
+	scope := main.IdempotencyScope(httptest.NewRequest("POST", "/api/v1/continent/create", nil))
+	claimed, record, err := DB.ClaimIdempotencyKey(context.Background(), key, "POST", "/api/v1/continent/create", scope, "", time.Hour, main.IdempotencyClaimLease)
+	if err != nil || !claimed {
+		t.Fatalf("key after a panic: claimed = %t, %v", claimed, err)
+	}
+	DB.ReleaseIdempotencyKey(context.Background(), record)
+}
+
+func TestIdempotencyKeyScopedToCaller(t *testing.T) {
+	RequireDB(t)
+
+	main.DB = DB
+	main.AppConfig = AppConfig
+
+	var (
+		key     = muuid.NewUUID().String()
+		calls   = 0
+		handler = main.IdempotencyHandle(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			mhttp.WriteBodyJSON(w, r.Header.Get("Authorization"))
+		})
+	)
+
+	post := func(authorization string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/api/v1/continent/create", strings.NewReader("{}"))
+		r.Header.Set(main.HeaderIdempotencyKey, key)
+		r.Header.Set("Authorization", authorization)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	post("Bearer first")
+	if other := post("Bearer second"); calls != 2 || len(other.Header().Get(main.HeaderIdempotentReplayed)) > 0 {
+		t.Fatalf("another caller with the same key ran %d times, replayed %q: %s", calls, other.Header().Get(main.HeaderIdempotentReplayed), other.Body.String())
+	}
+	if same := post("Bearer first"); calls != 2 || !strings.Contains(same.Body.String(), "Bearer first") {
+		t.Errorf("the same caller was not replayed its response: %d calls, %s", calls, same.Body.String())
+	}
+
+	if main.IdempotencyScope(httptest.NewRequest("POST", "/", nil)) == main.IdempotencyScope(&http.Request{RemoteAddr: "198.51.100.7:443"}) {
+		t.Error("callers at different addresses share a scope")
+	}
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/nhht77/earth-rest-api/server/pkg/mstring"
 	"github.com/sirupsen/logrus"
@@ -21,15 +23,22 @@ func init_resource() {
 	init_logger()
 
 	// init framework
-	init_framework(AppConfig)
-
-	AppConfig.ReadDefault()
+	init_framework()
 
 	if err := DB.Initialize(AppConfig); err != nil {
 		release_resource()
 		Log.Fatalf("Error: open connection %s", err.Error())
 		return
 	}
+
+	if err := Changes.Listen(AppConfig); err != nil {
+		release_resource()
+		Log.Fatalf("Error: listen to changes %s", err.Error())
+		return
+	}
+
+	Webhooks.Start(AppConfig.Webhook)
+	StartIdempotencyCleanup(time.Duration(AppConfig.Idempotency.Window))
 }
 
 func main() {
@@ -46,10 +55,19 @@ func main() {
 		Log.Fatalf("[mhttp] RunHTTP error %s", err.Error())
 		return
 	}
+
+	release_resource()
 }
 
 func release_resource() {
-	DB.Close()
+	Webhooks.Stop()
+	StopIdempotencyCleanup()
+	if err := Changes.Close(); err != nil {
+		Log.Warnf("[postgre] Change listener close error %s", err.Error())
+	}
+	if err := DB.Close(); err != nil {
+		Log.Warnf("[postgre] Close error %s", err.Error())
+	}
 }
 
 func init_logger() {
@@ -59,14 +77,24 @@ func init_logger() {
 	})
 }
 
-func init_framework(AppConfig *Config) {
+func init_framework() {
 
-	AppConfig.Framework = &FrameworkConfig{}
+	config, err := LoadConfig(os.Args[1:])
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	}
+	if err != nil {
+		Log.Fatalf("[config] %s", err.Error())
+		return
+	}
+	AppConfig = config
+	ApplyRuntimeConfig(AppConfig)
+	InitCache(AppConfig.Cache)
 
-	flag.BoolVar(&AppConfig.Framework.IsTestBuild, "-test-build", false, "run test environment and using test database")
-	flag.StringVar(&AppConfig.Framework.ServerPort, "-port", "8080", "server serves and listens at port")
-	flag.StringVar(&AppConfig.Framework.DatabasePort, "-database-port", "5432", "Database port")
-	flag.StringVar(&AppConfig.Framework.DatabaseHost, "-database-host", "localhost", "Database host")
+	if AppConfig.Framework.PrintConfig {
+		fmt.Println(mstring.ToJSON(AppConfig.Printable()))
+		os.Exit(0)
+	}
 
-	Log.Info("Framework Config: ", mstring.ToJSON(AppConfig.Framework))
+	Log.Info("Config: ", mstring.ToJSON(AppConfig.Printable()))
 }
@@ -1,6 +1,7 @@
 package main_test
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -18,7 +19,11 @@ var (
 	}()
 
 	DB        = &main.Database{}
-	AppConfig = &main.Config{}
+	AppConfig = main.DefaultConfig()
+
+	// DBUnavailable is set when TestMain can't reach Postgres, tests that
+	// need it skip through RequireDB
+	DBUnavailable error
 )
 
 func TestMain(m *testing.M) {
@@ -27,42 +32,47 @@ func TestMain(m *testing.M) {
 
 	err := DB.Initialize(AppConfig)
 	if err != nil {
-		Log.Fatalf("[test] DB.Initialize error %s", err)
+		Log.Warnf("[test] DB.Initialize error %s, database tests are skipped", err)
+		DBUnavailable = err
+		os.Exit(m.Run())
 	}
-	var (
-		tables = map[string]string{
-			"continent": "continent_index_seq",
-		}
-	)
-
-	ensureTableExists(tables)
+	ensureTableExists(main.TestTables)
 
 	code := m.Run()
 
-	clearTable(tables)
+	clearTable(main.TestTables)
 
 	os.Exit(code)
 }
 
-func ensureTableExists(tables map[string]string) {
+// RequireDB skips the test when TestMain could not initialize the database.
+func RequireDB(t *testing.T) {
+	t.Helper()
+	if DBUnavailable != nil {
+		t.Skipf("database unavailable: %s", DBUnavailable)
+	}
+}
+
+func ensureTableExists(tables []main.TestTable) {
 
-	for table, _ := range tables {
-		_, table_exist := DB.Query(nil, fmt.Sprintf("SELECT uuid FROM %s", table))
+	for _, iter := range tables {
+		rows, table_exist := DB.Query(context.Background(), nil, fmt.Sprintf("SELECT index FROM %s LIMIT 1", iter.Name))
 		if table_exist != nil {
 			Log.Fatal(table_exist)
 		}
+		rows.Close()
 	}
 }
 
-func clearTable(tables map[string]string) {
+func clearTable(tables []main.TestTable) {
 
-	clear_table_by_map := func(tx *sql.Tx, tables map[string]string) error {
-		for table, sequence := range tables {
-			_, err := DB.Exec(tx, fmt.Sprintf("TRUNCATE %s CASCADE", table))
+	clear_table_by_map := func(tx *sql.Tx, tables []main.TestTable) error {
+		for _, iter := range tables {
+			_, err := DB.Exec(context.Background(), tx, fmt.Sprintf("TRUNCATE %s CASCADE", iter.Name))
 			if err != nil {
 				return err
 			}
-			_, err = DB.Exec(tx, fmt.Sprintf("ALTER SEQUENCE %s RESTART WITH 1", sequence))
+			_, err = DB.Exec(context.Background(), tx, fmt.Sprintf("ALTER SEQUENCE %s RESTART WITH 1", iter.Sequence))
 			if err != nil {
 				return err
 			}
@@ -70,7 +80,7 @@ func clearTable(tables map[string]string) {
 		return nil
 	}
 
-	tx, err := DB.Begin()
+	tx, err := DB.Begin(context.Background())
 	if err != nil {
 		Log.Fatal("clearTable DB.Begin error", err)
 	}
@@ -0,0 +1,116 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	main "github.com/nhht77/earth-rest-api/server"
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+)
+
+func TestCityMetadata(t *testing.T) {
+	RequireDB(t)
+
+	main.DB = DB
+	main.AppConfig = AppConfig
+
+	var (
+		ctx     = context.Background()
+		router  = main.NewRouter()
+		creator = &pkg_v1.UserMinimal{Email: "metadata@earth.test", Name: "metadata"}
+	)
+
+	continent, err := DB.CreateContinent(ctx, nil, &pkg_v1.Continent{
+		Name:      "South America",
+		Type:      pkg_v1.ContinentType_South_America,
+		AreaByKm2: 17840000,
+		Creator:   creator,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { DB.SoftDeleteContinent(context.Background(), nil, continent.Uuid.String()) })
+
+	country, err := DB.CreateCountry(ctx, nil, &pkg_v1.Country{
+		ContinentUuid: continent.Uuid,
+		Name:          "Bolivia",
+		Details:       &pkg_v1.CountryDetails{PhoneCode: "metadata-bo", ISOCode: "MB", Currency: "MET"},
+		Creator:       creator,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	population := func(v int64) *int64 { return &v }
+	elevation := func(v float64) *float64 { return &v }
+
+	cities := map[string]*pkg_v1.City{}
+	for _, iter := range []*pkg_v1.City{
+		{Name: "La Paz", Population: population(755732), Timezone: "America/La_Paz", ElevationByM: elevation(3640), AlternateNames: []string{"Chuqi Yapu"}},
+		{Name: "Santa Cruz", Population: population(1453549), Timezone: "America/La_Paz", ElevationByM: elevation(416), PostalCodePatterns: []string{"####"}},
+		{Name: "Oruro"},
+		{Name: "Puerto Su'arez"},
+	} {
+		iter.ContinentUuid, iter.CountryUuid = continent.Uuid, country.Uuid
+		iter.Details, iter.Creator = &pkg_v1.CityDetails{}, creator
+		if cities[iter.Name], err = DB.CreateCity(ctx, nil, iter); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	la_paz := cities["La Paz"]
+	if *la_paz.Population != 755732 || la_paz.Timezone != "America/La_Paz" || *la_paz.ElevationByM != 3640 || len(la_paz.AlternateNames) != 1 || len(la_paz.PostalCodePatterns) != 0 {
+		t.Errorf("unexpected metadata %+v", la_paz)
+	}
+	if cities["Puerto Su'arez"].Name != "Puerto Su'arez" {
+		t.Errorf("expected a quoted name to be stored as is, got %q", cities["Puerto Su'arez"].Name)
+	}
+	if cities["Oruro"].Population != nil || cities["Oruro"].AlternateNames == nil {
+		t.Errorf("expected an unknown population & no alternate names, got %+v", cities["Oruro"])
+	}
+
+	list := func(query string) []*pkg_v1.City {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/cities?countries="+country.Uuid.String()+"&"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s answered %d: %s", query, w.Code, w.Body.String())
+		}
+		results := []*pkg_v1.City{}
+		if err := json.Unmarshal(w.Body.Bytes(), &results); err != nil {
+			t.Fatal(err)
+		}
+		return results
+	}
+
+	if results := list("min_population=1000000"); len(results) != 1 || results[0].Name != "Santa Cruz" {
+		t.Errorf("expected Santa Cruz only, got %v", results)
+	}
+	if results := list("sort=-population"); len(results) != 4 || results[0].Name != "Santa Cruz" || results[1].Name != "La Paz" {
+		t.Errorf("expected Santa Cruz then La Paz, unknown populations last, got %v", results)
+	}
+	if results := list("sort=-elevation_by_m&filter[timezone]=America/La_Paz"); len(results) != 2 || results[0].Name != "La Paz" {
+		t.Errorf("expected La Paz first, got %v", results)
+	}
+
+	oruro := cities["Oruro"]
+	oruro.Name = "Oruro's Plaza 10 de Febrero"
+	if updated, err := DB.UpdateCity(ctx, nil, oruro); err != nil || updated.Name != oruro.Name {
+		t.Errorf("expected a quoted name to be stored as is, got %v %v", updated, err)
+	}
+
+	la_paz.Timezone = "America/Atlantis"
+	if _, err := DB.UpdateCity(ctx, nil, la_paz); err == nil {
+		t.Error("expected an unknown timezone to be rejected")
+	}
+
+	summaries, err := DB.CountrySummaries(ctx, pkg_v1.CountryList{country})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summaries[0].CityPopulation == nil || *summaries[0].CityPopulation != 755732+1453549 {
+		t.Errorf("unexpected city population %v", summaries[0].CityPopulation)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nhht77/earth-rest-api/server/pkg/mmetrics"
+	"github.com/nhht77/earth-rest-api/server/pkg/msql"
+)
+
+var (
+	Metrics = mmetrics.NewRegistry()
+
+	httpRequestsTotal = mmetrics.NewCounterVec(
+		"earth_http_requests_total",
+		"Total HTTP requests by route, method and status.",
+		"route", "method", "status",
+	)
+	httpRequestDuration = mmetrics.NewHistogramVec(
+		"earth_http_request_duration_seconds",
+		"HTTP request latency by route, method and status.",
+		mmetrics.DefaultBuckets,
+		"route", "method", "status",
+	)
+	databaseOperationDuration = mmetrics.NewHistogramVec(
+		"earth_database_operation_duration_seconds",
+		"Database operation latency by CheckOperation name and result.",
+		mmetrics.DefaultBuckets,
+		"operation", "result",
+	)
+	databasePool = mmetrics.NewGaugeVec(
+		"earth_database_pool",
+		"sql.DB connection pool statistics.",
+		"stat",
+	)
+	databaseRows = mmetrics.NewGaugeVec(
+		"earth_database_rows",
+		"Row count per entity and deleted state.",
+		"entity", "state",
+	)
+)
+
+// Row counts scan every table, scrapes within the interval reuse the last
+// counts. Pool statistics are read on every scrape.
+const MetricsRowsInterval = 30 * time.Second
+
+func init() {
+	Metrics.Register(
+		httpRequestsTotal,
+		httpRequestDuration,
+		databaseOperationDuration,
+		databasePool,
+		databaseRows,
+	)
+}
+
+// statusRecorder keeps the status code written by the wrapped handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+func (rec *statusRecorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func ObserveHTTP(r *http.Request, status int, started time.Time) {
+	route := "unknown"
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			route = template
+		}
+	}
+
+	code := strconv.Itoa(status)
+	httpRequestsTotal.Inc(route, r.Method, code)
+	httpRequestDuration.Observe(time.Since(started).Seconds(), route, r.Method, code)
+}
+
+func ObserveOperation(op string, err error, started time.Time) {
+	if started.IsZero() {
+		return
+	}
+
+	result := "ok"
+	if err != nil && !DatabaseNoResults(err) {
+		result = "error"
+	}
+	databaseOperationDuration.Observe(time.Since(started).Seconds(), strings.TrimSpace(op), result)
+}
+
+func (db *Database) CollectMetrics(ctx context.Context) error {
+	if db.postgres == nil {
+		return nil
+	}
+
+	stats := db.postgres.Stats()
+	for stat, value := range map[string]float64{
+		"max_open_connections": float64(stats.MaxOpenConnections),
+		"open_connections":     float64(stats.OpenConnections),
+		"in_use":               float64(stats.InUse),
+		"idle":                 float64(stats.Idle),
+		"wait_count":           float64(stats.WaitCount),
+		"wait_duration_s":      stats.WaitDuration.Seconds(),
+		"max_idle_closed":      float64(stats.MaxIdleClosed),
+		"max_idle_time_closed": float64(stats.MaxIdleTimeClosed),
+		"max_lifetime_closed":  float64(stats.MaxLifetimeClosed),
+	} {
+		databasePool.Set(value, stat)
+	}
+
+	return db.collectRows(ctx)
+}
+
+func (db *Database) collectRows(ctx context.Context) error {
+	// Note: concurrent scrapes wait for one count instead of each running it
+	db.rowsMu.Lock()
+	defer db.rowsMu.Unlock()
+
+	if time.Since(db.rowsCollected) < MetricsRowsInterval {
+		return nil
+	}
+
+	for _, table := range []string{"continent", "country", "city"} {
+		rows, err := db.Query(ctx, nil, fmt.Sprintf(
+			`SELECT deleted_state, COUNT(*) FROM %s GROUP BY deleted_state`,
+			table,
+		))
+		if err != nil {
+			return err
+		}
+
+		counts := map[string]float64{"live": 0, "deleted": 0}
+		for rows.Next() {
+			var (
+				state msql.DeletedState
+				count float64
+			)
+			if err := rows.Scan(&state, &count); err != nil {
+				rows.Close()
+				return err
+			}
+			if state == msql.SoftDeleted {
+				counts["deleted"] += count
+			} else {
+				counts["live"] += count
+			}
+		}
+		rows.Close()
+
+		for state, count := range counts {
+			databaseRows.Set(count, table, state)
+		}
+	}
+
+	db.rowsCollected = time.Now()
+	return nil
+}
+
+func HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	if err := DB.CollectMetrics(r.Context()); err != nil {
+		Log.Warnf("[metrics] CollectMetrics error %s", err.Error())
+	}
+
+	w.Header().Set("Content-Type", mmetrics.ContentType)
+	if err := Metrics.WriteText(w); err != nil {
+		Log.Warnf("[metrics] WriteText error %s", err.Error())
+	}
+}
@@ -0,0 +1,100 @@
+package main_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	main "github.com/nhht77/earth-rest-api/server"
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+	"github.com/nhht77/earth-rest-api/server/pkg/mmetrics"
+)
+
+func scrapeMetrics(t *testing.T, router http.Handler) string {
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("/metrics status %d", recorder.Code)
+	}
+	if content_type := recorder.Header().Get("Content-Type"); content_type != mmetrics.ContentType {
+		t.Errorf("/metrics Content-Type %q, expected %q", content_type, mmetrics.ContentType)
+	}
+	return recorder.Body.String()
+}
+
+// metricLine returns the sample line of the series name_labels.
+func metricLine(body string, name_labels string) (string, bool) {
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, name_labels+" ") {
+			return line, true
+		}
+	}
+	return "", false
+}
+
+func TestMetricsHandlerObservesRequests(t *testing.T) {
+	previous := main.DB
+	main.DB = &main.Database{}
+	defer func() { main.DB = previous }()
+
+	router := main.NewRouter()
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/api/v1/ping", nil))
+
+	body := scrapeMetrics(t, router)
+	for _, expected := range []string{
+		"# TYPE earth_http_requests_total counter",
+		`earth_http_requests_total{route="/api/v1/ping",method="GET",status="200"}`,
+		`earth_http_request_duration_seconds_count{route="/api/v1/ping",method="GET",status="200"}`,
+		"# TYPE earth_database_pool gauge",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("/metrics is missing %s:\n%s", expected, body)
+		}
+	}
+}
+
+func TestMetricsHandlerRowCounts(t *testing.T) {
+	RequireDB(t)
+
+	// a fresh database counts on its first scrape
+	db := &main.Database{}
+	if err := db.Initialize(AppConfig); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	previous := main.DB
+	main.DB = db
+	defer func() { main.DB = previous }()
+
+	router := main.NewRouter()
+	series := `earth_database_rows{entity="continent",state="live"}`
+
+	first, ok := metricLine(scrapeMetrics(t, router), series)
+	if !ok {
+		t.Fatalf("/metrics is missing %s", series)
+	}
+	if _, ok := metricLine(scrapeMetrics(t, router), `earth_database_pool{stat="in_use"}`); !ok {
+		t.Errorf("/metrics is missing the pool statistics")
+	}
+
+	continent, err := db.CreateContinent(context.Background(), nil, &pkg_v1.Continent{
+		Name:    "Metrics",
+		Type:    pkg_v1.ContinentType_North_America,
+		Creator: &pkg_v1.UserMinimal{Email: "metrics@earth.test", Name: "metrics"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.SoftDeleteContinent(context.Background(), nil, continent.Uuid.String())
+
+	// counts are reused within MetricsRowsInterval
+	if second, _ := metricLine(scrapeMetrics(t, router), series); second != first {
+		t.Errorf("row count %q changed within the interval, expected %q", second, first)
+	}
+}
@@ -0,0 +1,617 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nhht77/earth-rest-api/server/pkg/mhttp"
+)
+
+// OpenAPIVersion is the version of this API in the document, not of the spec.
+const OpenAPIVersion = "1.0.0"
+
+type openAPIParameter struct {
+	name        string
+	in          string // query, header or path
+	description string
+	schema      map[string]interface{}
+	required    bool
+	style       string // deepObject for filter[field][operator]
+}
+
+type openAPIOperation struct {
+	method  string
+	path    string
+	tag     string
+	summary string
+
+	parameters []openAPIParameter
+
+	// schema of the JSON body, nil for none
+	body map[string]interface{}
+
+	// status & schema of the success response
+	status      int
+	response    map[string]interface{}
+	contentType string
+
+	// error statuses besides the ones every route may answer
+	errors []int
+}
+
+////////////////////////////
+/////// Schema helpers
+
+func schemaRef(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+func schemaType(name string, description string) map[string]interface{} {
+	schema := map[string]interface{}{"type": name}
+	if len(description) > 0 {
+		schema["description"] = description
+	}
+	return schema
+}
+
+func schemaFormat(name string, format string) map[string]interface{} {
+	return map[string]interface{}{"type": name, "format": format}
+}
+
+func schemaArray(items map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"type": "array", "items": items}
+}
+
+func schemaObject(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func schemaEnum(name string, description string, values ...interface{}) map[string]interface{} {
+	schema := schemaType(name, description)
+	schema["enum"] = values
+	return schema
+}
+
+var (
+	schemaUuid     = schemaFormat("string", "uuid")
+	schemaDateTime = schemaFormat("string", "date-time")
+	schemaEmpty    = schemaEnum("string", "empty string", "")
+
+	schemaContinentType = schemaEnum("integer",
+		"1 Asia, 2 Africa, 3 Europe, 4 North America, 5 South America, 6 Oceania, 7 Antarctica",
+		1, 2, 3, 4, 5, 6, 7,
+	)
+)
+
+func openAPISchemas() map[string]interface{} {
+	return map[string]interface{}{
+		"Error": schemaType("string", "error message"),
+		"UserMinimal": schemaObject(map[string]interface{}{
+			"email": schemaType("string", ""),
+			"name":  schemaType("string", ""),
+		}, "email", "name"),
+		"Continent": schemaObject(map[string]interface{}{
+			"uuid":        schemaUuid,
+			"name":        schemaType("string", ""),
+			"type":        schemaContinentType,
+			"area_by_km2": schemaType("number", ""),
+			"created":     schemaDateTime,
+			"updated":     schemaDateTime,
+			"creator":     schemaRef("UserMinimal"),
+			"countries":   schemaArray(schemaRef("Country")),
+			"cities":      schemaArray(schemaRef("City")),
+		}, "name", "type", "area_by_km2"),
+		"CountryDetails": schemaObject(map[string]interface{}{
+			"phone_code": schemaType("string", ""),
+			"iso_code":   schemaType("string", ""),
+			"currency":   schemaType("string", ""),
+			"continent":  schemaRef("Continent"),
+		}, "phone_code", "iso_code", "currency"),
+		"Country": schemaObject(map[string]interface{}{
+			"uuid":           schemaUuid,
+			"continent_uuid": schemaUuid,
+			"name":           schemaType("string", ""),
+			"population":     schemaType("integer", "latest of the series, read-only"),
+			"area_by_km2":    schemaType("number", "latest of the series, read-only"),
+			"gdp":            schemaType("number", "latest of the series in current US dollars, read-only"),
+			"details":        schemaRef("CountryDetails"),
+			"created":        schemaDateTime,
+			"updated":        schemaDateTime,
+			"creator":        schemaRef("UserMinimal"),
+			"cities":         schemaArray(schemaRef("City")),
+		}, "continent_uuid", "name", "details"),
+		"CountryYear": schemaObject(map[string]interface{}{
+			"year":        schemaType("integer", ""),
+			"population":  schemaType("integer", ""),
+			"area_by_km2": schemaType("number", ""),
+			"gdp":         schemaType("number", "current US dollars"),
+			"updated":     schemaDateTime,
+		}, "year"),
+		"CountrySummary": schemaObject(map[string]interface{}{
+			"uuid":            schemaUuid,
+			"continent_uuid":  schemaUuid,
+			"name":            schemaType("string", ""),
+			"capital":         schemaRef("City"),
+			"city_count":      schemaType("integer", "live cities"),
+			"city_population": schemaType("integer", "sum of the known populations of live cities"),
+			"population":      schemaType("integer", "latest of the country's series"),
+			"area_by_km2":     schemaType("number", "latest of the country's series"),
+		}),
+		"Stats": schemaObject(map[string]interface{}{
+			"continent_types": schemaArray(schemaRef("ContinentTypeStats")),
+			"rows": schemaObject(map[string]interface{}{
+				"continent": schemaRef("RowCounts"),
+				"country":   schemaRef("RowCounts"),
+				"city":      schemaRef("RowCounts"),
+			}),
+			"activity": schemaRef("Activity"),
+		}),
+		"ContinentTypeStats": schemaObject(map[string]interface{}{
+			"type":        schemaContinentType,
+			"continents":  schemaType("integer", "live continents"),
+			"countries":   schemaType("integer", "live countries"),
+			"cities":      schemaType("integer", "live cities"),
+			"area_by_km2": schemaType("number", "total area of the live continents"),
+		}),
+		"RowCounts": schemaObject(map[string]interface{}{
+			"live":    schemaType("integer", ""),
+			"deleted": schemaType("integer", "soft-deleted"),
+		}),
+		"Activity": schemaObject(map[string]interface{}{
+			"bucket":  schemaEnum("string", "", "day", "week", "month"),
+			"from":    schemaDateTime,
+			"to":      schemaDateTime,
+			"buckets": schemaArray(schemaRef("ActivityBucket")),
+		}),
+		"ActivityBucket": schemaObject(map[string]interface{}{
+			"start":     schemaDateTime,
+			"continent": schemaRef("ActivityCount"),
+			"country":   schemaRef("ActivityCount"),
+			"city":      schemaRef("ActivityCount"),
+		}),
+		"ActivityCount": schemaObject(map[string]interface{}{
+			"created": schemaType("integer", "rows created in the bucket"),
+			"updated": schemaType("integer", "rows updated in the bucket"),
+		}),
+		"CityDetails": schemaObject(map[string]interface{}{
+			"is_capital": schemaType("boolean", ""),
+			"continent":  schemaRef("Continent"),
+			"country":    schemaRef("Country"),
+		}),
+		"City": schemaObject(map[string]interface{}{
+			"uuid":                 schemaUuid,
+			"continent_uuid":       schemaUuid,
+			"country_uuid":         schemaUuid,
+			"name":                 schemaType("string", ""),
+			"population":           schemaType("integer", ""),
+			"timezone":             schemaType("string", "IANA timezone, like Europe/Paris"),
+			"elevation_by_m":       schemaType("number", "-1000 to 9000"),
+			"postal_code_patterns": schemaArray(schemaType("string", "# stands for a digit and @ for a letter, like 75###")),
+			"alternate_names":      schemaArray(schemaType("string", "")),
+			"details":              schemaRef("CityDetails"),
+			"created":              schemaDateTime,
+			"updated":              schemaDateTime,
+			"creator":              schemaRef("UserMinimal"),
+		}, "continent_uuid", "country_uuid", "name"),
+		"Change": schemaObject(map[string]interface{}{
+			"index":          schemaType("integer", "change log index, also the event id"),
+			"entity":         schemaEnum("string", "", "continent", "country", "city"),
+			"operation":      schemaEnum("string", "", "insert", "update", "delete"),
+			"uuid":           schemaUuid,
+			"continent_uuid": schemaUuid,
+			"continent_type": schemaContinentType,
+			"created":        schemaDateTime,
+		}),
+		"WebhookSubscription": schemaObject(map[string]interface{}{
+			"uuid":    schemaUuid,
+			"url":     schemaFormat("string", "uri"),
+			"secret":  schemaType("string", "HMAC-SHA256 key, 16+ characters, generated when omitted and only returned on create"),
+			"events":  schemaArray(schemaType("string", "<entity>.<operation>, * for any")),
+			"created": schemaDateTime,
+			"updated": schemaDateTime,
+			"creator": schemaRef("UserMinimal"),
+		}, "url"),
+		"WebhookDeliveryAttempt": schemaObject(map[string]interface{}{
+			"attempt":     schemaType("integer", ""),
+			"status_code": schemaType("integer", "0 when no response was received"),
+			"response":    schemaType("string", ""),
+			"error":       schemaType("string", ""),
+			"duration_ms": schemaType("number", ""),
+			"created":     schemaDateTime,
+		}),
+		"WebhookDelivery": schemaObject(map[string]interface{}{
+			"uuid":              schemaUuid,
+			"subscription_uuid": schemaUuid,
+			"event":             schemaType("string", ""),
+			"event_uuid":        schemaUuid,
+			"state":             schemaEnum("integer", "0 pending, 1 delivered, 2 dead", 0, 1, 2),
+			"attempts":          schemaType("integer", ""),
+			"next_attempt":      schemaDateTime,
+			"last_error":        schemaType("string", ""),
+			"created":           schemaDateTime,
+			"updated":           schemaDateTime,
+			"attempt_list":      schemaArray(schemaRef("WebhookDeliveryAttempt")),
+		}),
+		"HealthReport": schemaObject(map[string]interface{}{
+			"status": schemaEnum("string", "", HealthStatus_Ok, HealthStatus_Fail),
+			"checks": schemaArray(schemaObject(map[string]interface{}{
+				"name":       schemaType("string", ""),
+				"status":     schemaEnum("string", "", HealthStatus_Ok, HealthStatus_Fail),
+				"latency_ms": schemaType("number", ""),
+				"error":      schemaType("string", ""),
+				"details":    map[string]interface{}{},
+			})),
+		}),
+	}
+}
+
+////////////////////////////
+/////// Parameters
+
+func queryParameter(name string, description string, schema map[string]interface{}) openAPIParameter {
+	return openAPIParameter{name: name, in: "query", description: description, schema: schema}
+}
+
+// filterParameter documents filter[field][operator]=value with the fields
+// of an entity and their operators.
+func filterParameter(fields map[string]filterField) openAPIParameter {
+	names := []string{}
+	for name, field := range fields {
+		names = append(names, fmt.Sprintf("%s (%s)", name, strings.Join(filterOperators[field.kind], ", ")))
+	}
+	sort.Strings(names)
+
+	return openAPIParameter{
+		name:        "filter",
+		in:          "query",
+		description: "filter[field]=value or filter[field][operator]=value, in takes a comma separated list. Fields: " + strings.Join(names, "; "),
+		schema:      map[string]interface{}{"type": "object", "additionalProperties": true},
+		style:       "deepObject",
+	}
+}
+
+func sortParameter(fields map[string]string) openAPIParameter {
+	names := []string{}
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return queryParameter("sort", "comma separated fields, descending with a - prefix, values missing last. Fields: "+strings.Join(names, ", "), schemaType("string", ""))
+}
+
+var (
+	parameterUuid = openAPIParameter{name: "uuid", in: "query", schema: schemaUuid, required: true}
+
+	parameterDeleted        = queryParameter("deleted", "list soft-deleted rows instead of live ones", schemaType("boolean", ""))
+	parameterContinentTypes = queryParameter("continent_types", "comma separated continent types", schemaType("string", ""))
+
+	parameterLimit  = queryParameter("limit", "page size, 0 to 1000, 0 (default) lists every row", schemaType("integer", ""))
+	parameterOffset = queryParameter("offset", "rows to skip, lists are ordered by creation", schemaType("integer", ""))
+
+	parameterReadYourWrites = openAPIParameter{
+		name:        HeaderReadYourWrites,
+		in:          "header",
+		description: "true reads from the primary and skips the cache",
+		schema:      schemaType("boolean", ""),
+	}
+	parameterIdempotencyKey = openAPIParameter{
+		name:        HeaderIdempotencyKey,
+		in:          "header",
+		description: "replays the stored response of an earlier request with the same key & body",
+		schema:      map[string]interface{}{"type": "string", "maxLength": IdempotencyKeyMaxLength},
+	}
+)
+
+func entityOperations(name string, plural string, schema string, relations string, list_parameters ...openAPIParameter) []openAPIOperation {
+	var (
+		tag      = plural
+		resource = "/api/v1/" + name
+		entity   = schemaRef(schema)
+
+		// fields= & expand= apply to the list & get alike
+		fields = queryParameter("fields", "comma separated fields of the response, like uuid,name,details.iso_code", schemaType("string", ""))
+		expand = queryParameter("expand", "comma separated relations among "+relations+", supersedes the with_* flags", schemaType("string", ""))
+	)
+
+	return []openAPIOperation{
+		{
+			method: "GET", path: "/api/v1/" + plural, tag: tag, summary: "List " + plural,
+			parameters: append(list_parameters, fields, expand, parameterLimit, parameterOffset, parameterReadYourWrites),
+			status:     http.StatusOK,
+			response:   schemaArray(entity),
+		},
+		{
+			method: "GET", path: resource, tag: tag, summary: "Get a " + name + " by uuid",
+			parameters: []openAPIParameter{parameterUuid, fields, expand, parameterReadYourWrites},
+			status:     http.StatusOK,
+			response:   entity,
+			errors:     []int{http.StatusNotFound},
+		},
+		{
+			method: "PUT", path: resource, tag: tag, summary: "Update the " + name + " with uuid, or create it (201)",
+			parameters: []openAPIParameter{{name: "uuid", in: "query", description: "or uuid in the body", schema: schemaUuid}},
+			body:       entity,
+			status:     http.StatusOK,
+			response:   entity,
+			errors:     []int{http.StatusConflict},
+		},
+		{
+			method: "POST", path: resource + "/create", tag: tag, summary: "Create a " + name + ", uuid is optional",
+			parameters: []openAPIParameter{parameterIdempotencyKey},
+			body:       entity,
+			status:     http.StatusOK,
+			response:   entity,
+			errors:     []int{http.StatusConflict, http.StatusUnprocessableEntity},
+		},
+		{
+			method: "PUT", path: resource + "/update", tag: tag, summary: "Update a " + name,
+			body:     entity,
+			status:   http.StatusOK,
+			response: entity,
+			errors:   []int{http.StatusNotFound},
+		},
+		{
+			method: "DELETE", path: resource + "/delete", tag: tag, summary: "Soft delete a " + name,
+			parameters: []openAPIParameter{parameterUuid},
+			status:     http.StatusOK,
+			response:   schemaEmpty,
+		},
+	}
+}
+
+// OpenAPIOperations documents every route of NewRouter, openapi_test.go
+// fails when one is missing.
+func OpenAPIOperations() []openAPIOperation {
+	operations := []openAPIOperation{
+		{method: "GET", path: "/api/openapi.json", tag: "meta", summary: "This document", status: http.StatusOK, response: map[string]interface{}{"type": "object"}},
+		{method: "GET", path: "/api/v1/ping", tag: "meta", summary: "Ping", status: http.StatusOK, response: schemaEmpty},
+		{method: "GET", path: "/healthz", tag: "meta", summary: "Liveness", status: http.StatusOK, response: schemaRef("HealthReport")},
+		{method: "GET", path: "/readyz", tag: "meta", summary: "Readiness: database, replicas, migrations and pool", status: http.StatusOK, response: schemaRef("HealthReport"), errors: []int{http.StatusServiceUnavailable}},
+		{method: "GET", path: "/metrics", tag: "meta", summary: "Prometheus metrics", status: http.StatusOK, response: schemaType("string", ""), contentType: "text/plain"},
+		{
+			method: "GET", path: "/api/v1/changes", tag: "changes",
+			summary: "Server-Sent Events stream of the change log, event id is the change index and data a Change",
+			parameters: []openAPIParameter{
+				queryParameter("entities", "comma separated continent, country, city", schemaType("string", "")),
+				parameterContinentTypes,
+				queryParameter("last_event_id", "resume after this change when Last-Event-ID can not be sent", schemaType("integer", "")),
+				{name: HeaderLastEventID, in: "header", description: "resume after this change", schema: schemaType("integer", "")},
+			},
+			status:   http.StatusOK,
+			response: schemaRef("Change"), contentType: "text/event-stream",
+			errors: []int{http.StatusServiceUnavailable},
+		},
+	}
+
+	operations = append(operations, openAPIOperation{
+		method: "POST", path: "/graphql", tag: "graphql",
+		summary: "GraphQL query or mutation over continents, countries & cities, the schema is served by introspection",
+		body: schemaObject(map[string]interface{}{
+			"query":         schemaType("string", ""),
+			"operationName": schemaType("string", ""),
+			"variables":     schemaType("object", ""),
+		}, "query"),
+		status: http.StatusOK,
+		response: schemaObject(map[string]interface{}{
+			"data":   schemaType("object", ""),
+			"errors": schemaArray(schemaType("object", "")),
+		}),
+	})
+
+	operations = append(operations, entityOperations("continent", "continents", "Continent", "countries, cities",
+		queryParameter("types", "comma separated continent types", schemaType("string", "")),
+		filterParameter(continentFilterFields),
+		queryParameter("cities", "embed cities, in their country with countries=true", schemaType("boolean", "")),
+		queryParameter("countries", "embed countries", schemaType("boolean", "")),
+		parameterDeleted,
+	)...)
+	operations = append(operations, entityOperations("country", "countries", "Country", "continent, cities",
+		queryParameter("countries", "comma separated country uuids", schemaType("string", "")),
+		parameterContinentTypes,
+		filterParameter(countryFilterFields),
+		sortParameter(countrySortFields),
+		queryParameter("with_cities", "embed cities", schemaType("boolean", "")),
+		queryParameter("with_continent", "embed the continent in details", schemaType("boolean", "")),
+		parameterDeleted,
+	)...)
+	operations = append(operations, entityOperations("city", "cities", "City", "continent, country",
+		queryParameter("countries", "comma separated country uuids", schemaType("string", "")),
+		queryParameter("cities", "comma separated city uuids", schemaType("string", "")),
+		parameterContinentTypes,
+		filterParameter(cityFilterFields),
+		sortParameter(citySortFields),
+		queryParameter("is_capital", "only capitals with true, only other cities with false", schemaType("boolean", "")),
+		queryParameter("min_population", "only cities of at least that population, unknown ones left out", schemaType("integer", "")),
+		queryParameter("with_country", "embed the country in details", schemaType("boolean", "")),
+		queryParameter("with_continent", "embed the continent in details", schemaType("boolean", "")),
+		parameterDeleted,
+	)...)
+
+	operations = append(operations,
+		openAPIOperation{
+			method: "GET", path: "/api/v1/countries/summary", tag: "countries", summary: "Capital & city count of countries, takes the country list options",
+			parameters: []openAPIParameter{
+				queryParameter("countries", "comma separated country uuids", schemaType("string", "")),
+				parameterContinentTypes,
+				filterParameter(countryFilterFields),
+				sortParameter(countrySortFields),
+				parameterDeleted,
+				parameterLimit,
+				parameterOffset,
+				parameterReadYourWrites,
+			},
+			status:   http.StatusOK,
+			response: schemaArray(schemaRef("CountrySummary")),
+		},
+		openAPIOperation{
+			method: "GET", path: "/api/v1/countries/{uuid}/summary", tag: "countries", summary: "Capital & city count of a country",
+			parameters: []openAPIParameter{{name: "uuid", in: "path", schema: schemaUuid, required: true}, parameterReadYourWrites},
+			status:     http.StatusOK,
+			response:   schemaRef("CountrySummary"),
+		},
+		openAPIOperation{
+			method: "GET", path: "/api/v1/countries/{uuid}/series", tag: "countries", summary: "Yearly population, area & GDP of a country, oldest first",
+			parameters: []openAPIParameter{
+				{name: "uuid", in: "path", schema: schemaUuid, required: true},
+				queryParameter("from", "first year, included", schemaType("integer", "")),
+				queryParameter("to", "last year, included", schemaType("integer", "")),
+				parameterReadYourWrites,
+			},
+			status:   http.StatusOK,
+			response: schemaArray(schemaRef("CountryYear")),
+		},
+		openAPIOperation{
+			method: "PUT", path: "/api/v1/countries/{uuid}/series", tag: "countries", summary: "Upsert years of a country's series, omitted values are kept, answers the upserted years",
+			parameters: []openAPIParameter{{name: "uuid", in: "path", schema: schemaUuid, required: true}},
+			body:       schemaArray(schemaRef("CountryYear")),
+			status:     http.StatusOK,
+			response:   schemaArray(schemaRef("CountryYear")),
+		},
+	)
+
+	operations = append(operations, openAPIOperation{
+		method: "GET", path: "/api/v1/stats", tag: "stats", summary: "Aggregates by continent type, live & soft-deleted rows and activity by bucket",
+		parameters: []openAPIParameter{
+			queryParameter("bucket", "activity bucket, day by default", schemaEnum("string", "", "day", "week", "month")),
+			queryParameter("from", "activity start, RFC 3339 or 2006-01-02, defaults to 30 days, 12 weeks or 12 months before to", schemaDateTime),
+			queryParameter("to", "activity end (excluded), defaults to now", schemaDateTime),
+		},
+		status:   http.StatusOK,
+		response: schemaRef("Stats"),
+	})
+
+	subscription := schemaRef("WebhookSubscription")
+	operations = append(operations,
+		openAPIOperation{method: "GET", path: "/api/v1/webhooks", tag: "webhooks", summary: "List webhook subscriptions", status: http.StatusOK, response: schemaArray(subscription)},
+		openAPIOperation{method: "GET", path: "/api/v1/webhook", tag: "webhooks", summary: "Get a webhook subscription", parameters: []openAPIParameter{parameterUuid}, status: http.StatusOK, response: subscription},
+		openAPIOperation{method: "POST", path: "/api/v1/webhook/create", tag: "webhooks", summary: "Subscribe, the response carries the secret", body: subscription, status: http.StatusOK, response: subscription},
+		openAPIOperation{method: "PUT", path: "/api/v1/webhook/update", tag: "webhooks", summary: "Update a subscription, the secret is kept when omitted", body: subscription, status: http.StatusOK, response: subscription},
+		openAPIOperation{method: "DELETE", path: "/api/v1/webhook/delete", tag: "webhooks", summary: "Unsubscribe, pending deliveries are dead-lettered", parameters: []openAPIParameter{parameterUuid}, status: http.StatusOK, response: schemaEmpty},
+		openAPIOperation{
+			method: "GET", path: "/api/v1/webhook/deliveries", tag: "webhooks", summary: "Newest deliveries of a subscription with their attempts",
+			parameters: []openAPIParameter{
+				parameterUuid,
+				queryParameter("states", "comma separated delivery states, 2 for dead letters", schemaType("string", "")),
+				queryParameter("limit", "1 to 1000, default 100", schemaType("integer", "")),
+			},
+			status:   http.StatusOK,
+			response: schemaArray(schemaRef("WebhookDelivery")),
+		},
+		openAPIOperation{method: "POST", path: "/api/v1/webhook/delivery/retry", tag: "webhooks", summary: "Send a dead-lettered delivery again", parameters: []openAPIParameter{parameterUuid}, status: http.StatusOK, response: schemaEmpty, errors: []int{http.StatusNotFound}},
+	)
+
+	return operations
+}
+
+func errorResponse(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schemaRef("Error")},
+		},
+	}
+}
+
+var openAPIErrors = map[int]string{
+	http.StatusBadRequest:          "Invalid request or query, or no such row",
+	http.StatusConflict:            "uuid already used, or request with the same Idempotency-Key in progress",
+	http.StatusUnprocessableEntity: "Idempotency-Key already used with a different body",
+	http.StatusTooManyRequests:     "Rate limit exceeded",
+	http.StatusServiceUnavailable:  "Not ready",
+	http.StatusGatewayTimeout:      "Query deadline or statement timeout exceeded",
+}
+
+func OpenAPIDocument() map[string]interface{} {
+	paths := map[string]interface{}{}
+
+	for _, iter := range OpenAPIOperations() {
+		content_type := iter.contentType
+		if len(content_type) == 0 {
+			content_type = "application/json"
+		}
+
+		responses := map[string]interface{}{
+			strconv.Itoa(iter.status): map[string]interface{}{
+				"description": http.StatusText(iter.status),
+				"content": map[string]interface{}{
+					content_type: map[string]interface{}{"schema": iter.response},
+				},
+			},
+		}
+		statuses := append([]int{http.StatusBadRequest, http.StatusTooManyRequests, http.StatusGatewayTimeout}, iter.errors...)
+		sort.Ints(statuses)
+		for _, status := range statuses {
+			responses[strconv.Itoa(status)] = errorResponse(openAPIErrors[status])
+		}
+
+		operation := map[string]interface{}{
+			"tags":        []string{iter.tag},
+			"summary":     iter.summary,
+			"operationId": strings.ToLower(iter.method) + strings.ReplaceAll(strings.ReplaceAll(iter.path, "/", "_"), ".", "_"),
+			"responses":   responses,
+		}
+
+		if len(iter.parameters) > 0 {
+			parameters := []interface{}{}
+			for _, parameter := range iter.parameters {
+				value := map[string]interface{}{
+					"name":   parameter.name,
+					"in":     parameter.in,
+					"schema": parameter.schema,
+				}
+				if len(parameter.description) > 0 {
+					value["description"] = parameter.description
+				}
+				if parameter.required {
+					value["required"] = true
+				}
+				if len(parameter.style) > 0 {
+					value["style"] = parameter.style
+					value["explode"] = true
+				}
+				parameters = append(parameters, value)
+			}
+			operation["parameters"] = parameters
+		}
+
+		if iter.body != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": iter.body},
+				},
+			}
+		}
+
+		path, ok := paths[iter.path].(map[string]interface{})
+		if !ok {
+			path = map[string]interface{}{}
+			paths[iter.path] = path
+		}
+		path[strings.ToLower(iter.method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.1.0",
+		"info": map[string]interface{}{
+			"title":   "Earth REST API",
+			"version": OpenAPIVersion,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": openAPISchemas(),
+		},
+	}
+}
+
+func HandleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	mhttp.WriteBodyJSON(w, OpenAPIDocument())
+}
@@ -0,0 +1,84 @@
+package main_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	main "github.com/nhht77/earth-rest-api/server"
+)
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	paths, ok := main.OpenAPIDocument()["paths"].(map[string]interface{})
+	if !ok {
+		t.Fatal("expected paths in the document")
+	}
+
+	routed := map[string]bool{}
+	err := main.NewRouter().Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			t.Errorf("route %s has no methods", path)
+			return nil
+		}
+
+		for _, method := range methods {
+			method = strings.ToLower(method)
+			routed[method+" "+path] = true
+
+			operations, _ := paths[path].(map[string]interface{})
+			if _, ok := operations[method]; !ok {
+				t.Errorf("route %s %s missing from the OpenAPI document", strings.ToUpper(method), path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, iter := range paths {
+		for method := range iter.(map[string]interface{}) {
+			if !routed[method+" "+path] {
+				t.Errorf("documented %s %s is not routed", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestHandleOpenAPI(t *testing.T) {
+	var (
+		w   = httptest.NewRecorder()
+		r   = httptest.NewRequest("GET", "/api/openapi.json", nil)
+		doc struct {
+			OpenAPI    string                            `json:"openapi"`
+			Components map[string]map[string]interface{} `json:"components"`
+		}
+	)
+	main.NewRouter().ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != "3.1.0" {
+		t.Fatalf("expected openapi 3.1.0, got %q", doc.OpenAPI)
+	}
+
+	// every $ref must resolve to a component schema
+	for _, ref := range strings.Split(w.Body.String(), `"$ref":"#/components/schemas/`)[1:] {
+		name := ref[:strings.Index(ref, `"`)]
+		if _, ok := doc.Components["schemas"][name]; !ok {
+			t.Errorf("unresolved $ref %s", name)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package pkg_v1
+
+import (
+	"errors"
+	"time"
+
+	"github.com/nhht77/earth-rest-api/server/pkg/msql"
+	muuid "github.com/nhht77/earth-rest-api/server/pkg/muuid"
+)
+
+/////////////////////
+/////// Change struct
+
+type ChangeEntity string
+
+const (
+	ChangeEntity_Continent ChangeEntity = "continent"
+	ChangeEntity_Country   ChangeEntity = "country"
+	ChangeEntity_City      ChangeEntity = "city"
+)
+
+func (entity ChangeEntity) IsValid() error {
+	switch entity {
+	case ChangeEntity_Continent, ChangeEntity_Country, ChangeEntity_City:
+		return nil
+	}
+	return errors.New("Unsupported change entity")
+}
+
+type ChangeOperation string
+
+const (
+	ChangeOperation_Insert ChangeOperation = "insert"
+	ChangeOperation_Update ChangeOperation = "update"
+	// soft delete, or a row removed from the table
+	ChangeOperation_Delete ChangeOperation = "delete"
+)
+
+// Change is one row of the change log, written by trigger on every insert,
+// update and delete of continent, country and city.
+type Change struct {
+	Index msql.DatabaseIndex `json:"index"`
+
+	Entity    ChangeEntity    `json:"entity"`
+	Operation ChangeOperation `json:"operation"`
+	Uuid      muuid.UUID      `json:"uuid"`
+
+	// continent of the changed row, the row itself for a continent
+	ContinentUuid muuid.UUID    `json:"continent_uuid"`
+	ContinentType ContinentType `json:"continent_type"`
+
+	Created time.Time `json:"created"`
+}
+
+func (obj *Change) DatabaseFields() string {
+	return msql.FormatFields(
+		"index",
+		"entity", "operation", "uuid",
+		"continent_uuid", "continent_type",
+		"created",
+	)
+}
@@ -3,8 +3,13 @@ package pkg_v1
 import (
 	"database/sql/driver"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	// timezones validate against the embedded tz database, not the host's
+	_ "time/tzdata"
+
 	"github.com/nhht77/earth-rest-api/server/pkg/msql"
 	muuid "github.com/nhht77/earth-rest-api/server/pkg/muuid"
 )
@@ -21,6 +26,15 @@ type City struct {
 	Name    string       `json:"name"`
 	Details *CityDetails `json:"details"`
 
+	// nil or empty when unknown
+	Population   *int64   `json:"population"`
+	Timezone     string   `json:"timezone"` // IANA name, like Europe/Paris
+	ElevationByM *float64 `json:"elevation_by_m"`
+
+	// # stands for a digit and @ for a letter, like 75### or @#@ #@#
+	PostalCodePatterns msql.StringList `json:"postal_code_patterns"`
+	AlternateNames     msql.StringList `json:"alternate_names"`
+
 	Created time.Time `json:"created"`
 	Updated time.Time `json:"updated"`
 
@@ -49,6 +63,13 @@ func (v *CityDetails) Scan(src interface{}) error {
 
 func (obj *City) ValidateCreate() error {
 
+	// optional, generated when empty
+	if muuid.UUIDValid(obj.Uuid) {
+		if err := muuid.UUIDCheck(obj.Uuid); err != nil {
+			return fmt.Errorf("Invalid city uuid: %s", err.Error())
+		}
+	}
+
 	if !muuid.UUIDValid(obj.ContinentUuid) {
 		return errors.New("Invalid continent uuid")
 	}
@@ -61,6 +82,10 @@ func (obj *City) ValidateCreate() error {
 		return errors.New("Invalid city name")
 	}
 
+	if err := obj.ValidateMetadata(); err != nil {
+		return err
+	}
+
 	if err := obj.Creator.IsValid(); err != nil {
 		return err
 	}
@@ -82,6 +107,70 @@ func (obj *City) ValidateUpdate() error {
 		return errors.New("Invalid country name")
 	}
 
+	if err := obj.ValidateMetadata(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const (
+	CityElevation_Min = -1000
+	CityElevation_Max = 9000
+
+	CityPostalCodePattern_MaxLength = 20
+	CityAlternateName_MaxLength     = 200
+)
+
+// ValidateMetadata checks the population, timezone, elevation, postal code
+// patterns & alternate names, all optional.
+func (obj *City) ValidateMetadata() error {
+
+	if obj.Population != nil && *obj.Population < 0 {
+		return errors.New("Invalid city population")
+	}
+
+	if len(obj.Timezone) > 0 {
+		// Note: LoadLocation takes Local for the host timezone
+		if _, err := time.LoadLocation(obj.Timezone); err != nil || obj.Timezone == "Local" {
+			return fmt.Errorf("Invalid city timezone %q, expected an IANA name like Europe/Paris", obj.Timezone)
+		}
+	}
+
+	if obj.ElevationByM != nil && (*obj.ElevationByM < CityElevation_Min || *obj.ElevationByM > CityElevation_Max) {
+		return fmt.Errorf("Invalid city elevation, expected between %d and %d m", CityElevation_Min, CityElevation_Max)
+	}
+
+	if err := validateStringList("postal code pattern", obj.PostalCodePatterns, CityPostalCodePattern_MaxLength, isPostalCodePatternRune); err != nil {
+		return err
+	}
+
+	if err := validateStringList("alternate name", obj.AlternateNames, CityAlternateName_MaxLength, nil); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func isPostalCodePatternRune(r rune) bool {
+	return (r >= '0' && r <= '9') || (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') ||
+		r == '#' || r == '@' || r == ' ' || r == '-'
+}
+
+func validateStringList(name string, list []string, max_length int, valid_rune func(r rune) bool) error {
+	seen := map[string]bool{}
+	for _, iter := range list {
+		if len(strings.TrimSpace(iter)) == 0 || len(iter) > max_length {
+			return fmt.Errorf("Invalid city %s %q, expected 1 to %d characters", name, iter, max_length)
+		}
+		if valid_rune != nil && strings.IndexFunc(iter, func(r rune) bool { return !valid_rune(r) }) >= 0 {
+			return fmt.Errorf("Invalid city %s %q", name, iter)
+		}
+		if seen[iter] {
+			return fmt.Errorf("City %s %q listed twice", name, iter)
+		}
+		seen[iter] = true
+	}
 	return nil
 }
 
@@ -89,7 +178,9 @@ func (obj *City) DatabaseFields() string {
 	return msql.FormatFields(
 		"index", "continent_index",
 		"country_index", "uuid",
-		"name", "details", "creator",
+		"name", "population", "timezone", "elevation_by_m",
+		"postal_code_patterns", "alternate_names",
+		"details", "creator",
 		"created", "updated", "deleted_state",
 	)
 }
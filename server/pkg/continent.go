@@ -3,6 +3,7 @@ package pkg_v1
 import (
 	"database/sql/driver"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/nhht77/earth-rest-api/server/pkg/msql"
@@ -68,11 +69,22 @@ type Continent struct {
 
 	Creator *UserMinimal `json:"creator"`
 
+	// embedded on request, with countries the cities nest in their country
+	Countries CountryList `json:"countries,omitempty"`
+	Cities    CityList    `json:"cities,omitempty"`
+
 	DeletedState msql.DeletedState `json:"-"`
 }
 
 func (obj *Continent) ValidateCreate() error {
 
+	// optional, generated when empty
+	if muuid.UUIDValid(obj.Uuid) {
+		if err := muuid.UUIDCheck(obj.Uuid); err != nil {
+			return fmt.Errorf("Invalid continent uuid: %s", err.Error())
+		}
+	}
+
 	// check type
 	if err := obj.IsValidContinentType(obj.Type); err != nil {
 		return err
@@ -3,6 +3,7 @@ package pkg_v1
 import (
 	"database/sql/driver"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/nhht77/earth-rest-api/server/pkg/msql"
@@ -19,11 +20,19 @@ type Country struct {
 	Name    string          `json:"name"`
 	Details *CountryDetails `json:"details"`
 
+	// latest values of the yearly series, nil without data, read-only
+	Population *int64   `json:"population"`
+	AreaByKm2  *float64 `json:"area_by_km2"`
+	GDP        *float64 `json:"gdp"`
+
 	Created time.Time `json:"created"`
 	Updated time.Time `json:"updated"`
 
 	Creator *UserMinimal `json:"creator"`
 
+	// embedded on request
+	Cities CityList `json:"cities,omitempty"`
+
 	DeletedState msql.DeletedState `json:"-"`
 }
 
@@ -64,6 +73,13 @@ func (details *CountryDetails) Validate() error {
 
 func (obj *Country) ValidateCreate() error {
 
+	// optional, generated when empty
+	if muuid.UUIDValid(obj.Uuid) {
+		if err := muuid.UUIDCheck(obj.Uuid); err != nil {
+			return fmt.Errorf("Invalid country uuid: %s", err.Error())
+		}
+	}
+
 	if len(obj.Name) == 0 {
 		return errors.New("Invalid country name")
 	}
@@ -108,6 +124,7 @@ func (obj *Country) DatabaseFields() string {
 	return msql.FormatFields(
 		"index", "continent_index",
 		"uuid", "name",
+		"population", "area_by_km2", "gdp",
 		"details", "creator",
 		"created", "updated", "deleted_state",
 	)
@@ -122,3 +139,86 @@ func (list CountryList) GetContinentIndexes() msql.DatabaseIndexList {
 	}
 	return index_list
 }
+
+////////////////////////////////
+/////// Country summary struct
+
+// CountrySummary aggregates the cities of a country.
+type CountrySummary struct {
+	Uuid          muuid.UUID `json:"uuid"`
+	ContinentUuid muuid.UUID `json:"continent_uuid"`
+	Name          string     `json:"name"`
+
+	// nil without capital
+	Capital *City `json:"capital"`
+
+	CityCount int `json:"city_count"`
+	// sum of the known city populations, nil when none is known
+	CityPopulation *int64 `json:"city_population"`
+
+	// latest values of the country's series, nil without data
+	Population *int64   `json:"population"`
+	AreaByKm2  *float64 `json:"area_by_km2"`
+}
+
+///////////////////////////////
+/////// Country series struct
+
+// CountryYear is a year of the series of a country, nil values are unknown.
+type CountryYear struct {
+	Year int `json:"year"`
+
+	Population *int64   `json:"population"`
+	AreaByKm2  *float64 `json:"area_by_km2"`
+	// current US dollars
+	GDP *float64 `json:"gdp"`
+
+	Updated time.Time `json:"updated"`
+}
+
+type CountrySeries []*CountryYear
+
+const (
+	CountryYear_Min = 1
+	CountryYear_Max = 9999
+)
+
+func (obj *CountryYear) Validate() error {
+	if obj.Year < CountryYear_Min || obj.Year > CountryYear_Max {
+		return fmt.Errorf("Invalid country series year %d", obj.Year)
+	}
+
+	if obj.Population == nil && obj.AreaByKm2 == nil && obj.GDP == nil {
+		return fmt.Errorf("Empty country series year %d", obj.Year)
+	}
+
+	if (obj.Population != nil && *obj.Population < 0) ||
+		(obj.AreaByKm2 != nil && *obj.AreaByKm2 < 0) ||
+		(obj.GDP != nil && *obj.GDP < 0) {
+		return fmt.Errorf("Negative country series value in %d", obj.Year)
+	}
+
+	return nil
+}
+
+func (series CountrySeries) Validate() error {
+	if len(series) == 0 {
+		return errors.New("Empty country series")
+	}
+
+	years := map[int]bool{}
+	for _, iter := range series {
+		if iter == nil {
+			return errors.New("Empty country series year")
+		}
+		if err := iter.Validate(); err != nil {
+			return err
+		}
+		if years[iter.Year] {
+			return fmt.Errorf("Country series year %d listed twice", iter.Year)
+		}
+		years[iter.Year] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,676 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: earth.proto
+
+package grpc_v1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// EarthServiceClient is the client API for EarthService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type EarthServiceClient interface {
+	ListContinents(ctx context.Context, in *ListContinentsRequest, opts ...grpc.CallOption) (*ListContinentsResponse, error)
+	GetContinent(ctx context.Context, in *UuidRequest, opts ...grpc.CallOption) (*Continent, error)
+	CreateContinent(ctx context.Context, in *Continent, opts ...grpc.CallOption) (*Continent, error)
+	UpdateContinent(ctx context.Context, in *Continent, opts ...grpc.CallOption) (*Continent, error)
+	DeleteContinent(ctx context.Context, in *UuidRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListCountries(ctx context.Context, in *ListCountriesRequest, opts ...grpc.CallOption) (*ListCountriesResponse, error)
+	GetCountry(ctx context.Context, in *UuidRequest, opts ...grpc.CallOption) (*Country, error)
+	CreateCountry(ctx context.Context, in *Country, opts ...grpc.CallOption) (*Country, error)
+	UpdateCountry(ctx context.Context, in *Country, opts ...grpc.CallOption) (*Country, error)
+	DeleteCountry(ctx context.Context, in *UuidRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListCities(ctx context.Context, in *ListCitiesRequest, opts ...grpc.CallOption) (*ListCitiesResponse, error)
+	// StreamCities sends the cities of ListCities one message each, as they are read.
+	StreamCities(ctx context.Context, in *ListCitiesRequest, opts ...grpc.CallOption) (EarthService_StreamCitiesClient, error)
+	GetCity(ctx context.Context, in *UuidRequest, opts ...grpc.CallOption) (*City, error)
+	CreateCity(ctx context.Context, in *City, opts ...grpc.CallOption) (*City, error)
+	UpdateCity(ctx context.Context, in *City, opts ...grpc.CallOption) (*City, error)
+	DeleteCity(ctx context.Context, in *UuidRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type earthServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEarthServiceClient(cc grpc.ClientConnInterface) EarthServiceClient {
+	return &earthServiceClient{cc}
+}
+
+func (c *earthServiceClient) ListContinents(ctx context.Context, in *ListContinentsRequest, opts ...grpc.CallOption) (*ListContinentsResponse, error) {
+	out := new(ListContinentsResponse)
+	err := c.cc.Invoke(ctx, "/earth.v1.EarthService/ListContinents", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *earthServiceClient) GetContinent(ctx context.Context, in *UuidRequest, opts ...grpc.CallOption) (*Continent, error) {
+	out := new(Continent)
+	err := c.cc.Invoke(ctx, "/earth.v1.EarthService/GetContinent", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *earthServiceClient) CreateContinent(ctx context.Context, in *Continent, opts ...grpc.CallOption) (*Continent, error) {
+	out := new(Continent)
+	err := c.cc.Invoke(ctx, "/earth.v1.EarthService/CreateContinent", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *earthServiceClient) UpdateContinent(ctx context.Context, in *Continent, opts ...grpc.CallOption) (*Continent, error) {
+	out := new(Continent)
+	err := c.cc.Invoke(ctx, "/earth.v1.EarthService/UpdateContinent", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *earthServiceClient) DeleteContinent(ctx context.Context, in *UuidRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/earth.v1.EarthService/DeleteContinent", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *earthServiceClient) ListCountries(ctx context.Context, in *ListCountriesRequest, opts ...grpc.CallOption) (*ListCountriesResponse, error) {
+	out := new(ListCountriesResponse)
+	err := c.cc.Invoke(ctx, "/earth.v1.EarthService/ListCountries", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *earthServiceClient) GetCountry(ctx context.Context, in *UuidRequest, opts ...grpc.CallOption) (*Country, error) {
+	out := new(Country)
+	err := c.cc.Invoke(ctx, "/earth.v1.EarthService/GetCountry", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *earthServiceClient) CreateCountry(ctx context.Context, in *Country, opts ...grpc.CallOption) (*Country, error) {
+	out := new(Country)
+	err := c.cc.Invoke(ctx, "/earth.v1.EarthService/CreateCountry", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *earthServiceClient) UpdateCountry(ctx context.Context, in *Country, opts ...grpc.CallOption) (*Country, error) {
+	out := new(Country)
+	err := c.cc.Invoke(ctx, "/earth.v1.EarthService/UpdateCountry", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *earthServiceClient) DeleteCountry(ctx context.Context, in *UuidRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/earth.v1.EarthService/DeleteCountry", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *earthServiceClient) ListCities(ctx context.Context, in *ListCitiesRequest, opts ...grpc.CallOption) (*ListCitiesResponse, error) {
+	out := new(ListCitiesResponse)
+	err := c.cc.Invoke(ctx, "/earth.v1.EarthService/ListCities", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *earthServiceClient) StreamCities(ctx context.Context, in *ListCitiesRequest, opts ...grpc.CallOption) (EarthService_StreamCitiesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &EarthService_ServiceDesc.Streams[0], "/earth.v1.EarthService/StreamCities", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &earthServiceStreamCitiesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type EarthService_StreamCitiesClient interface {
+	Recv() (*City, error)
+	grpc.ClientStream
+}
+
+type earthServiceStreamCitiesClient struct {
+	grpc.ClientStream
+}
+
+func (x *earthServiceStreamCitiesClient) Recv() (*City, error) {
+	m := new(City)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *earthServiceClient) GetCity(ctx context.Context, in *UuidRequest, opts ...grpc.CallOption) (*City, error) {
+	out := new(City)
+	err := c.cc.Invoke(ctx, "/earth.v1.EarthService/GetCity", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *earthServiceClient) CreateCity(ctx context.Context, in *City, opts ...grpc.CallOption) (*City, error) {
+	out := new(City)
+	err := c.cc.Invoke(ctx, "/earth.v1.EarthService/CreateCity", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *earthServiceClient) UpdateCity(ctx context.Context, in *City, opts ...grpc.CallOption) (*City, error) {
+	out := new(City)
+	err := c.cc.Invoke(ctx, "/earth.v1.EarthService/UpdateCity", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *earthServiceClient) DeleteCity(ctx context.Context, in *UuidRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/earth.v1.EarthService/DeleteCity", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EarthServiceServer is the server API for EarthService service.
+// All implementations must embed UnimplementedEarthServiceServer
+// for forward compatibility
+type EarthServiceServer interface {
+	ListContinents(context.Context, *ListContinentsRequest) (*ListContinentsResponse, error)
+	GetContinent(context.Context, *UuidRequest) (*Continent, error)
+	CreateContinent(context.Context, *Continent) (*Continent, error)
+	UpdateContinent(context.Context, *Continent) (*Continent, error)
+	DeleteContinent(context.Context, *UuidRequest) (*emptypb.Empty, error)
+	ListCountries(context.Context, *ListCountriesRequest) (*ListCountriesResponse, error)
+	GetCountry(context.Context, *UuidRequest) (*Country, error)
+	CreateCountry(context.Context, *Country) (*Country, error)
+	UpdateCountry(context.Context, *Country) (*Country, error)
+	DeleteCountry(context.Context, *UuidRequest) (*emptypb.Empty, error)
+	ListCities(context.Context, *ListCitiesRequest) (*ListCitiesResponse, error)
+	// StreamCities sends the cities of ListCities one message each, as they are read.
+	StreamCities(*ListCitiesRequest, EarthService_StreamCitiesServer) error
+	GetCity(context.Context, *UuidRequest) (*City, error)
+	CreateCity(context.Context, *City) (*City, error)
+	UpdateCity(context.Context, *City) (*City, error)
+	DeleteCity(context.Context, *UuidRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedEarthServiceServer()
+}
+
+// UnimplementedEarthServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedEarthServiceServer struct {
+}
+
+func (UnimplementedEarthServiceServer) ListContinents(context.Context, *ListContinentsRequest) (*ListContinentsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListContinents not implemented")
+}
+func (UnimplementedEarthServiceServer) GetContinent(context.Context, *UuidRequest) (*Continent, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetContinent not implemented")
+}
+func (UnimplementedEarthServiceServer) CreateContinent(context.Context, *Continent) (*Continent, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateContinent not implemented")
+}
+func (UnimplementedEarthServiceServer) UpdateContinent(context.Context, *Continent) (*Continent, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateContinent not implemented")
+}
+func (UnimplementedEarthServiceServer) DeleteContinent(context.Context, *UuidRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteContinent not implemented")
+}
+func (UnimplementedEarthServiceServer) ListCountries(context.Context, *ListCountriesRequest) (*ListCountriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCountries not implemented")
+}
+func (UnimplementedEarthServiceServer) GetCountry(context.Context, *UuidRequest) (*Country, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCountry not implemented")
+}
+func (UnimplementedEarthServiceServer) CreateCountry(context.Context, *Country) (*Country, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCountry not implemented")
+}
+func (UnimplementedEarthServiceServer) UpdateCountry(context.Context, *Country) (*Country, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCountry not implemented")
+}
+func (UnimplementedEarthServiceServer) DeleteCountry(context.Context, *UuidRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCountry not implemented")
+}
+func (UnimplementedEarthServiceServer) ListCities(context.Context, *ListCitiesRequest) (*ListCitiesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCities not implemented")
+}
+func (UnimplementedEarthServiceServer) StreamCities(*ListCitiesRequest, EarthService_StreamCitiesServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamCities not implemented")
+}
+func (UnimplementedEarthServiceServer) GetCity(context.Context, *UuidRequest) (*City, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCity not implemented")
+}
+func (UnimplementedEarthServiceServer) CreateCity(context.Context, *City) (*City, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCity not implemented")
+}
+func (UnimplementedEarthServiceServer) UpdateCity(context.Context, *City) (*City, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCity not implemented")
+}
+func (UnimplementedEarthServiceServer) DeleteCity(context.Context, *UuidRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCity not implemented")
+}
+func (UnimplementedEarthServiceServer) mustEmbedUnimplementedEarthServiceServer() {}
+
+// UnsafeEarthServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EarthServiceServer will
+// result in compilation errors.
+type UnsafeEarthServiceServer interface {
+	mustEmbedUnimplementedEarthServiceServer()
+}
+
+func RegisterEarthServiceServer(s grpc.ServiceRegistrar, srv EarthServiceServer) {
+	s.RegisterService(&EarthService_ServiceDesc, srv)
+}
+
+func _EarthService_ListContinents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListContinentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EarthServiceServer).ListContinents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/earth.v1.EarthService/ListContinents",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EarthServiceServer).ListContinents(ctx, req.(*ListContinentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EarthService_GetContinent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UuidRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EarthServiceServer).GetContinent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/earth.v1.EarthService/GetContinent",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EarthServiceServer).GetContinent(ctx, req.(*UuidRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EarthService_CreateContinent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Continent)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EarthServiceServer).CreateContinent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/earth.v1.EarthService/CreateContinent",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EarthServiceServer).CreateContinent(ctx, req.(*Continent))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EarthService_UpdateContinent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Continent)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EarthServiceServer).UpdateContinent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/earth.v1.EarthService/UpdateContinent",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EarthServiceServer).UpdateContinent(ctx, req.(*Continent))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EarthService_DeleteContinent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UuidRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EarthServiceServer).DeleteContinent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/earth.v1.EarthService/DeleteContinent",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EarthServiceServer).DeleteContinent(ctx, req.(*UuidRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EarthService_ListCountries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCountriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EarthServiceServer).ListCountries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/earth.v1.EarthService/ListCountries",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EarthServiceServer).ListCountries(ctx, req.(*ListCountriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EarthService_GetCountry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UuidRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EarthServiceServer).GetCountry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/earth.v1.EarthService/GetCountry",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EarthServiceServer).GetCountry(ctx, req.(*UuidRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EarthService_CreateCountry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Country)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EarthServiceServer).CreateCountry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/earth.v1.EarthService/CreateCountry",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EarthServiceServer).CreateCountry(ctx, req.(*Country))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EarthService_UpdateCountry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Country)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EarthServiceServer).UpdateCountry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/earth.v1.EarthService/UpdateCountry",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EarthServiceServer).UpdateCountry(ctx, req.(*Country))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EarthService_DeleteCountry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UuidRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EarthServiceServer).DeleteCountry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/earth.v1.EarthService/DeleteCountry",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EarthServiceServer).DeleteCountry(ctx, req.(*UuidRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EarthService_ListCities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCitiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EarthServiceServer).ListCities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/earth.v1.EarthService/ListCities",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EarthServiceServer).ListCities(ctx, req.(*ListCitiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EarthService_StreamCities_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListCitiesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EarthServiceServer).StreamCities(m, &earthServiceStreamCitiesServer{stream})
+}
+
+type EarthService_StreamCitiesServer interface {
+	Send(*City) error
+	grpc.ServerStream
+}
+
+type earthServiceStreamCitiesServer struct {
+	grpc.ServerStream
+}
+
+func (x *earthServiceStreamCitiesServer) Send(m *City) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _EarthService_GetCity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UuidRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EarthServiceServer).GetCity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/earth.v1.EarthService/GetCity",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EarthServiceServer).GetCity(ctx, req.(*UuidRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EarthService_CreateCity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(City)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EarthServiceServer).CreateCity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/earth.v1.EarthService/CreateCity",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EarthServiceServer).CreateCity(ctx, req.(*City))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EarthService_UpdateCity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(City)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EarthServiceServer).UpdateCity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/earth.v1.EarthService/UpdateCity",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EarthServiceServer).UpdateCity(ctx, req.(*City))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EarthService_DeleteCity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UuidRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EarthServiceServer).DeleteCity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/earth.v1.EarthService/DeleteCity",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EarthServiceServer).DeleteCity(ctx, req.(*UuidRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// EarthService_ServiceDesc is the grpc.ServiceDesc for EarthService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var EarthService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "earth.v1.EarthService",
+	HandlerType: (*EarthServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListContinents",
+			Handler:    _EarthService_ListContinents_Handler,
+		},
+		{
+			MethodName: "GetContinent",
+			Handler:    _EarthService_GetContinent_Handler,
+		},
+		{
+			MethodName: "CreateContinent",
+			Handler:    _EarthService_CreateContinent_Handler,
+		},
+		{
+			MethodName: "UpdateContinent",
+			Handler:    _EarthService_UpdateContinent_Handler,
+		},
+		{
+			MethodName: "DeleteContinent",
+			Handler:    _EarthService_DeleteContinent_Handler,
+		},
+		{
+			MethodName: "ListCountries",
+			Handler:    _EarthService_ListCountries_Handler,
+		},
+		{
+			MethodName: "GetCountry",
+			Handler:    _EarthService_GetCountry_Handler,
+		},
+		{
+			MethodName: "CreateCountry",
+			Handler:    _EarthService_CreateCountry_Handler,
+		},
+		{
+			MethodName: "UpdateCountry",
+			Handler:    _EarthService_UpdateCountry_Handler,
+		},
+		{
+			MethodName: "DeleteCountry",
+			Handler:    _EarthService_DeleteCountry_Handler,
+		},
+		{
+			MethodName: "ListCities",
+			Handler:    _EarthService_ListCities_Handler,
+		},
+		{
+			MethodName: "GetCity",
+			Handler:    _EarthService_GetCity_Handler,
+		},
+		{
+			MethodName: "CreateCity",
+			Handler:    _EarthService_CreateCity_Handler,
+		},
+		{
+			MethodName: "UpdateCity",
+			Handler:    _EarthService_UpdateCity_Handler,
+		},
+		{
+			MethodName: "DeleteCity",
+			Handler:    _EarthService_DeleteCity_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamCities",
+			Handler:       _EarthService_StreamCities_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "earth.proto",
+}
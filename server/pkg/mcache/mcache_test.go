@@ -0,0 +1,58 @@
+package mcache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewLRU(2, time.Minute)
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	cache.Set("c", 3)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("expected %s to be cached", key)
+		}
+	}
+	if cache.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", cache.Len())
+	}
+}
+
+func TestLRUExpires(t *testing.T) {
+	cache := NewLRU(2, 10*time.Millisecond)
+
+	cache.Set("a", 1)
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := cache.Get("a"); ok {
+		t.Error("expected a to be expired")
+	}
+	if cache.Len() != 0 {
+		t.Errorf("expected expired entry to be removed, got %d", cache.Len())
+	}
+}
+
+func TestLRUPurgeAndDisabled(t *testing.T) {
+	cache := NewLRU(2, time.Minute)
+	cache.Set("a", 1)
+	cache.Purge()
+	if _, ok := cache.Get("a"); ok {
+		t.Error("expected a to be purged")
+	}
+
+	disabled := NewLRU(0, time.Minute)
+	disabled.Set("a", 1)
+	if _, ok := disabled.Get("a"); ok || disabled.Enabled() {
+		t.Error("expected zero capacity to disable the cache")
+	}
+}
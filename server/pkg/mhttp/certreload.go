@@ -0,0 +1,86 @@
+package mhttp
+
+import (
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+)
+
+// Interval between stat calls on the certificate files.
+const certReloadInterval = 10 * time.Second
+
+// CertReloader serves a key pair from disk and reloads it when either file
+// changes, so rotated certificates are picked up without restart.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+func NewCertReloader(certFile string, keyFile string) (*CertReloader, error) {
+	reloader := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := reloader.reload(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checked) >= certReloadInterval {
+		r.checked = time.Now()
+		if mod_time, err := r.latestModTime(); err == nil && mod_time.After(r.modTime) {
+			if err := r.load(mod_time); err != nil {
+				// keep serving the previous certificate
+				Log.Warnf("[mhttp] Certificate reload error %s", err.Error())
+			} else {
+				Log.Infof("[mhttp] Certificate %s reloaded", r.certFile)
+			}
+		}
+	}
+
+	return r.cert, nil
+}
+
+func (r *CertReloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	mod_time, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+	r.checked = time.Now()
+	return r.load(mod_time)
+}
+
+func (r *CertReloader) load(mod_time time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert = &cert
+	r.modTime = mod_time
+	return nil
+}
+
+func (r *CertReloader) latestModTime() (time.Time, error) {
+	latest := time.Time{}
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return latest, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
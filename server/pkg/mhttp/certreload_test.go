@@ -0,0 +1,117 @@
+package mhttp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeKeyPair writes a self-signed certificate for name and its key, both
+// modified at mod_time.
+func writeKeyPair(t *testing.T, cert_file string, key_file string, name string, mod_time time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	cert_der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key_der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for file, block := range map[string]*pem.Block{
+		cert_file: {Type: "CERTIFICATE", Bytes: cert_der},
+		key_file:  {Type: "EC PRIVATE KEY", Bytes: key_der},
+	} {
+		if err := ioutil.WriteFile(file, pem.EncodeToMemory(block), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, mod_time, mod_time); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func servedName(t *testing.T, reloader *CertReloader) string {
+	cert, err := reloader.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloaderPicksUpRotation(t *testing.T) {
+	var (
+		dir       = t.TempDir()
+		cert_file = filepath.Join(dir, "tls.crt")
+		key_file  = filepath.Join(dir, "tls.key")
+		issued    = time.Now().Add(-time.Hour)
+	)
+	writeKeyPair(t, cert_file, key_file, "first.earth.test", issued)
+
+	reloader, err := NewCertReloader(cert_file, key_file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := servedName(t, reloader); name != "first.earth.test" {
+		t.Fatalf("served %s, expected first.earth.test", name)
+	}
+
+	writeKeyPair(t, cert_file, key_file, "second.earth.test", issued.Add(time.Minute))
+
+	// files are only checked once per interval
+	if name := servedName(t, reloader); name != "first.earth.test" {
+		t.Errorf("served %s within the check interval, expected first.earth.test", name)
+	}
+
+	reloader.checked = time.Now().Add(-certReloadInterval)
+	if name := servedName(t, reloader); name != "second.earth.test" {
+		t.Errorf("served %s after rotation, expected second.earth.test", name)
+	}
+
+	// a rotation caught half way, new certificate with the previous key,
+	// keeps serving the last valid pair
+	key_b, err := ioutil.ReadFile(key_file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeKeyPair(t, cert_file, key_file, "third.earth.test", issued.Add(2*time.Minute))
+	if err := ioutil.WriteFile(key_file, key_b, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	reloader.checked = time.Now().Add(-certReloadInterval)
+	if name := servedName(t, reloader); name != "second.earth.test" {
+		t.Errorf("served %s after a mismatched rotation, expected second.earth.test", name)
+	}
+}
+
+func TestCertReloaderMissingFiles(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewCertReloader(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")); err == nil {
+		t.Error("expected error for missing certificate files")
+	}
+}
@@ -34,10 +34,30 @@ func WriteBadRequest(w http.ResponseWriter, http_err interface{}) error {
 	return WriteJSON(w, http.StatusBadRequest, http_err)
 }
 
+func WriteNotFound(w http.ResponseWriter, http_err interface{}) error {
+	return WriteJSON(w, http.StatusNotFound, http_err)
+}
+
+func WriteConflict(w http.ResponseWriter, http_err interface{}) error {
+	return WriteJSON(w, http.StatusConflict, http_err)
+}
+
+func WriteUnprocessableEntity(w http.ResponseWriter, http_err interface{}) error {
+	return WriteJSON(w, http.StatusUnprocessableEntity, http_err)
+}
+
 func WriteInternalServerError(w http.ResponseWriter, http_err interface{}) error {
 	return WriteJSON(w, http.StatusInternalServerError, http_err)
 }
 
+func WriteGatewayTimeout(w http.ResponseWriter, http_err interface{}) error {
+	return WriteJSON(w, http.StatusGatewayTimeout, http_err)
+}
+
+func WriteServiceUnavailable(w http.ResponseWriter, http_err interface{}) error {
+	return WriteJSON(w, http.StatusServiceUnavailable, http_err)
+}
+
 func Query(r *http.Request, query string) string {
 	if r == nil {
 		return ""
@@ -0,0 +1,94 @@
+package mhttp
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const rateLimitIdleExpiry = 10 * time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter is a token bucket per key (usually the client ip).
+// A limit of 0 requests per second disables it.
+type RateLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   int
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	limiter := &RateLimiter{buckets: map[string]*bucket{}}
+	limiter.SetLimit(rate, burst)
+	return limiter
+}
+
+func (l *RateLimiter) SetLimit(rate float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if burst < 1 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+	l.rate = rate
+	l.burst = burst
+}
+
+func (l *RateLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 {
+		return true
+	}
+
+	now := time.Now()
+	l.sweep(now)
+
+	curr := l.buckets[key]
+	if curr == nil {
+		curr = &bucket{tokens: float64(l.burst), last: now}
+		l.buckets[key] = curr
+	}
+
+	curr.tokens = math.Min(float64(l.burst), curr.tokens+now.Sub(curr.last).Seconds()*l.rate)
+	curr.last = now
+
+	if curr.tokens < 1 {
+		return false
+	}
+	curr.tokens--
+	return true
+}
+
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < rateLimitIdleExpiry {
+		return
+	}
+	for key, iter := range l.buckets {
+		if now.Sub(iter.last) > rateLimitIdleExpiry {
+			delete(l.buckets, key)
+		}
+	}
+	l.swept = now
+}
+
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func WriteTooManyRequests(w http.ResponseWriter, http_err interface{}) error {
+	return WriteJSON(w, http.StatusTooManyRequests, http_err)
+}
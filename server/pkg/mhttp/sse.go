@@ -0,0 +1,65 @@
+package mhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WriteEventStreamHeaders starts a text/event-stream response. retry is the
+// reconnect delay sent to EventSource clients.
+func WriteEventStreamHeaders(w http.ResponseWriter, retry time.Duration) error {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// disable proxy buffering (nginx)
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	_, err := fmt.Fprintf(w, "retry: %d\n\n", retry.Milliseconds())
+	return err
+}
+
+// WriteEvent writes one server-sent event with data encoded as JSON.
+func WriteEvent(w http.ResponseWriter, id string, event string, data interface{}) error {
+	data_b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	var builder strings.Builder
+	if len(id) > 0 {
+		fmt.Fprintf(&builder, "id: %s\n", id)
+	}
+	if len(event) > 0 {
+		fmt.Fprintf(&builder, "event: %s\n", event)
+	}
+	fmt.Fprintf(&builder, "data: %s\n\n", data_b)
+
+	_, err = w.Write([]byte(builder.String()))
+	return err
+}
+
+// WriteEventComment writes a comment line, used as keep-alive.
+func WriteEventComment(w http.ResponseWriter, comment string) error {
+	_, err := fmt.Fprintf(w, ": %s\n\n", comment)
+	return err
+}
+
+// ClearWriteDeadline lifts the server WriteTimeout for a long lived response,
+// through response writers of middlewares that have an Unwrap method.
+func ClearWriteDeadline(w http.ResponseWriter) error {
+	for {
+		switch curr := w.(type) {
+		case interface{ SetWriteDeadline(time.Time) error }:
+			return curr.SetWriteDeadline(time.Time{})
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = curr.Unwrap()
+		default:
+			return errors.New("write deadline is not supported")
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package mmetrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Latency buckets in seconds, same defaults as the prometheus client.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type Collector interface {
+	WriteText(w io.Writer) error
+}
+
+////////////////////////
+/////// Registry
+
+type Registry struct {
+	mu         sync.Mutex
+	collectors []Collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) Register(collectors ...Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, collectors...)
+}
+
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	collectors := append([]Collector{}, r.collectors...)
+	r.mu.Unlock()
+
+	buffered := bufio.NewWriter(w)
+	for _, iter := range collectors {
+		if err := iter.WriteText(buffered); err != nil {
+			return err
+		}
+	}
+	return buffered.Flush()
+}
+
+////////////////////////
+/////// Series
+
+type series struct {
+	labels []string
+	values map[string][]string
+}
+
+func newSeries(labels []string) series {
+	return series{labels: labels, values: map[string][]string{}}
+}
+
+func (s *series) key(values []string) string {
+	if len(values) != len(s.labels) {
+		panic(fmt.Sprintf("mmetrics: expected %d label values, got %d", len(s.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	if _, ok := s.values[key]; !ok {
+		s.values[key] = append([]string{}, values...)
+	}
+	return key
+}
+
+func (s *series) sortedKeys() []string {
+	keys := make([]string, 0, len(s.values))
+	for key := range s.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *series) format(key string, extra ...string) string {
+	pairs := []string{}
+	for i, name := range s.labels {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, escapeLabel(s.values[key][i])))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extra[i], escapeLabel(extra[i+1])))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func escapeLabel(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	return strings.ReplaceAll(value, `"`, `\"`)
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func writeHeader(w io.Writer, name string, help string, kind string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	return err
+}
+
+////////////////////////
+/////// Counter & Gauge
+
+type CounterVec struct {
+	name string
+	help string
+
+	mu     sync.Mutex
+	series series
+	counts map[string]float64
+}
+
+func NewCounterVec(name string, help string, labels ...string) *CounterVec {
+	return &CounterVec{
+		name:   name,
+		help:   help,
+		series: newSeries(labels),
+		counts: map[string]float64{},
+	}
+}
+
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+func (c *CounterVec) Add(delta float64, values ...string) {
+	if delta < 0 {
+		panic("mmetrics: counter cannot decrease")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[c.series.key(values)] += delta
+}
+
+func (c *CounterVec) WriteText(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return writeValues(w, c.name, c.help, "counter", &c.series, c.counts)
+}
+
+type GaugeVec struct {
+	name string
+	help string
+
+	mu     sync.Mutex
+	series series
+	values map[string]float64
+}
+
+func NewGaugeVec(name string, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{
+		name:   name,
+		help:   help,
+		series: newSeries(labels),
+		values: map[string]float64{},
+	}
+}
+
+func (g *GaugeVec) Set(value float64, values ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.values[g.series.key(values)] = value
+}
+
+func (g *GaugeVec) Add(delta float64, values ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.values[g.series.key(values)] += delta
+}
+
+func (g *GaugeVec) WriteText(w io.Writer) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return writeValues(w, g.name, g.help, "gauge", &g.series, g.values)
+}
+
+func writeValues(w io.Writer, name string, help string, kind string, s *series, values map[string]float64) error {
+	if err := writeHeader(w, name, help, kind); err != nil {
+		return err
+	}
+	for _, key := range s.sortedKeys() {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", name, s.format(key), formatFloat(values[key])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+////////////////////////
+/////// Histogram
+
+type histogramValue struct {
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+type HistogramVec struct {
+	name    string
+	help    string
+	buckets []float64
+
+	mu     sync.Mutex
+	series series
+	values map[string]*histogramValue
+}
+
+func NewHistogramVec(name string, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	sorted := append([]float64{}, buckets...)
+	sort.Float64s(sorted)
+
+	return &HistogramVec{
+		name:    name,
+		help:    help,
+		buckets: sorted,
+		series:  newSeries(labels),
+		values:  map[string]*histogramValue{},
+	}
+}
+
+func (h *HistogramVec) Observe(value float64, values ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := h.series.key(values)
+	curr := h.values[key]
+	if curr == nil {
+		curr = &histogramValue{buckets: make([]uint64, len(h.buckets))}
+		h.values[key] = curr
+	}
+
+	for i, bound := range h.buckets {
+		if value <= bound {
+			curr.buckets[i]++
+		}
+	}
+	curr.count++
+	curr.sum += value
+}
+
+func (h *HistogramVec) WriteText(w io.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := writeHeader(w, h.name, h.help, "histogram"); err != nil {
+		return err
+	}
+	for _, key := range h.series.sortedKeys() {
+		curr := h.values[key]
+		for i, bound := range h.buckets {
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.series.format(key, "le", formatFloat(bound)), curr.buckets[i]); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.series.format(key, "le", "+Inf"), curr.count); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.series.format(key), formatFloat(curr.sum)); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.series.format(key), curr.count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
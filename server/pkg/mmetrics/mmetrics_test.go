@@ -0,0 +1,41 @@
+package mmetrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	var (
+		registry  = NewRegistry()
+		counter   = NewCounterVec("requests_total", "Total requests.", "route")
+		histogram = NewHistogramVec("latency_seconds", "Latency.", []float64{0.1, 1}, "op")
+		buffer    = &bytes.Buffer{}
+	)
+	registry.Register(counter, histogram)
+
+	counter.Inc(`/api/"v1"`)
+	counter.Add(2, `/api/"v1"`)
+	histogram.Observe(0.05, "ContinentByUuid")
+	histogram.Observe(0.5, "ContinentByUuid")
+
+	if err := registry.WriteText(buffer); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{
+		"# TYPE requests_total counter",
+		`requests_total{route="/api/\"v1\""} 3`,
+		"# TYPE latency_seconds histogram",
+		`latency_seconds_bucket{op="ContinentByUuid",le="0.1"} 1`,
+		`latency_seconds_bucket{op="ContinentByUuid",le="1"} 2`,
+		`latency_seconds_bucket{op="ContinentByUuid",le="+Inf"} 2`,
+		`latency_seconds_sum{op="ContinentByUuid"} 0.55`,
+		`latency_seconds_count{op="ContinentByUuid"} 2`,
+	} {
+		if !strings.Contains(buffer.String(), line+"\n") {
+			t.Errorf("missing line %q in:\n%s", line, buffer.String())
+		}
+	}
+}
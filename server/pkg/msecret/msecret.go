@@ -0,0 +1,62 @@
+package msecret
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+const Mask = "****"
+
+// Redact hides a secret entirely.
+func Redact(secret string) string {
+	if len(secret) == 0 {
+		return ""
+	}
+	return Mask
+}
+
+// RedactPartial keeps at most the first 3 characters, a third of the value,
+// so identifiers such as usernames stay recognizable in logs.
+func RedactPartial(value string) string {
+	if len(value) == 0 {
+		return ""
+	}
+	keep := len(value) / 3
+	if keep > 3 {
+		keep = 3
+	}
+	return value[0:keep] + "..."
+}
+
+var dsnSecret = regexp.MustCompile(`(^|\s)(user|password|dbname)=('(?:[^'\\]|\\.)*'|\S*)`)
+
+// RedactDSN masks the password of a libpq key=value connection string, user
+// and dbname are kept partially as by RedactPartial.
+func RedactDSN(dsn string) string {
+	return dsnSecret.ReplaceAllStringFunc(dsn, func(match string) string {
+		var (
+			groups = dsnSecret.FindStringSubmatch(match)
+			key    = groups[1] + groups[2]
+			value  = groups[3]
+		)
+		if groups[2] == "password" {
+			return key + "=" + Mask
+		}
+		if len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+			value = strings.NewReplacer(`\\`, `\`, `\'`, "'").Replace(value[1 : len(value)-1])
+		}
+		return key + "=" + RedactPartial(value)
+	})
+}
+
+// FromFile reads a secret mounted as a file (Docker or Kubernetes secrets),
+// without the trailing newline.
+func FromFile(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read secret file: %s", err.Error())
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
@@ -0,0 +1,59 @@
+package msecret
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRedactPartial(t *testing.T) {
+	for value, expected := range map[string]string{
+		"":                "",
+		"a":               "...",
+		"ab":              "...",
+		"abc":             "a...",
+		"postgres":        "po...",
+		"earth_rest_user": "ear...",
+	} {
+		if result := RedactPartial(value); result != expected {
+			t.Errorf("RedactPartial(%q) = %q, expected %q", value, result, expected)
+		}
+	}
+}
+
+func TestRedactDSN(t *testing.T) {
+	for dsn, expected := range map[string]string{
+		"host=localhost password=s3cret dbname=earth":                   "host=localhost password=**** dbname=e...",
+		`host=localhost password='s3 cr\'et' dbname=earth`:              "host=localhost password=**** dbname=e...",
+		"host=localhost dbname=earth":                                   "host=localhost dbname=e...",
+		"host=localhost user=earth_rest_user password= sslmode=disable": "host=localhost user=ear... password=**** sslmode=disable",
+		`user='earth user' dbname='earth\'s' sslrootcert=/etc/user=ca`:  "user=ear... dbname=ea... sslrootcert=/etc/user=ca",
+		"user=' dbname=earth":                                           "user=... dbname=e...",
+	} {
+		if result := RedactDSN(dsn); result != expected {
+			t.Errorf("RedactDSN(%q) = %q, expected %q", dsn, result, expected)
+		}
+	}
+}
+
+func TestFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "msecret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "db_password")
+	if err := ioutil.WriteFile(path, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := FromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret != "s3cret" {
+		t.Errorf("FromFile = %q, expected %q", secret, "s3cret")
+	}
+}
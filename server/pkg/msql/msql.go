@@ -10,7 +10,7 @@ import (
 	"strconv"
 	"strings"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 
 	mstring "github.com/nhht77/earth-rest-api/server/pkg/mstring"
 )
@@ -39,6 +39,25 @@ const (
 	SoftDeleted DeletedState = 1
 )
 
+// StringList is a text[] column, nil is stored as an empty array.
+type StringList []string
+
+func (list StringList) Value() (driver.Value, error) {
+	if list == nil {
+		list = StringList{}
+	}
+	return pq.StringArray(list).Value()
+}
+
+func (list *StringList) Scan(src interface{}) error {
+	array := pq.StringArray{}
+	if err := array.Scan(src); err != nil {
+		return err
+	}
+	*list = StringList(array)
+	return nil
+}
+
 //////////////////////////
 /////// Basic DB function
 
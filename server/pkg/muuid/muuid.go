@@ -23,6 +23,21 @@ func UUIDNil(uuid UUID) bool {
 	return uuid == _uuid.Nil
 }
 
+// UUIDCheck rejects the nil UUID and UUIDs that are not of the RFC 4122
+// variant with a version 1 to 8 (RFC 9562 adds 6, 7 & 8 to RFC 4122's 1 to 5).
+func UUIDCheck(uuid UUID) error {
+	if UUIDNil(uuid) {
+		return fmt.Errorf("nil UUID")
+	}
+	if uuid.Variant() != _uuid.VariantRFC4122 {
+		return fmt.Errorf("UUID %s is not RFC 4122", uuid.String())
+	}
+	if version := uuid.Version(); version < 1 || version > 8 {
+		return fmt.Errorf("UUID %s has unsupported version %d", uuid.String(), version)
+	}
+	return nil
+}
+
 func UUIDFromString(uuid string) (UUID, error) {
 	if len(uuid) == 0 {
 		return _uuid.Nil, fmt.Errorf("empty UUID")
@@ -0,0 +1,41 @@
+package muuid
+
+import (
+	"testing"
+
+	_uuid "gopkg.in/satori/go.uuid.v1"
+)
+
+func TestUUIDCheck(t *testing.T) {
+	for _, value := range []string{
+		// version 1
+		"6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+		NewUUID().String(),
+		// version 6
+		"1ec9414c-232a-6b00-b3c8-9f6bdeced846",
+		// version 7
+		"017f22e2-79b0-7cc3-98c4-dc0c0c07398f",
+		// version 8
+		"2489e9ad-2ee2-8e00-8ec9-32d5f69181c0",
+	} {
+		if err := UUIDCheck(_uuid.FromStringOrNil(value)); err != nil {
+			t.Errorf("expected %s to be accepted: %s", value, err.Error())
+		}
+	}
+
+	for _, value := range []string{
+		"00000000-0000-0000-0000-000000000000",
+		// variant bits 0xx (NCS)
+		"6ba7b810-9dad-11d1-00b4-00c04fd430c8",
+		// version 0
+		"6ba7b810-9dad-01d1-80b4-00c04fd430c8",
+		// version 9
+		"6ba7b810-9dad-91d1-80b4-00c04fd430c8",
+		// version 7 with the Microsoft variant
+		"017f22e2-79b0-7cc3-c8c4-dc0c0c07398f",
+	} {
+		if err := UUIDCheck(_uuid.FromStringOrNil(value)); err == nil {
+			t.Errorf("expected %s to be rejected", value)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package mwebhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	HeaderEvent     = "X-Earth-Event"
+	HeaderDelivery  = "X-Earth-Delivery"
+	HeaderTimestamp = "X-Earth-Timestamp"
+	HeaderSignature = "X-Earth-Signature"
+
+	signaturePrefix = "sha256="
+
+	// response body kept on a delivery attempt
+	maxResponseBody = 1024
+)
+
+// Sign returns the X-Earth-Signature value: HMAC-SHA256 over
+// "<timestamp>.<body>" keyed with the subscription secret.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a request signed by Send, rejecting timestamps older than
+// tolerance to limit replays. Receivers may copy it.
+func Verify(r *http.Request, secret string, tolerance time.Duration) ([]byte, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return body, fmt.Errorf("invalid %s", HeaderTimestamp)
+	}
+	if tolerance > 0 && time.Since(time.Unix(timestamp, 0)) > tolerance {
+		return body, fmt.Errorf("%s too old", HeaderTimestamp)
+	}
+
+	expected := Sign(secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(r.Header.Get(HeaderSignature))) {
+		return body, fmt.Errorf("invalid %s", HeaderSignature)
+	}
+	return body, nil
+}
+
+type Message struct {
+	URL      string
+	Secret   string
+	Event    string
+	Delivery string
+	Body     []byte
+}
+
+type Result struct {
+	StatusCode int
+	Response   string
+	Duration   time.Duration
+}
+
+// Success is a 2xx response, anything else is retried.
+func (result Result) Success() bool {
+	return result.StatusCode >= 200 && result.StatusCode < 300
+}
+
+// Send POSTs the signed message. err is set when no response was received.
+func Send(ctx context.Context, client *http.Client, message Message) (Result, error) {
+	var (
+		result  = Result{}
+		started = time.Now()
+	)
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, message.URL, bytes.NewReader(message.Body))
+	if err != nil {
+		return result, err
+	}
+
+	timestamp := time.Now().Unix()
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(HeaderEvent, message.Event)
+	r.Header.Set(HeaderDelivery, message.Delivery)
+	r.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	r.Header.Set(HeaderSignature, Sign(message.Secret, timestamp, message.Body))
+
+	response, err := client.Do(r)
+	result.Duration = time.Since(started)
+	if err != nil {
+		return result, err
+	}
+	defer response.Body.Close()
+
+	body, _ := ioutil.ReadAll(io.LimitReader(response.Body, maxResponseBody))
+	result.StatusCode = response.StatusCode
+	result.Response = strings.TrimSpace(CleanText(string(body)))
+	return result, nil
+}
+
+// CleanText drops what a PostgreSQL text value cannot hold: invalid UTF-8,
+// like a character cut at maxResponseBody, and NUL bytes.
+func CleanText(text string) string {
+	return strings.ReplaceAll(strings.ToValidUTF8(text, ""), "\x00", "")
+}
+
+// Backoff is the delay before retry number attempt (1 based): base doubled
+// per attempt, capped at max.
+func Backoff(attempt int, base time.Duration, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// MatchEvent reports whether event ("city.update") is selected by filters,
+// each filter is an event or uses "*" for entity or operation. No filter
+// selects every event.
+func MatchEvent(filters []string, event string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+
+	entity, operation := splitEvent(event)
+	for _, iter := range filters {
+		if iter == "*" || iter == event {
+			return true
+		}
+		filter_entity, filter_operation := splitEvent(iter)
+		if (filter_entity == "*" || filter_entity == entity) && (filter_operation == "*" || filter_operation == operation) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitEvent(event string) (string, string) {
+	parts := strings.SplitN(event, ".", 2)
+	if len(parts) < 2 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
@@ -0,0 +1,124 @@
+package mwebhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestSendSignsForReceiver(t *testing.T) {
+	var (
+		secret   = "receiver-secret"
+		received = make(chan string, 1)
+	)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := Verify(r, secret, time.Minute)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		received <- r.Header.Get(HeaderEvent) + " " + string(body)
+		w.Write([]byte("ok"))
+	}))
+	defer receiver.Close()
+
+	message := Message{
+		URL:      receiver.URL,
+		Secret:   secret,
+		Event:    "city.insert",
+		Delivery: "d1",
+		Body:     []byte(`{"name":"Helsinki"}`),
+	}
+
+	result, err := Send(context.Background(), receiver.Client(), message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success() || result.Response != "ok" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if got := <-received; got != `city.insert {"name":"Helsinki"}` {
+		t.Errorf("receiver got %q", got)
+	}
+
+	message.Secret = "wrong-secret"
+	result, err = Send(context.Background(), receiver.Client(), message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success() || result.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a wrong secret, got %d", result.StatusCode)
+	}
+}
+
+func TestSendUnreachable(t *testing.T) {
+	receiver := httptest.NewServer(http.NotFoundHandler())
+	url := receiver.URL
+	receiver.Close()
+
+	if _, err := Send(context.Background(), http.DefaultClient, Message{URL: url}); err == nil {
+		t.Error("expected error for a closed receiver")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for _, iter := range []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{4, 80 * time.Second},
+		{20, time.Hour},
+	} {
+		if delay := Backoff(iter.attempt, 10*time.Second, time.Hour); delay != iter.expected {
+			t.Errorf("Backoff(%d) = %s, expected %s", iter.attempt, delay, iter.expected)
+		}
+	}
+}
+
+func TestMatchEvent(t *testing.T) {
+	for _, iter := range []struct {
+		filters  []string
+		event    string
+		expected bool
+	}{
+		{nil, "city.insert", true},
+		{[]string{"*"}, "city.insert", true},
+		{[]string{"city.insert"}, "city.insert", true},
+		{[]string{"city.*"}, "city.delete", true},
+		{[]string{"*.delete"}, "country.delete", true},
+		{[]string{"country.*"}, "city.insert", false},
+		{[]string{"city.update", "*.delete"}, "city.insert", false},
+	} {
+		if matched := MatchEvent(iter.filters, iter.event); matched != iter.expected {
+			t.Errorf("MatchEvent(%v, %s) = %t, expected %t", iter.filters, iter.event, matched, iter.expected)
+		}
+	}
+}
+
+func TestSendCleansResponse(t *testing.T) {
+	// a 3 byte character cut by maxResponseBody, and a NUL byte
+	body := strings.Repeat("a", maxResponseBody-1) + "€"
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("nul\x00" + body))
+	}))
+	defer receiver.Close()
+
+	result, err := Send(context.Background(), receiver.Client(), Message{URL: receiver.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !utf8.ValidString(result.Response) || strings.ContainsRune(result.Response, 0) {
+		t.Errorf("response %q is not storable text", result.Response)
+	}
+	if !strings.HasPrefix(result.Response, "nulaaa") {
+		t.Errorf("response %q lost its text", result.Response[:10])
+	}
+}
@@ -0,0 +1,109 @@
+package mwebhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress is returned for a webhook host that resolves to an
+// address of the server's own networks.
+var ErrPrivateAddress = errors.New("webhook address is not public")
+
+// blocked networks besides loopback, link-local, multicast & unspecified
+var privateNetworks = func() []*net.IPNet {
+	networks := []*net.IPNet{}
+	for _, cidr := range []string{
+		"0.0.0.0/8",      // this network
+		"10.0.0.0/8",     // RFC 1918
+		"100.64.0.0/10",  // RFC 6598 shared address space, also cloud metadata
+		"172.16.0.0/12",  // RFC 1918
+		"192.168.0.0/16", // RFC 1918
+		"fc00::/7",       // RFC 4193 unique local, also cloud metadata
+	} {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// CheckIP rejects loopback, private (RFC 1918 & 4193), link-local (cloud
+// metadata 169.254.169.254 included), multicast & unspecified addresses.
+func CheckIP(ip net.IP) error {
+	if ip == nil {
+		return fmt.Errorf("%w: invalid address", ErrPrivateAddress)
+	}
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, ip)
+	}
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return fmt.Errorf("%w: %s", ErrPrivateAddress, ip)
+		}
+	}
+	return nil
+}
+
+// CheckURL resolves the host of raw_url and checks every address it has.
+func CheckURL(ctx context.Context, raw_url string) error {
+	target, err := url.Parse(raw_url)
+	if err != nil {
+		return err
+	}
+
+	host := target.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		return CheckIP(ip)
+	}
+
+	addresses, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, iter := range addresses {
+		if err := CheckIP(iter.IP); err != nil {
+			return fmt.Errorf("%s: %w", host, err)
+		}
+	}
+	return nil
+}
+
+// NewClient returns the client deliveries are sent with. Unless
+// allow_private is set, its dialer checks the address each connection is
+// made to, so a host resolving to a private address after registration or a
+// redirect to one is refused.
+func NewClient(timeout time.Duration, allow_private bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allow_private {
+		dialer.Control = func(network string, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			return CheckIP(net.ParseIP(host))
+		}
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// Note: no proxy from the environment, the dialer would check the proxy
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+	}
+}
@@ -0,0 +1,78 @@
+package mwebhook
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCheckIP(t *testing.T) {
+	for _, iter := range []struct {
+		ip      string
+		allowed bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"172.31.255.255", false},
+		{"172.32.0.1", true},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00:ec2::254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+	} {
+		err := CheckIP(net.ParseIP(iter.ip))
+		if allowed := err == nil; allowed != iter.allowed {
+			t.Errorf("CheckIP(%s) = %v, expected allowed %t", iter.ip, err, iter.allowed)
+		}
+		if err != nil && !errors.Is(err, ErrPrivateAddress) {
+			t.Errorf("CheckIP(%s) = %v, expected ErrPrivateAddress", iter.ip, err)
+		}
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	for _, iter := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://[::1]/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"https://10.0.0.1/hook",
+		"http://localhost/hook",
+	} {
+		if err := CheckURL(context.Background(), iter); !errors.Is(err, ErrPrivateAddress) {
+			t.Errorf("CheckURL(%s) = %v, expected ErrPrivateAddress", iter, err)
+		}
+	}
+
+	if err := CheckURL(context.Background(), "https://93.184.216.34/hook"); err != nil {
+		t.Errorf("CheckURL of a public address = %v", err)
+	}
+}
+
+func TestNewClientRefusesPrivateDial(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+
+	_, err := Send(context.Background(), NewClient(time.Second, false), Message{URL: receiver.URL})
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Errorf("expected ErrPrivateAddress dialing %s, got %v", receiver.URL, err)
+	}
+
+	result, err := Send(context.Background(), NewClient(time.Second, true), Message{URL: receiver.URL})
+	if err != nil || !result.Success() {
+		t.Errorf("expected delivery with private networks allowed, got %+v, %v", result, err)
+	}
+}
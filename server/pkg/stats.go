@@ -0,0 +1,51 @@
+package pkg_v1
+
+import "time"
+
+////////////////////////
+/////// Stats struct
+
+type Stats struct {
+	ContinentTypes []*ContinentTypeStats `json:"continent_types"`
+
+	// live & soft-deleted rows by entity: continent, country, city
+	Rows map[string]*RowCounts `json:"rows"`
+
+	Activity *Activity `json:"activity"`
+}
+
+// ContinentTypeStats aggregates the live rows of a continent type.
+type ContinentTypeStats struct {
+	Type       ContinentType `json:"type"`
+	Continents int64         `json:"continents"`
+	Countries  int64         `json:"countries"`
+	Cities     int64         `json:"cities"`
+	AreaByKm2  float64       `json:"area_by_km2"`
+}
+
+type RowCounts struct {
+	Live    int64 `json:"live"`
+	Deleted int64 `json:"deleted"`
+}
+
+// Activity counts rows created & updated per bucket of [From, To).
+type Activity struct {
+	Bucket string    `json:"bucket"` // day, week or month
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+
+	Buckets []*ActivityBucket `json:"buckets"`
+}
+
+type ActivityBucket struct {
+	Start time.Time `json:"start"`
+
+	Continent ActivityCount `json:"continent"`
+	Country   ActivityCount `json:"country"`
+	City      ActivityCount `json:"city"`
+}
+
+type ActivityCount struct {
+	Created int64 `json:"created"`
+	Updated int64 `json:"updated"`
+}
@@ -0,0 +1,165 @@
+package pkg_v1
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nhht77/earth-rest-api/server/pkg/msql"
+	muuid "github.com/nhht77/earth-rest-api/server/pkg/muuid"
+)
+
+//////////////////////////////////
+/////// Webhook subscription struct
+
+const WebhookSecretMinLength = 16
+
+type WebhookSubscription struct {
+	Index msql.DatabaseIndex `json:"-"`
+	Uuid  muuid.UUID         `json:"uuid"`
+
+	URL string `json:"url"`
+	// only returned when the subscription is created
+	Secret string           `json:"secret,omitempty"`
+	Events WebhookEventList `json:"events"`
+
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+
+	Creator *UserMinimal `json:"creator"`
+
+	DeletedState msql.DeletedState `json:"-"`
+}
+
+// WebhookEventList filters events as "<entity>.<operation>", e.g.
+// "city.update", "country.*" or "*.delete". Empty selects every event.
+type WebhookEventList []string
+
+func (v WebhookEventList) Value() (driver.Value, error) {
+	return msql.JSONValue(v)
+}
+
+func (v *WebhookEventList) Scan(src interface{}) error {
+	return msql.JSONScan(src, v)
+}
+
+func WebhookEvent(entity ChangeEntity, operation ChangeOperation) string {
+	return fmt.Sprintf("%s.%s", entity, operation)
+}
+
+func (obj *WebhookSubscription) ValidateCreate() error {
+	if err := obj.validate(); err != nil {
+		return err
+	}
+
+	if len(obj.Secret) > 0 && len(obj.Secret) < WebhookSecretMinLength {
+		return fmt.Errorf("Webhook secret must be at least %d characters", WebhookSecretMinLength)
+	}
+
+	if obj.Creator == nil {
+		return errors.New("Empty webhook creator")
+	}
+	return obj.Creator.IsValid()
+}
+
+func (obj *WebhookSubscription) ValidateUpdate() error {
+	if !muuid.UUIDValid(obj.Uuid) {
+		return errors.New("Invalid webhook uuid")
+	}
+
+	if len(obj.Secret) > 0 && len(obj.Secret) < WebhookSecretMinLength {
+		return fmt.Errorf("Webhook secret must be at least %d characters", WebhookSecretMinLength)
+	}
+
+	return obj.validate()
+}
+
+func (obj *WebhookSubscription) validate() error {
+	target, err := url.Parse(obj.URL)
+	if err != nil || len(target.Host) == 0 || (target.Scheme != "http" && target.Scheme != "https") {
+		return errors.New("Invalid webhook url")
+	}
+
+	for _, iter := range obj.Events {
+		parts := strings.SplitN(iter, ".", 2)
+		if iter == "*" {
+			continue
+		}
+		if len(parts) != 2 {
+			return fmt.Errorf("Invalid webhook event %s", iter)
+		}
+		if entity := ChangeEntity(parts[0]); parts[0] != "*" && entity.IsValid() != nil {
+			return fmt.Errorf("Invalid webhook event %s", iter)
+		}
+		switch ChangeOperation(parts[1]) {
+		case "*", ChangeOperation_Insert, ChangeOperation_Update, ChangeOperation_Delete:
+		default:
+			return fmt.Errorf("Invalid webhook event %s", iter)
+		}
+	}
+
+	return nil
+}
+
+func (obj *WebhookSubscription) DatabaseFields() string {
+	return msql.FormatFields(
+		"index", "uuid",
+		"url", "events",
+		"creator",
+		"created", "updated", "deleted_state",
+	)
+}
+
+//////////////////////////////
+/////// Webhook delivery struct
+
+type WebhookDeliveryState int
+
+const (
+	WebhookDeliveryState_Pending   WebhookDeliveryState = 0
+	WebhookDeliveryState_Delivered WebhookDeliveryState = 1
+	// gave up after the maximum attempts, kept for inspection & manual retry
+	WebhookDeliveryState_Dead WebhookDeliveryState = 2
+)
+
+// WebhookPayload is the body POSTed to subscribers, stored in the outbox.
+type WebhookPayload struct {
+	Uuid    muuid.UUID  `json:"uuid"`
+	Event   string      `json:"event"`
+	Created time.Time   `json:"created"`
+	Data    interface{} `json:"data"`
+}
+
+type WebhookDelivery struct {
+	Index msql.DatabaseIndex `json:"-"`
+	Uuid  muuid.UUID         `json:"uuid"`
+
+	SubscriptionUuid muuid.UUID `json:"subscription_uuid"`
+	Event            string     `json:"event"`
+	EventUuid        muuid.UUID `json:"event_uuid"`
+
+	State       WebhookDeliveryState `json:"state"`
+	Attempts    int                  `json:"attempts"`
+	NextAttempt time.Time            `json:"next_attempt"`
+	LastError   string               `json:"last_error,omitempty"`
+
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+
+	AttemptList []*WebhookDeliveryAttempt `json:"attempt_list"`
+}
+
+type WebhookDeliveryAttempt struct {
+	Index msql.DatabaseIndex `json:"-"`
+
+	Attempt    int     `json:"attempt"`
+	StatusCode int     `json:"status_code"`
+	Response   string  `json:"response,omitempty"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+
+	Created time.Time `json:"created"`
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/nhht77/earth-rest-api/server/pkg/mhttp"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	RateLimiter = mhttp.NewRateLimiter(0, 0)
+
+	runtimeMu   sync.RWMutex
+	runtimeCORS CORSConfig
+)
+
+// ApplyRuntimeConfig applies the settings that may change while serving:
+// log level & format, rate limits and CORS.
+func ApplyRuntimeConfig(c *Config) {
+	if level, err := logrus.ParseLevel(c.Logging.Level); err == nil {
+		Log.SetLevel(level)
+		mhttp.Log.SetLevel(level)
+	}
+
+	if c.Logging.Format == "json" {
+		Log.SetFormatter(&logrus.JSONFormatter{})
+	} else {
+		Log.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	}
+
+	RateLimiter.SetLimit(c.RateLimit.RequestsPerSecond, c.RateLimit.Burst)
+
+	runtimeMu.Lock()
+	runtimeCORS = c.CORS
+	runtimeMu.Unlock()
+}
+
+func CurrentCORS() CORSConfig {
+	runtimeMu.RLock()
+	defer runtimeMu.RUnlock()
+	return runtimeCORS
+}
+
+// ReloadConfig re-reads file, environment and flags. Runtime settings are
+// applied, changes to settings read only at startup are logged and ignored.
+func ReloadConfig() error {
+	next, err := LoadConfig(os.Args[1:])
+	if err != nil {
+		Log.Errorf("[config] Reload rejected, keeping current config: %s", err.Error())
+		return err
+	}
+
+	if changes := AppConfig.ImmutableChanges(next); len(changes) > 0 {
+		Log.Warnf(
+			"[config] Reload ignores %s: read only at startup, restart the server to apply",
+			strings.Join(changes, ", "),
+		)
+	}
+
+	runtimeMu.Lock()
+	AppConfig.Logging = next.Logging
+	AppConfig.RateLimit = next.RateLimit
+	AppConfig.CORS = next.CORS
+	runtimeMu.Unlock()
+
+	ApplyRuntimeConfig(next)
+
+	Log.Infof(
+		"[config] Reloaded logging=%s/%s rate_limit=%g/s burst %d cors_origins=%s",
+		next.Logging.Level,
+		next.Logging.Format,
+		next.RateLimit.RequestsPerSecond,
+		next.RateLimit.Burst,
+		strings.Join(next.CORS.AllowedOrigins, ","),
+	)
+	return nil
+}
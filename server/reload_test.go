@@ -0,0 +1,145 @@
+package main_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	main "github.com/nhht77/earth-rest-api/server"
+	"github.com/sirupsen/logrus"
+)
+
+// reloadConfig writes the config file read by ReloadConfig.
+type reloadConfig struct {
+	database string
+	port     string
+	level    string
+	origin   string
+}
+
+func (c reloadConfig) write(t *testing.T, path string) {
+	content := fmt.Sprintf(`{
+		"database": {"username": "earth", "password": "earth", "database": %q},
+		"framework": {"server_port": %q},
+		"logging": {"level": %q},
+		"cors": {"allowed_origins": [%q]}
+	}`, c.database, c.port, c.level, c.origin)
+
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// startReload loads initial as the running config with the command line of
+// ReloadConfig pointing at its file. Config, runtime settings, arguments and
+// log output are restored after t.
+func startReload(t *testing.T, initial reloadConfig) (string, *bytes.Buffer) {
+	setEnv(t, map[string]string{
+		"TEST_USERNAME":     "",
+		"TEST_PASSWORD":     "",
+		"TEST_DATABASE":     "",
+		"TEST_SERVER_PORT":  "",
+		"TEST_LOG_LEVEL":    "",
+		"TEST_CORS_ORIGINS": "",
+	})
+
+	var (
+		path   = filepath.Join(t.TempDir(), "config.json")
+		args   = os.Args
+		config = main.AppConfig
+		out    = main.Log.Out
+		level  = main.Log.GetLevel()
+		output = &bytes.Buffer{}
+	)
+	t.Cleanup(func() {
+		os.Args = args
+		main.AppConfig = config
+		main.ApplyRuntimeConfig(config)
+		main.Log.Out = out
+		main.Log.SetLevel(level)
+	})
+
+	initial.write(t, path)
+	os.Args = []string{"server", "--test-build", "--config", path}
+
+	loaded, err := main.LoadConfig(os.Args[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	main.AppConfig = loaded
+	main.ApplyRuntimeConfig(loaded)
+	main.Log.Out = output
+
+	return path, output
+}
+
+func TestReloadConfigAppliesRuntimeSettings(t *testing.T) {
+	path, output := startReload(t, reloadConfig{"earth", "7001", "info", "https://old.earth.test"})
+
+	reloadConfig{"earth", "7001", "debug", "https://new.earth.test"}.write(t, path)
+	if err := main.ReloadConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	if level := main.Log.GetLevel(); level != logrus.DebugLevel {
+		t.Errorf("log level %s, expected %s", level, logrus.DebugLevel)
+	}
+	if main.AppConfig.Logging.Level != "debug" {
+		t.Errorf("AppConfig.Logging.Level = %q, expected debug", main.AppConfig.Logging.Level)
+	}
+	if origins := main.CurrentCORS().AllowedOrigins; len(origins) != 1 || origins[0] != "https://new.earth.test" {
+		t.Errorf("CORS origins %v, expected the reloaded origin", origins)
+	}
+	if strings.Contains(output.String(), "Reload ignores") {
+		t.Errorf("unexpected ignored settings:\n%s", output.String())
+	}
+}
+
+func TestReloadConfigRejectsImmutableSettings(t *testing.T) {
+	path, output := startReload(t, reloadConfig{"earth", "7001", "info", "https://old.earth.test"})
+
+	reloadConfig{"moon", "7002", "warn", "https://new.earth.test"}.write(t, path)
+	if err := main.ReloadConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	if port := main.AppConfig.Framework.ServerPort; port != "7001" {
+		t.Errorf("ServerPort = %q, expected 7001 until restart", port)
+	}
+	if database := main.AppConfig.Database.Database; database != "earth" {
+		t.Errorf("Database = %q, expected earth until restart", database)
+	}
+	for _, setting := range []string{"framework.server_port", "database connection (DSN)"} {
+		if !strings.Contains(output.String(), setting) {
+			t.Errorf("ignored %s was not logged:\n%s", setting, output.String())
+		}
+	}
+
+	// mutable settings of the same reload still apply
+	if main.AppConfig.Logging.Level != "warn" {
+		t.Errorf("AppConfig.Logging.Level = %q, expected warn", main.AppConfig.Logging.Level)
+	}
+}
+
+func TestReloadConfigKeepsCurrentOnInvalidFile(t *testing.T) {
+	path, output := startReload(t, reloadConfig{"earth", "7001", "info", "https://old.earth.test"})
+
+	reloadConfig{"earth", "7001", "loud", "https://new.earth.test"}.write(t, path)
+	if err := main.ReloadConfig(); err == nil {
+		t.Fatal("expected error on invalid log level")
+	}
+
+	if main.AppConfig.Logging.Level != "info" {
+		t.Errorf("AppConfig.Logging.Level = %q, expected info", main.AppConfig.Logging.Level)
+	}
+	if origins := main.CurrentCORS().AllowedOrigins; len(origins) != 1 || origins[0] != "https://old.earth.test" {
+		t.Errorf("CORS origins %v, expected the current origin", origins)
+	}
+	if !strings.Contains(output.String(), "Reload rejected") {
+		t.Errorf("rejected reload was not logged:\n%s", output.String())
+	}
+}
@@ -0,0 +1,121 @@
+package main_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	main "github.com/nhht77/earth-rest-api/server"
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+)
+
+func TestCountrySeries(t *testing.T) {
+	RequireDB(t)
+
+	main.DB = DB
+	main.AppConfig = AppConfig
+
+	var (
+		ctx     = context.Background()
+		router  = main.NewRouter()
+		creator = &pkg_v1.UserMinimal{Email: "series@earth.test", Name: "series"}
+	)
+
+	continent, err := DB.CreateContinent(ctx, nil, &pkg_v1.Continent{
+		Name:      "Oceania",
+		Type:      pkg_v1.ContinentType_Oceania,
+		AreaByKm2: 8525989,
+		Creator:   creator,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { DB.SoftDeleteContinent(context.Background(), nil, continent.Uuid.String()) })
+
+	countries := map[string]*pkg_v1.Country{}
+	for _, name := range []string{"Fiji", "Tonga"} {
+		countries[name], err = DB.CreateCountry(ctx, nil, &pkg_v1.Country{
+			ContinentUuid: continent.Uuid,
+			Name:          name,
+			Details:       &pkg_v1.CountryDetails{PhoneCode: "series-" + name, ISOCode: "Q" + name[:1], Currency: "SER"},
+			Creator:       creator,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	call := func(method string, path string, body interface{}, dest interface{}) int {
+		payload, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewReader(payload)))
+		if w.Code == http.StatusOK && dest != nil {
+			if err := json.Unmarshal(w.Body.Bytes(), dest); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w.Code
+	}
+
+	var (
+		fiji            = "/api/v1/countries/" + countries["Fiji"].Uuid.String() + "/series"
+		population      = func(v int64) *int64 { return &v }
+		number          = func(v float64) *float64 { return &v }
+		upserted        = pkg_v1.CountrySeries{}
+		fiji_population = population(924610)
+	)
+
+	if code := call("PUT", fiji, pkg_v1.CountrySeries{
+		{Year: 2020, Population: population(900000), AreaByKm2: number(18274), GDP: number(4.5e9)},
+		{Year: 2021, Population: fiji_population},
+	}, &upserted); code != http.StatusOK || len(upserted) != 2 {
+		t.Fatalf("upsert answered %d with %v", code, upserted)
+	}
+
+	// an omitted value keeps the stored one
+	if code := call("PUT", fiji, pkg_v1.CountrySeries{{Year: 2020, GDP: number(4.6e9)}}, &upserted); code != http.StatusOK || *upserted[0].Population != 900000 || *upserted[0].GDP != 4.6e9 {
+		t.Fatalf("upsert answered %d with %v", code, upserted)
+	}
+
+	series := pkg_v1.CountrySeries{}
+	call("GET", fiji+"?from=2021&to=2030", nil, &series)
+	if len(series) != 1 || series[0].Year != 2021 || series[0].GDP != nil {
+		t.Fatalf("expected 2021 only, got %v", series)
+	}
+
+	// latest values come from the latest year that has them
+	country, err := DB.CountryByUuid(ctx, nil, countries["Fiji"].Uuid.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *country.Population != *fiji_population || *country.AreaByKm2 != 18274 || *country.GDP != 4.6e9 {
+		t.Errorf("unexpected latest values %d %f %f", *country.Population, *country.AreaByKm2, *country.GDP)
+	}
+
+	listed := []*pkg_v1.Country{}
+	call("GET", "/api/v1/countries?sort=-population&countries="+countries["Tonga"].Uuid.String()+","+countries["Fiji"].Uuid.String(), nil, &listed)
+	if len(listed) != 2 || listed[0].Name != "Fiji" || listed[1].Population != nil {
+		t.Errorf("expected Fiji first and Tonga without population, got %v", listed)
+	}
+
+	filtered := []*pkg_v1.Country{}
+	call("GET", "/api/v1/countries?filter[population][gte]=900000&countries="+countries["Tonga"].Uuid.String()+","+countries["Fiji"].Uuid.String(), nil, &filtered)
+	if len(filtered) != 1 || filtered[0].Name != "Fiji" {
+		t.Errorf("expected Fiji only, got %v", filtered)
+	}
+
+	for _, body := range []pkg_v1.CountrySeries{
+		{},
+		{{Year: 2020}},
+		{{Year: 0, Population: population(1)}},
+		{{Year: 2020, Population: population(-1)}},
+		{{Year: 2020, GDP: number(1)}, {Year: 2020, GDP: number(2)}},
+	} {
+		if code := call("PUT", fiji, body, nil); code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %v, got %d", body, code)
+		}
+	}
+}
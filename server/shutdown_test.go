@@ -0,0 +1,126 @@
+package main_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	main "github.com/nhht77/earth-rest-api/server"
+)
+
+// serveDrainable serves handler on a local port with a cancellable base
+// context, as RunHTTP does.
+func serveDrainable(t *testing.T, handler http.HandlerFunc) (*http.Server, string, context.CancelFunc) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base_ctx, cancel_base := context.WithCancel(context.Background())
+	t.Cleanup(cancel_base)
+
+	server := main.NewHTTPServer(listener.Addr().String(), handler, main.DefaultFrameworkConfig())
+	server.BaseContext = func(net.Listener) context.Context { return base_ctx }
+
+	go server.Serve(listener)
+	return server, "http://" + listener.Addr().String(), cancel_base
+}
+
+func TestDrainHTTPWaitsForInFlight(t *testing.T) {
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+	server, url, cancel_base := serveDrainable(t, func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("drained"))
+	})
+
+	type result struct {
+		body string
+		err  error
+	}
+	response := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			response <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		response <- result{string(body), err}
+	}()
+	<-started
+
+	drained := make(chan struct{})
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		main.DrainHTTP(ctx, server, cancel_base)
+		close(drained)
+	}()
+
+	// new connections are refused while the in-flight request finishes
+	deadline := time.Now().Add(time.Second)
+	for {
+		conn, err := net.DialTimeout("tcp", server.Addr, 100*time.Millisecond)
+		if err != nil {
+			break
+		}
+		conn.Close()
+		if time.Now().After(deadline) {
+			t.Fatal("listener still accepts connections after shutdown started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case <-drained:
+		t.Fatal("DrainHTTP returned before the in-flight request finished")
+	default:
+	}
+
+	close(release)
+	if got := <-response; got.err != nil || got.body != "drained" {
+		t.Errorf("in-flight request = %q, %v", got.body, got.err)
+	}
+	<-drained
+}
+
+func TestDrainHTTPCancelsAfterDeadline(t *testing.T) {
+	var (
+		started   = make(chan struct{})
+		cancelled = make(chan error, 1)
+	)
+	server, url, cancel_base := serveDrainable(t, func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		// a query would be aborted by the same request context
+		<-r.Context().Done()
+		cancelled <- r.Context().Err()
+	})
+
+	go func() {
+		if resp, err := http.Get(url); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	main.DrainHTTP(ctx, server, cancel_base)
+
+	select {
+	case err := <-cancelled:
+		if err != context.Canceled {
+			t.Errorf("request context error %v, expected %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("request context was not cancelled after the drain deadline")
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/nhht77/earth-rest-api/server/pkg/mhttp"
+)
+
+// Sort is one field of sort=, descending with a - prefix.
+type Sort struct {
+	Field string
+	Desc  bool
+
+	// SQL expression of Field
+	column string
+}
+
+type SortList []Sort
+
+// sortable fields of each entity, expressions are fixed here like filter fields
+var countrySortFields = map[string]string{
+	"name":        "name",
+	"created":     "created",
+	"updated":     "updated",
+	"population":  "population",
+	"area_by_km2": "area_by_km2",
+	"gdp":         "gdp",
+}
+
+var citySortFields = map[string]string{
+	"name":           "name",
+	"created":        "created",
+	"updated":        "updated",
+	"population":     "population",
+	"elevation_by_m": "elevation_by_m",
+}
+
+// SortFromQuery reads sort=-population,name against the sortable fields of
+// an entity, earlier fields take precedence.
+func SortFromQuery(r *http.Request, fields map[string]string) (SortList, error) {
+	var (
+		list = SortList{}
+		seen = map[string]bool{}
+	)
+
+	for _, iter := range mhttp.QueryList(r, "sort", ",") {
+		if len(iter) == 0 {
+			continue
+		}
+
+		curr := Sort{Field: strings.TrimPrefix(iter, "-"), Desc: strings.HasPrefix(iter, "-")}
+
+		column, ok := fields[curr.Field]
+		if !ok {
+			return nil, fmt.Errorf("unknown sort field %q", curr.Field)
+		}
+		if seen[curr.Field] {
+			return nil, fmt.Errorf("sort field %q listed twice", curr.Field)
+		}
+		seen[curr.Field] = true
+
+		curr.column = column
+		list = append(list, curr)
+	}
+	return list, nil
+}
+
+// OrderBy returns the ORDER BY clause of list, rows without value last and
+// index breaking ties so pages stay stable.
+func (list SortList) OrderBy() string {
+	order := "ORDER BY "
+	for _, iter := range list {
+		direction := "ASC"
+		if iter.Desc {
+			direction = "DESC"
+		}
+		order += fmt.Sprintf("%s %s NULLS LAST, ", iter.column, direction)
+	}
+	return order + "index"
+}
+
+// String lists the sort for cache keys.
+func (list SortList) String() string {
+	fields := []string{}
+	for _, iter := range list {
+		if iter.Desc {
+			fields = append(fields, "-"+iter.Field)
+			continue
+		}
+		fields = append(fields, iter.Field)
+	}
+	return strings.Join(fields, ",")
+}
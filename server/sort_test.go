@@ -0,0 +1,46 @@
+package main_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	main "github.com/nhht77/earth-rest-api/server"
+)
+
+func TestCountrySort(t *testing.T) {
+	options, err := main.CountryOptionsFromQuery(httptest.NewRequest("GET", "/api/v1/countries?sort=-population,name&filter[population][gte]=1000000&filter[gdp][lt]=2.5e12", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if order := options.Sort.OrderBy(); order != "ORDER BY population DESC NULLS LAST, name ASC NULLS LAST, index" {
+		t.Errorf("unexpected order %q", order)
+	}
+	if sort := options.Sort.String(); sort != "-population,name" {
+		t.Errorf("unexpected sort %q", sort)
+	}
+
+	args := []interface{}{}
+	if where := options.Filters.Where(&args); where != "AND gdp < $1 AND population >= $2 " {
+		t.Errorf("unexpected conditions %q", where)
+	}
+	// bigint columns take integer arguments
+	if population, ok := args[1].(int64); !ok || population != 1000000 {
+		t.Errorf("expected an int64 population, got %T %v", args[1], args[1])
+	}
+
+	if none, _ := main.CountryOptionsFromQuery(httptest.NewRequest("GET", "/api/v1/countries", nil)); none.Sort.OrderBy() != "ORDER BY index" {
+		t.Errorf("expected creation order by default, got %q", none.Sort.OrderBy())
+	}
+
+	for _, query := range []string{
+		"/api/v1/countries?sort=details",
+		"/api/v1/countries?sort=name,-name",
+		"/api/v1/countries?sort=index%3Bdrop%20table%20country",
+		"/api/v1/countries?filter[population][gt]=1.5",
+	} {
+		if _, err := main.CountryOptionsFromQuery(httptest.NewRequest("GET", query, nil)); err == nil {
+			t.Errorf("expected an error for %s", query)
+		}
+	}
+}
@@ -22,6 +22,23 @@ CREATE TABLE IF NOT EXISTS country (
     deleted_state smallint default 0
 );
 
+-- latest values of country_series
+ALTER TABLE country ADD COLUMN IF NOT EXISTS population bigint;
+ALTER TABLE country ADD COLUMN IF NOT EXISTS area_by_km2 float;
+ALTER TABLE country ADD COLUMN IF NOT EXISTS gdp float;
+
+CREATE TABLE IF NOT EXISTS country_series (
+    index bigserial PRIMARY KEY,
+    country_index bigint REFERENCES country(index),
+    year smallint NOT NULL,
+    population bigint,
+    area_by_km2 float,
+    gdp float,
+    created timestamp DEFAULT NOW(),
+    updated timestamp,
+    UNIQUE (country_index, year)
+);
+
 CREATE TABLE IF NOT EXISTS city (
     index bigserial PRIMARY KEY,
     continent_index bigint REFERENCES continent(index),
@@ -33,4 +50,84 @@ CREATE TABLE IF NOT EXISTS city (
     updated timestamp,
     creator jsonb,
     deleted_state smallint default 0
+);
+
+ALTER TABLE city ADD COLUMN IF NOT EXISTS population bigint;
+ALTER TABLE city ADD COLUMN IF NOT EXISTS timezone text NOT NULL DEFAULT '';
+ALTER TABLE city ADD COLUMN IF NOT EXISTS elevation_by_m float;
+ALTER TABLE city ADD COLUMN IF NOT EXISTS postal_code_patterns text[] NOT NULL DEFAULT '{}';
+ALTER TABLE city ADD COLUMN IF NOT EXISTS alternate_names text[] NOT NULL DEFAULT '{}';
+
+CREATE TABLE IF NOT EXISTS change_log (
+    index bigserial PRIMARY KEY,
+    entity text NOT NULL,
+    operation text NOT NULL,
+    uuid uuid NOT NULL,
+    continent_uuid uuid,
+    continent_type smallint,
+    created timestamp DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_subscription (
+    index bigserial PRIMARY KEY,
+    uuid uuid NOT NULL UNIQUE,
+    url text NOT NULL,
+    secret text NOT NULL,
+    events jsonb,
+    created timestamp DEFAULT NOW(),
+    updated timestamp,
+    creator jsonb,
+    deleted_state smallint default 0
+);
+
+CREATE TABLE IF NOT EXISTS webhook_outbox (
+    index bigserial PRIMARY KEY,
+    uuid uuid NOT NULL UNIQUE,
+    event text NOT NULL,
+    payload jsonb NOT NULL,
+    dispatched boolean default false,
+    created timestamp DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS webhook_outbox_pending ON webhook_outbox (index) WHERE dispatched = false;
+
+CREATE TABLE IF NOT EXISTS webhook_delivery (
+    index bigserial PRIMARY KEY,
+    uuid uuid NOT NULL UNIQUE,
+    subscription_index bigint REFERENCES webhook_subscription(index),
+    outbox_index bigint REFERENCES webhook_outbox(index),
+    state smallint default 0,
+    attempts int default 0,
+    next_attempt timestamp DEFAULT NOW(),
+    last_error text,
+    created timestamp DEFAULT NOW(),
+    updated timestamp
+);
+
+CREATE INDEX IF NOT EXISTS webhook_delivery_pending ON webhook_delivery (next_attempt) WHERE state = 0;
+
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempt (
+    index bigserial PRIMARY KEY,
+    delivery_index bigint REFERENCES webhook_delivery(index),
+    attempt int NOT NULL,
+    status_code int,
+    response text,
+    error text,
+    duration_ms float,
+    created timestamp DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS idempotency_key (
+    index bigserial PRIMARY KEY,
+    key text NOT NULL,
+    method text NOT NULL,
+    path text NOT NULL,
+    scope text NOT NULL,
+    request_hash text NOT NULL,
+    status_code int,
+    content_type text,
+    response text,
+    created timestamp DEFAULT NOW(),
+    claimed_at timestamp DEFAULT NOW(),
+    UNIQUE (key, method, path, scope)
 );
\ No newline at end of file
@@ -4,4 +4,42 @@ BEGIN
     NEW.updated = NOW();
     RETURN NEW;
 END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION trigger_change_log()
+RETURNS TRIGGER AS $$
+DECLARE
+    curr record;
+    curr_operation text;
+    curr_continent_uuid uuid;
+    curr_continent_type smallint;
+    curr_index bigint;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        curr = OLD;
+    ELSE
+        curr = NEW;
+    END IF;
+
+    curr_operation = lower(TG_OP);
+    IF TG_OP = 'UPDATE' AND OLD.deleted_state = 0 AND NEW.deleted_state != 0 THEN
+        curr_operation = 'delete';
+    END IF;
+
+    IF TG_TABLE_NAME = 'continent' THEN
+        curr_continent_uuid = curr.uuid;
+        curr_continent_type = curr.type;
+    ELSE
+        SELECT uuid, type INTO curr_continent_uuid, curr_continent_type
+            FROM continent WHERE index = curr.continent_index;
+    END IF;
+
+    INSERT INTO change_log(entity, operation, uuid, continent_uuid, continent_type)
+        VALUES(TG_TABLE_NAME, curr_operation, curr.uuid, curr_continent_uuid, curr_continent_type)
+        RETURNING index INTO curr_index;
+
+    -- listeners read the log from the last index they saw
+    PERFORM pg_notify('earth_changes', curr_index::text);
+    RETURN NULL;
+END;
 $$ LANGUAGE plpgsql;
\ No newline at end of file
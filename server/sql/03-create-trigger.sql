@@ -8,7 +8,37 @@ CREATE TRIGGER country_updated
     BEFORE UPDATE ON country FOR EACH ROW
     EXECUTE PROCEDURE trigger_timestamp_updated();
 
+-- condition: SELECT EXISTS(SELECT tgname FROM pg_trigger WHERE tgname = 'country_series_updated') condition --
+CREATE TRIGGER country_series_updated
+    BEFORE UPDATE ON country_series FOR EACH ROW
+    EXECUTE PROCEDURE trigger_timestamp_updated();
+
 -- condition: SELECT EXISTS(SELECT tgname FROM pg_trigger WHERE tgname = 'city_updated') condition --
 CREATE TRIGGER city_updated
     BEFORE UPDATE ON city FOR EACH ROW
+    EXECUTE PROCEDURE trigger_timestamp_updated();
+
+-- condition: SELECT EXISTS(SELECT tgname FROM pg_trigger WHERE tgname = 'continent_changed') condition --
+CREATE TRIGGER continent_changed
+    AFTER INSERT OR UPDATE OR DELETE ON continent FOR EACH ROW
+    EXECUTE PROCEDURE trigger_change_log();
+
+-- condition: SELECT EXISTS(SELECT tgname FROM pg_trigger WHERE tgname = 'country_changed') condition --
+CREATE TRIGGER country_changed
+    AFTER INSERT OR UPDATE OR DELETE ON country FOR EACH ROW
+    EXECUTE PROCEDURE trigger_change_log();
+
+-- condition: SELECT EXISTS(SELECT tgname FROM pg_trigger WHERE tgname = 'city_changed') condition --
+CREATE TRIGGER city_changed
+    AFTER INSERT OR UPDATE OR DELETE ON city FOR EACH ROW
+    EXECUTE PROCEDURE trigger_change_log();
+
+-- condition: SELECT EXISTS(SELECT tgname FROM pg_trigger WHERE tgname = 'webhook_subscription_updated') condition --
+CREATE TRIGGER webhook_subscription_updated
+    BEFORE UPDATE ON webhook_subscription FOR EACH ROW
+    EXECUTE PROCEDURE trigger_timestamp_updated();
+
+-- condition: SELECT EXISTS(SELECT tgname FROM pg_trigger WHERE tgname = 'webhook_delivery_updated') condition --
+CREATE TRIGGER webhook_delivery_updated
+    BEFORE UPDATE ON webhook_delivery FOR EACH ROW
     EXECUTE PROCEDURE trigger_timestamp_updated();
\ No newline at end of file
@@ -0,0 +1,40 @@
+package main_test
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	main "github.com/nhht77/earth-rest-api/server"
+)
+
+func TestStatsOptions(t *testing.T) {
+	options, err := main.StatsOptionsFromQuery(httptest.NewRequest("GET", "/api/v1/stats?bucket=week&from=2022-03-10&to=2022-04-01T12:00:00Z", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// weeks start on Monday, as date_trunc does
+	if expected := time.Date(2022, 3, 7, 0, 0, 0, 0, time.UTC); !options.From.Equal(expected) {
+		t.Errorf("expected from %s, got %s", expected, options.From)
+	}
+
+	options, err = main.StatsOptionsFromQuery(httptest.NewRequest("GET", "/api/v1/stats?bucket=month&to=2022-04-15", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC); !options.From.Equal(expected) {
+		t.Errorf("expected 12 months by default from %s, got %s", expected, options.From)
+	}
+
+	for _, query := range []string{
+		"/api/v1/stats?bucket=year",
+		"/api/v1/stats?from=yesterday",
+		"/api/v1/stats?from=2022-04-02&to=2022-04-01",
+		"/api/v1/stats?bucket=day&from=2000-01-01&to=2022-01-01",
+		"/api/v1/stats?bucket=day%27)%3B--",
+	} {
+		if _, err := main.StatsOptionsFromQuery(httptest.NewRequest("GET", query, nil)); err == nil {
+			t.Errorf("expected an error for %s", query)
+		}
+	}
+}
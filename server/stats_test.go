@@ -0,0 +1,97 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	main "github.com/nhht77/earth-rest-api/server"
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+)
+
+func TestStats(t *testing.T) {
+	RequireDB(t)
+
+	main.DB = DB
+	main.AppConfig = AppConfig
+
+	var (
+		ctx     = context.Background()
+		router  = main.NewRouter()
+		creator = &pkg_v1.UserMinimal{Email: "stats@earth.test", Name: "stats"}
+	)
+
+	get := func() *pkg_v1.Stats {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/stats?bucket=day", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("stats answered %d: %s", w.Code, w.Body.String())
+		}
+		stats := &pkg_v1.Stats{}
+		if err := json.Unmarshal(w.Body.Bytes(), stats); err != nil {
+			t.Fatal(err)
+		}
+		return stats
+	}
+
+	var timezone string
+	if err := DB.QueryRow(ctx, nil, "SHOW timezone").Scan(&timezone); err != nil || timezone != "UTC" {
+		t.Fatalf("expected the session in UTC, got %q %v", timezone, err)
+	}
+
+	before := get()
+	if len(before.ContinentTypes) != 7 || before.Rows["city"] == nil || len(before.Activity.Buckets) < 30 {
+		t.Fatalf("expected 7 continent types, city rows & 30 days of activity, got %+v", before)
+	}
+
+	continent, err := DB.CreateContinent(ctx, nil, &pkg_v1.Continent{
+		Name:      "Antarctica",
+		Type:      pkg_v1.ContinentType_Antarctica,
+		AreaByKm2: 14200000,
+		Creator:   creator,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { DB.SoftDeleteContinent(context.Background(), nil, continent.Uuid.String()) })
+
+	country, err := DB.CreateCountry(ctx, nil, &pkg_v1.Country{
+		ContinentUuid: continent.Uuid,
+		Name:          "Ross Dependency",
+		Details:       &pkg_v1.CountryDetails{PhoneCode: "stats-ross", ISOCode: "SR", Currency: "STA"},
+		Creator:       creator,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = DB.CreateCity(ctx, nil, &pkg_v1.City{
+		ContinentUuid: continent.Uuid,
+		CountryUuid:   country.Uuid,
+		Name:          "Scott Base",
+		Details:       &pkg_v1.CityDetails{},
+		Creator:       creator,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	after := get()
+	antarctica, previous := after.ContinentTypes[6], before.ContinentTypes[6]
+	if antarctica.Type != pkg_v1.ContinentType_Antarctica ||
+		antarctica.Continents != previous.Continents+1 ||
+		antarctica.Countries != previous.Countries+1 ||
+		antarctica.Cities != previous.Cities+1 ||
+		antarctica.AreaByKm2 != previous.AreaByKm2+14200000 {
+		t.Errorf("expected one more Antarctica continent, country & city, got %+v from %+v", antarctica, previous)
+	}
+	if after.Rows["city"].Live != before.Rows["city"].Live+1 {
+		t.Errorf("expected one more live city, got %+v", after.Rows["city"])
+	}
+
+	today := after.Activity.Buckets[len(after.Activity.Buckets)-1]
+	earlier := before.Activity.Buckets[len(before.Activity.Buckets)-1]
+	if today.Start.Equal(earlier.Start) && today.City.Created != earlier.City.Created+1 {
+		t.Errorf("expected one more city created today, got %+v", today.City)
+	}
+}
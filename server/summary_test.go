@@ -0,0 +1,99 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	main "github.com/nhht77/earth-rest-api/server"
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+)
+
+func TestCountrySummary(t *testing.T) {
+	RequireDB(t)
+
+	main.DB = DB
+	main.AppConfig = AppConfig
+
+	var (
+		ctx     = context.Background()
+		router  = main.NewRouter()
+		creator = &pkg_v1.UserMinimal{Email: "summary@earth.test", Name: "summary"}
+	)
+
+	continent, err := DB.CreateContinent(ctx, nil, &pkg_v1.Continent{
+		Name:      "Africa",
+		Type:      pkg_v1.ContinentType_Africa,
+		AreaByKm2: 30370000,
+		Creator:   creator,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { DB.SoftDeleteContinent(context.Background(), nil, continent.Uuid.String()) })
+
+	countries := map[string]*pkg_v1.Country{}
+	for _, name := range []string{"Kenya", "Chad"} {
+		countries[name], err = DB.CreateCountry(ctx, nil, &pkg_v1.Country{
+			ContinentUuid: continent.Uuid,
+			Name:          name,
+			Details:       &pkg_v1.CountryDetails{PhoneCode: "summary-" + name, ISOCode: "S" + name[:1], Currency: "SUM"},
+			Creator:       creator,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, name := range []string{"Nairobi", "Mombasa", "Kisumu"} {
+		if _, err = DB.CreateCity(ctx, nil, &pkg_v1.City{
+			ContinentUuid: continent.Uuid,
+			CountryUuid:   countries["Kenya"].Uuid,
+			Name:          name,
+			Details:       &pkg_v1.CityDetails{IsCapital: i == 0},
+			Creator:       creator,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	get := func(path string, dest interface{}) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s answered %d: %s", path, w.Code, w.Body.String())
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), dest); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	summary := &pkg_v1.CountrySummary{}
+	get("/api/v1/countries/"+countries["Kenya"].Uuid.String()+"/summary", summary)
+	if summary.CityCount != 3 || summary.Capital == nil || summary.Capital.Name != "Nairobi" {
+		t.Fatalf("expected 3 cities with Nairobi as capital, got %+v", summary)
+	}
+	if summary.Capital.CountryUuid != countries["Kenya"].Uuid {
+		t.Errorf("expected the capital in Kenya, got %s", summary.Capital.CountryUuid)
+	}
+
+	summaries := []*pkg_v1.CountrySummary{}
+	get("/api/v1/countries/summary?countries="+countries["Kenya"].Uuid.String()+","+countries["Chad"].Uuid.String(), &summaries)
+	if len(summaries) != 2 || summaries[1].Name != "Chad" || summaries[1].CityCount != 0 || summaries[1].Capital != nil {
+		t.Fatalf("expected Kenya & Chad without cities, got %v", summaries)
+	}
+
+	capitals := []*pkg_v1.City{}
+	get("/api/v1/cities?is_capital=true&countries="+countries["Kenya"].Uuid.String(), &capitals)
+	if len(capitals) != 1 || capitals[0].Name != "Nairobi" {
+		t.Errorf("expected Nairobi only, got %v", capitals)
+	}
+
+	others := []*pkg_v1.City{}
+	get("/api/v1/cities?is_capital=false&countries="+countries["Kenya"].Uuid.String(), &others)
+	if len(others) != 2 {
+		t.Errorf("expected 2 other cities, got %v", others)
+	}
+}
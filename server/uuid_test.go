@@ -0,0 +1,103 @@
+package main_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	main "github.com/nhht77/earth-rest-api/server"
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+	muuid "github.com/nhht77/earth-rest-api/server/pkg/muuid"
+)
+
+func serveJSON(router http.Handler, method string, url string, body interface{}) *httptest.ResponseRecorder {
+	body_b, _ := json.Marshal(body)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(method, url, bytes.NewReader(body_b)))
+	return w
+}
+
+func TestCreateWithClientUuid(t *testing.T) {
+	RequireDB(t)
+
+	main.DB = DB
+	main.AppConfig = AppConfig
+
+	var (
+		router    = main.NewRouter()
+		uuid      = muuid.NewUUID()
+		continent = &pkg_v1.Continent{
+			Uuid:      uuid,
+			Name:      "Africa",
+			Type:      pkg_v1.ContinentType_Africa,
+			AreaByKm2: 30370000,
+			Creator:   &pkg_v1.UserMinimal{Email: "uuid@earth.test", Name: "uuid"},
+		}
+	)
+
+	created := serveJSON(router, "POST", "/api/v1/continent/create", continent)
+	if created.Code != http.StatusOK {
+		t.Fatalf("create answered %d: %s", created.Code, created.Body.String())
+	}
+	result := &pkg_v1.Continent{}
+	json.Unmarshal(created.Body.Bytes(), result)
+	if result.Uuid != uuid {
+		t.Fatalf("created uuid %s, expected %s", result.Uuid, uuid)
+	}
+
+	if err := DB.SoftDeleteContinent(context.Background(), nil, uuid.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	// soft-deleted uuids are not reused
+	if again := serveJSON(router, "POST", "/api/v1/continent/create", continent); again.Code != http.StatusConflict {
+		t.Errorf("create with a deleted uuid answered %d, expected 409", again.Code)
+	}
+	if upsert := serveJSON(router, "PUT", "/api/v1/continent?uuid="+uuid.String(), continent); upsert.Code != http.StatusConflict {
+		t.Errorf("upsert of a deleted uuid answered %d, expected 409", upsert.Code)
+	}
+}
+
+func TestUpsertByUuid(t *testing.T) {
+	RequireDB(t)
+
+	main.DB = DB
+	main.AppConfig = AppConfig
+
+	var (
+		router    = main.NewRouter()
+		uuid      = muuid.NewUUID()
+		url       = "/api/v1/continent?uuid=" + uuid.String()
+		continent = &pkg_v1.Continent{
+			Name:      "South America",
+			Type:      pkg_v1.ContinentType_South_America,
+			AreaByKm2: 17840000,
+			Creator:   &pkg_v1.UserMinimal{Email: "uuid@earth.test", Name: "uuid"},
+		}
+	)
+	defer DB.SoftDeleteContinent(context.Background(), nil, uuid.String())
+
+	if created := serveJSON(router, "PUT", url, continent); created.Code != http.StatusCreated {
+		t.Fatalf("first upsert answered %d: %s", created.Code, created.Body.String())
+	}
+
+	continent.AreaByKm2 = 17840001
+	updated := serveJSON(router, "PUT", url, continent)
+	if updated.Code != http.StatusOK {
+		t.Fatalf("second upsert answered %d: %s", updated.Code, updated.Body.String())
+	}
+	result := &pkg_v1.Continent{}
+	json.Unmarshal(updated.Body.Bytes(), result)
+	if result.Uuid != uuid || result.AreaByKm2 != 17840001 {
+		t.Errorf("upsert did not update continent %s: %+v", uuid, result)
+	}
+
+	continent.Uuid = muuid.NewUUID()
+	if mismatch := serveJSON(router, "PUT", url, continent); mismatch.Code != http.StatusBadRequest {
+		t.Errorf("upsert with differing uuids answered %d, expected 400", mismatch.Code)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+	"github.com/nhht77/earth-rest-api/server/pkg/mmetrics"
+	"github.com/nhht77/earth-rest-api/server/pkg/mwebhook"
+)
+
+// outbox events dispatched & deliveries sent per round
+const WebhookBatchSize = 50
+
+var (
+	Webhooks = NewWebhookWorker()
+
+	webhookDeliveriesTotal = mmetrics.NewCounterVec(
+		"earth_webhook_deliveries_total",
+		"Webhook delivery attempts by result (delivered, retry or dead).",
+		"result",
+	)
+)
+
+func init() {
+	Metrics.Register(webhookDeliveriesTotal)
+}
+
+// WebhookWorker moves outbox events to deliveries and sends the due ones.
+// Every state change is stored, so several servers may run a worker.
+type WebhookWorker struct {
+	config WebhookConfig
+	client *http.Client
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewWebhookWorker() *WebhookWorker {
+	return &WebhookWorker{wake: make(chan struct{}, 1)}
+}
+
+func (worker *WebhookWorker) Configure(config WebhookConfig) {
+	worker.config = config
+	worker.client = mwebhook.NewClient(time.Duration(config.Timeout), config.AllowPrivateNetworks)
+}
+
+// CheckURL rejects a subscription url resolving to a private address, the
+// delivery dialer checks again on every connection.
+func (worker *WebhookWorker) CheckURL(ctx context.Context, url string) error {
+	if worker.config.AllowPrivateNetworks {
+		return nil
+	}
+	return mwebhook.CheckURL(ctx, url)
+}
+
+func (worker *WebhookWorker) Start(config WebhookConfig) {
+	worker.Configure(config)
+	worker.stop = make(chan struct{})
+	worker.done = make(chan struct{})
+
+	go worker.run()
+}
+
+// Wake runs a round now instead of at the next poll, called after writes.
+func (worker *WebhookWorker) Wake() {
+	select {
+	case worker.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (worker *WebhookWorker) Stop() {
+	if worker.stop == nil {
+		return
+	}
+	close(worker.stop)
+	<-worker.done
+	worker.stop = nil
+}
+
+func (worker *WebhookWorker) run() {
+	defer close(worker.done)
+
+	ticker := time.NewTicker(time.Duration(worker.config.PollInterval))
+	defer ticker.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-worker.stop
+		cancel()
+	}()
+
+	for {
+		select {
+		case <-worker.stop:
+			return
+		case <-ticker.C:
+		case <-worker.wake:
+		}
+		worker.RunOnce(WithPrimary(ctx))
+	}
+}
+
+// RunOnce dispatches the outbox then sends every due delivery, batch by batch.
+func (worker *WebhookWorker) RunOnce(ctx context.Context) {
+	for {
+		dispatched, err := DB.DispatchWebhookOutbox(ctx, WebhookBatchSize)
+		if err != nil || dispatched < WebhookBatchSize {
+			break
+		}
+	}
+
+	for ctx.Err() == nil {
+		// Note: the lease outlives the request timeout so a slow receiver is not sent twice
+		jobs, err := DB.ClaimWebhookDeliveries(ctx, WebhookBatchSize, 2*time.Duration(worker.config.Timeout))
+		if err != nil || len(jobs) == 0 {
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, iter := range jobs {
+			wg.Add(1)
+			go func(job *WebhookJob) {
+				defer wg.Done()
+				worker.deliver(ctx, job)
+			}(iter)
+		}
+		wg.Wait()
+
+		if len(jobs) < WebhookBatchSize {
+			return
+		}
+	}
+}
+
+func (worker *WebhookWorker) deliver(ctx context.Context, job *WebhookJob) {
+	result, err := mwebhook.Send(ctx, worker.client, mwebhook.Message{
+		URL:      job.URL,
+		Secret:   job.Secret,
+		Event:    job.Event,
+		Delivery: job.Uuid.String(),
+		Body:     job.Payload,
+	})
+
+	state, record_err := DB.RecordWebhookAttempt(ctx, job, result, err, worker.config)
+	if record_err != nil {
+		// the lease expired and another worker took the delivery over, or the
+		// database is unreachable and the delivery is sent again
+		Log.Warnf("[webhook] Delivery %s not recorded: %s", job.Uuid, record_err.Error())
+		return
+	}
+
+	switch state {
+	case pkg_v1.WebhookDeliveryState_Delivered:
+		webhookDeliveriesTotal.Inc("delivered")
+	case pkg_v1.WebhookDeliveryState_Dead:
+		webhookDeliveriesTotal.Inc("dead")
+		Log.Warnf("[webhook] Delivery %s of %s dead-lettered after %d attempts", job.Uuid, job.Event, job.Attempts+1)
+	default:
+		webhookDeliveriesTotal.Inc("retry")
+	}
+}
@@ -0,0 +1,242 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	main "github.com/nhht77/earth-rest-api/server"
+	pkg_v1 "github.com/nhht77/earth-rest-api/server/pkg"
+	"github.com/nhht77/earth-rest-api/server/pkg/mwebhook"
+)
+
+var webhookCreator = &pkg_v1.UserMinimal{Email: "webhook@earth.test", Name: "webhook"}
+
+func createWebhookSubscription(t *testing.T, url string, events ...string) *pkg_v1.WebhookSubscription {
+	subscription, err := DB.CreateWebhookSubscription(context.Background(), nil, &pkg_v1.WebhookSubscription{
+		URL:     url,
+		Events:  events,
+		Creator: webhookCreator,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subscription.Secret) == 0 {
+		t.Fatal("expected a generated secret")
+	}
+	t.Cleanup(func() { DB.SoftDeleteWebhookSubscription(context.Background(), subscription.Uuid.String()) })
+	return subscription
+}
+
+func createWebhookContinent(t *testing.T) *pkg_v1.Continent {
+	continent, err := DB.CreateContinent(context.Background(), nil, &pkg_v1.Continent{
+		Name:      "Oceania",
+		Type:      pkg_v1.ContinentType_Oceania,
+		AreaByKm2: 8525989,
+		Creator:   webhookCreator,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { DB.SoftDeleteContinent(context.Background(), nil, continent.Uuid.String()) })
+	return continent
+}
+
+func webhookDeliveries(t *testing.T, subscription *pkg_v1.WebhookSubscription) []*pkg_v1.WebhookDelivery {
+	deliveries, err := DB.WebhookDeliveriesByOptions(context.Background(), main.WebhookDeliveryQueryOptions{
+		SubscriptionUuid: subscription.Uuid.String(),
+		Limit:            10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return deliveries
+}
+
+func TestWebhookDeliveredToReceiver(t *testing.T) {
+	RequireDB(t)
+
+	main.DB = DB
+
+	var (
+		received = make(chan *pkg_v1.WebhookPayload, 1)
+		secret   = make(chan string, 1)
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := mwebhook.Verify(r, <-secret, time.Minute)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		payload := &pkg_v1.WebhookPayload{}
+		json.Unmarshal(body, payload)
+		received <- payload
+	}))
+	defer receiver.Close()
+
+	subscription := createWebhookSubscription(t, receiver.URL, "continent.insert")
+	secret <- subscription.Secret
+	continent := createWebhookContinent(t)
+
+	config := main.DefaultConfig().Webhook
+	config.AllowPrivateNetworks = true
+
+	worker := main.NewWebhookWorker()
+	worker.Configure(config)
+	worker.RunOnce(context.Background())
+
+	select {
+	case payload := <-received:
+		if payload.Event != "continent.insert" {
+			t.Errorf("event = %s, expected continent.insert", payload.Event)
+		}
+		if data, _ := payload.Data.(map[string]interface{}); data["uuid"] != continent.Uuid.String() {
+			t.Errorf("payload data %v does not describe continent %s", payload.Data, continent.Uuid)
+		}
+	default:
+		t.Fatal("receiver got no delivery")
+	}
+
+	deliveries := webhookDeliveries(t, subscription)
+	if len(deliveries) != 1 || deliveries[0].State != pkg_v1.WebhookDeliveryState_Delivered || len(deliveries[0].AttemptList) != 1 {
+		t.Fatalf("expected one delivered delivery with one attempt, got %+v", deliveries)
+	}
+}
+
+func TestWebhookDeadLetteredAndRetried(t *testing.T) {
+	RequireDB(t)
+
+	main.DB = DB
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	subscription := createWebhookSubscription(t, receiver.URL, "continent.insert")
+	createWebhookContinent(t)
+
+	config := main.DefaultConfig().Webhook
+	config.MaxAttempts = 1
+	config.AllowPrivateNetworks = true
+
+	worker := main.NewWebhookWorker()
+	worker.Configure(config)
+	worker.RunOnce(context.Background())
+
+	deliveries := webhookDeliveries(t, subscription)
+	if len(deliveries) != 1 || deliveries[0].State != pkg_v1.WebhookDeliveryState_Dead {
+		t.Fatalf("expected one dead delivery, got %+v", deliveries)
+	}
+	if attempt := deliveries[0].AttemptList[0]; attempt.StatusCode != http.StatusInternalServerError {
+		t.Errorf("attempt status = %d, expected 500", attempt.StatusCode)
+	}
+
+	if err := DB.RetryWebhookDelivery(context.Background(), deliveries[0].Uuid.String()); err != nil {
+		t.Fatal(err)
+	}
+	if deliveries = webhookDeliveries(t, subscription); deliveries[0].State != pkg_v1.WebhookDeliveryState_Pending {
+		t.Errorf("expected retried delivery to be pending, got %d", deliveries[0].State)
+	}
+}
+
+func TestWebhookPrivateUrlRejected(t *testing.T) {
+	for _, url := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://192.168.0.10/hook",
+	} {
+		body := `{"url":"` + url + `","events":["*"],"creator":{"email":"webhook@earth.test","name":"webhook"}}`
+
+		recorder := httptest.NewRecorder()
+		main.HandleCreateWebhook(recorder, httptest.NewRequest("POST", "/api/v1/webhooks", strings.NewReader(body)))
+		if recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), "not public") {
+			t.Errorf("create %s: %d %s, expected 400", url, recorder.Code, recorder.Body.String())
+		}
+	}
+}
+
+func TestWebhookDeleteEventOnce(t *testing.T) {
+	RequireDB(t)
+
+	main.DB = DB
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+
+	subscription := createWebhookSubscription(t, receiver.URL, "continent.delete")
+	continent := createWebhookContinent(t)
+
+	for i := 0; i < 2; i++ {
+		if err := DB.SoftDeleteContinent(context.Background(), nil, continent.Uuid.String()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := main.DefaultConfig().Webhook
+	config.AllowPrivateNetworks = true
+
+	worker := main.NewWebhookWorker()
+	worker.Configure(config)
+	worker.RunOnce(context.Background())
+
+	if deliveries := webhookDeliveries(t, subscription); len(deliveries) != 1 {
+		t.Fatalf("expected one delete delivery, got %d", len(deliveries))
+	}
+}
+
+func TestWebhookAttemptAfterLostLease(t *testing.T) {
+	RequireDB(t)
+
+	main.DB = DB
+
+	var (
+		ctx    = context.Background()
+		config = main.DefaultConfig().Webhook
+	)
+
+	subscription := createWebhookSubscription(t, "https://receiver.example/hook", "continent.insert")
+	createWebhookContinent(t)
+
+	if _, err := DB.DispatchWebhookOutbox(ctx, main.WebhookBatchSize); err != nil {
+		t.Fatal(err)
+	}
+	jobs, err := DB.ClaimWebhookDeliveries(ctx, main.WebhookBatchSize, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var job *main.WebhookJob
+	for _, iter := range jobs {
+		if iter.URL == subscription.URL {
+			job = iter
+		}
+	}
+	if job == nil {
+		t.Fatal("no delivery claimed for the subscription")
+	}
+
+	// the worker that took the delivery over after the lease delivered it
+	delivered := mwebhook.Result{StatusCode: http.StatusOK, Response: "ok"}
+	if state, err := DB.RecordWebhookAttempt(ctx, job, delivered, nil, config); err != nil || state != pkg_v1.WebhookDeliveryState_Delivered {
+		t.Fatalf("record = %d, %v", state, err)
+	}
+
+	failed := mwebhook.Result{StatusCode: http.StatusBadGateway, Response: "late\x00"}
+	if _, err := DB.RecordWebhookAttempt(ctx, job, failed, nil, config); !errors.Is(err, main.ErrWebhookLeaseLost) {
+		t.Fatalf("late record = %v, expected ErrWebhookLeaseLost", err)
+	}
+
+	deliveries := webhookDeliveries(t, subscription)
+	if len(deliveries) != 1 || deliveries[0].State != pkg_v1.WebhookDeliveryState_Delivered || len(deliveries[0].AttemptList) != 1 {
+		t.Fatalf("expected the delivery to stay delivered with one attempt, got %+v", deliveries)
+	}
+
+	if err := DB.RetryWebhookDelivery(ctx, deliveries[0].Uuid.String()); !main.DatabaseNoResults(err) {
+		t.Errorf("retry of a delivered delivery = %v, expected no rows", err)
+	}
+}